@@ -49,6 +49,8 @@ type Config struct {
 	AllowOverride bool              `yaml:"allow_override"`
 	Downloader    string            `yaml:"downloader"`
 	Player        PlayerConfig      `yaml:"player"`
+	Downloads     DownloadsConfig   `yaml:"downloads"`
+	Feeds         FeedsConfig       `yaml:"feeds"`
 	RFID          RFIDConfig        `yaml:"rfid"`
 	Startup       StartupConfig     `yaml:"startup"`
 	Log           LogConfig         `yaml:"log"`
@@ -56,19 +58,57 @@ type Config struct {
 }
 
 type PlayerConfig struct {
-	SongRoot  string `yaml:"song_root"`
-	ThumbRoot string `yaml:"thumb_root"`
-	Volume    int    `yaml:"volume"`
-	Loop      bool   `yaml:"loop"`
+	SongRoot  string   `yaml:"song_root"`
+	ThumbRoot string   `yaml:"thumb_root"`
+	Volume    int      `yaml:"volume"`
+	Loop      string   `yaml:"loop"`       // none, repeat-one or repeat-queue
+	Backend   string   `yaml:"backend"`    // ffplay or mpv
+	SleepFade Duration `yaml:"sleep_fade"` // how long the sleep timer fades out for; defaults to 30s
+	// ICYMetadata shows radio titles with backends that cannot report them, by
+	// downloading each stream a second time.
+	ICYMetadata bool `yaml:"icy_metadata"`
+}
+
+// DownloadsConfig tunes the download job queue. Zero values use its defaults.
+type DownloadsConfig struct {
+	Workers     int      `yaml:"workers"`      // downloads run at once; defaults to 2
+	MaxAttempts int      `yaml:"max_attempts"` // tries before a job fails; defaults to 4
+	Backoff     Duration `yaml:"backoff"`      // wait before the first retry, doubled each time; defaults to 30s
+	Retention   Duration `yaml:"retention"`    // how long finished jobs stay listed; defaults to 7 days
+}
+
+// FeedsConfig controls podcast feed polling.
+type FeedsConfig struct {
+	PollInterval Duration `yaml:"poll_interval"` // how often feeds are checked for new episodes
+	Keep         int      `yaml:"keep"`          // episodes kept per feed unless the feed sets its own
 }
 
 type RFIDConfig struct {
+	// Source is where tags are read from: reader (default; the chip chosen by
+	// Driver), evdev, stdin, pipe or replay.
+	Source string `yaml:"source"`
+	// Driver is the reader chip: mfrc522 (default, SPI), pn532-i2c or pn532-uart.
+	Driver   string `yaml:"driver"`
+	I2CBus   string `yaml:"i2c_bus"`   // pn532-i2c bus name; empty picks the first bus
+	UARTPort string `yaml:"uart_port"` // pn532-uart serial port; defaults to /dev/serial0
+	// Device is the input device, named pipe or replay script path for the
+	// evdev, pipe and replay sources.
+	Device         string   `yaml:"device"`
 	Cooldown       Duration `yaml:"cooldown"`
 	PollInterval   Duration `yaml:"poll_interval"`
 	ReadUIDTimeout Duration `yaml:"read_uid_timeout"`
 	SPIPort        string   `yaml:"spi_port"`
 	ResetPin       string   `yaml:"reset_pin"`
 	IRQPin         string   `yaml:"irq_pin"`
+	// OnRemove is what happens when the playing card is lifted off the reader:
+	// none (default), pause or stop. pause and stop turn on removal detection.
+	OnRemove     string   `yaml:"on_remove"`
+	RemovalGrace Duration `yaml:"removal_grace"` // how long a card may go unread before it counts as lifted
+}
+
+// DetectRemoval reports whether the reader should watch for cards being lifted.
+func (r RFIDConfig) DetectRemoval() bool {
+	return r.OnRemove == "pause" || r.OnRemove == "stop"
 }
 
 // CooldownOrDefault returns the configured cooldown or 2s if unset.
@@ -128,6 +168,12 @@ func defaults() *Config {
 			SongRoot:  "song_files",
 			ThumbRoot: "thumb_files",
 			Volume:    100,
+			Loop:      "none",
+			Backend:   "ffplay",
+		},
+		Feeds: FeedsConfig{
+			PollInterval: Duration{time.Hour},
+			Keep:         3,
 		},
 		Startup: StartupConfig{
 			Play: true,
@@ -161,6 +207,14 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("config: parse %s: %w", path, err)
 	}
 	cfg.path = path // yaml.Unmarshal overwrites unexported fields? No — it can't. Safe.
+
+	// player.loop used to be a bool that repeated the current song.
+	switch cfg.Player.Loop {
+	case "true":
+		cfg.Player.Loop = "repeat-one"
+	case "false", "":
+		cfg.Player.Loop = "none"
+	}
 	return cfg, nil
 }
 
@@ -176,26 +230,42 @@ func (c *Config) Save() error {
 // ToMap returns a flat string-keyed map compatible with the config template helpers.
 func (c *Config) ToMap() map[string]any {
 	return map[string]any{
-		"https":                 c.HTTPS,
-		"host":                  c.Host,
-		"rfid-enabled":          c.RFIDEnabled,
-		"beep":                  c.Beep,
-		"restart":               c.Restart,
-		"allow_override":        c.AllowOverride,
-		"downloader":            c.Downloader,
-		"player.song_root":      c.Player.SongRoot,
-		"player.thumb_root":     c.Player.ThumbRoot,
-		"player.volume":         c.Player.Volume,
-		"player.loop":           c.Player.Loop,
-		"startup.play":          c.Startup.Play,
-		"startup.file":          c.Startup.File,
-		"log.level":             c.Log.Level,
-		"log.format":            c.Log.Format,
-		"log.file":              c.Log.File,
-		"localtunnel.enabled":   c.Localtunnel.Enabled,
-		"localtunnel.host":      c.Localtunnel.Host,
-		"rfid.cooldown":         c.RFID.Cooldown.String(),
-		"rfid.poll_interval":    c.RFID.PollInterval.String(),
-		"rfid.read_uid_timeout": c.RFID.ReadUIDTimeout.String(),
+		"https":                  c.HTTPS,
+		"host":                   c.Host,
+		"rfid-enabled":           c.RFIDEnabled,
+		"beep":                   c.Beep,
+		"restart":                c.Restart,
+		"allow_override":         c.AllowOverride,
+		"downloader":             c.Downloader,
+		"player.song_root":       c.Player.SongRoot,
+		"player.thumb_root":      c.Player.ThumbRoot,
+		"player.volume":          c.Player.Volume,
+		"player.loop":            c.Player.Loop,
+		"player.backend":         c.Player.Backend,
+		"player.sleep_fade":      c.Player.SleepFade.String(),
+		"player.icy_metadata":    c.Player.ICYMetadata,
+		"downloads.workers":      c.Downloads.Workers,
+		"downloads.max_attempts": c.Downloads.MaxAttempts,
+		"downloads.backoff":      c.Downloads.Backoff.String(),
+		"downloads.retention":    c.Downloads.Retention.String(),
+		"feeds.poll_interval":    c.Feeds.PollInterval.String(),
+		"feeds.keep":             c.Feeds.Keep,
+		"startup.play":           c.Startup.Play,
+		"startup.file":           c.Startup.File,
+		"log.level":              c.Log.Level,
+		"log.format":             c.Log.Format,
+		"log.file":               c.Log.File,
+		"localtunnel.enabled":    c.Localtunnel.Enabled,
+		"localtunnel.host":       c.Localtunnel.Host,
+		"rfid.cooldown":          c.RFID.Cooldown.String(),
+		"rfid.poll_interval":     c.RFID.PollInterval.String(),
+		"rfid.read_uid_timeout":  c.RFID.ReadUIDTimeout.String(),
+		"rfid.on_remove":         c.RFID.OnRemove,
+		"rfid.source":            c.RFID.Source,
+		"rfid.driver":            c.RFID.Driver,
+		"rfid.i2c_bus":           c.RFID.I2CBus,
+		"rfid.uart_port":         c.RFID.UARTPort,
+		"rfid.device":            c.RFID.Device,
+		"rfid.removal_grace":     c.RFID.RemovalGrace.String(),
 	}
 }
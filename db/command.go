@@ -0,0 +1,73 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jaredwarren/rpi_music/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+const CommandBucket = "CommandBucket"
+
+// CommandStore is the read/write interface for command cards.
+type CommandStore interface {
+	GetCommandCard(rfid string) (*model.CommandCard, error)
+	ListCommandCards() ([]*model.CommandCard, error)
+	SetCommandCard(card *model.CommandCard) error
+	DeleteCommandCard(rfid string) error
+}
+
+func (s *SongDB) GetCommandCard(rfid string) (*model.CommandCard, error) {
+	var card *model.CommandCard
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket([]byte(CommandBucket)).Get([]byte(rfid))
+		if v == nil {
+			return ErrNotFound
+		}
+		card = &model.CommandCard{}
+		return json.Unmarshal(v, card)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func (s *SongDB) ListCommandCards() ([]*model.CommandCard, error) {
+	var out []*model.CommandCard
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(CommandBucket)).ForEach(func(k, v []byte) error {
+			var card model.CommandCard
+			if err := json.Unmarshal(v, &card); err != nil {
+				return err
+			}
+			out = append(out, &card)
+			return nil
+		})
+	})
+	return out, err
+}
+
+// SetCommandCard creates or replaces the command for card.RFID.
+func (s *SongDB) SetCommandCard(card *model.CommandCard) error {
+	if card.RFID == "" {
+		return fmt.Errorf("rfid required")
+	}
+	if _, err := model.ParseCommand(string(card.Command)); err != nil {
+		return err
+	}
+	buf, err := json.Marshal(card)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(CommandBucket)).Put([]byte(card.RFID), buf)
+	})
+}
+
+func (s *SongDB) DeleteCommandCard(rfid string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(CommandBucket)).Delete([]byte(rfid))
+	})
+}
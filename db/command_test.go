@@ -0,0 +1,34 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandCards(t *testing.T) {
+	d := newTestDB(t)
+	t.Cleanup(func() { require.NoError(t, d.Close()) })
+
+	_, err := d.GetCommandCard("rfid-1")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.Error(t, d.SetCommandCard(&model.CommandCard{RFID: "rfid-1", Command: "dance"}))
+	require.Error(t, d.SetCommandCard(&model.CommandCard{Command: model.CommandStop}))
+
+	want := &model.CommandCard{RFID: "rfid-1", Command: model.CommandSleep, SleepMinutes: 20}
+	require.NoError(t, d.SetCommandCard(want))
+	got, err := d.GetCommandCard("rfid-1")
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	require.NoError(t, d.SetCommandCard(&model.CommandCard{RFID: "rfid-2", Command: model.CommandNext}))
+	cards, err := d.ListCommandCards()
+	require.NoError(t, err)
+	require.Len(t, cards, 2)
+
+	require.NoError(t, d.DeleteCommandCard("rfid-1"))
+	_, err = d.GetCommandCard("rfid-1")
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jaredwarren/rpi_music/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+const FeedBucket = "FeedBucket"
+
+// FeedStore is the read/write interface for podcast subscriptions.
+type FeedStore interface {
+	GetFeed(id string) (*model.Feed, error)
+	ListFeeds() ([]*model.Feed, error)
+	SaveFeed(f *model.Feed) error
+	DeleteFeed(id string) error
+}
+
+func (s *SongDB) GetFeed(id string) (*model.Feed, error) {
+	var f *model.Feed
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket([]byte(FeedBucket)).Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+		f = &model.Feed{}
+		return json.Unmarshal(v, f)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *SongDB) ListFeeds() ([]*model.Feed, error) {
+	var out []*model.Feed
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(FeedBucket)).ForEach(func(k, v []byte) error {
+			var f model.Feed
+			if err := json.Unmarshal(v, &f); err != nil {
+				return err
+			}
+			out = append(out, &f)
+			return nil
+		})
+	})
+	return out, err
+}
+
+// SaveFeed creates or replaces f.
+func (s *SongDB) SaveFeed(f *model.Feed) error {
+	if f.ID == "" {
+		return fmt.Errorf("feed ID required")
+	}
+	buf, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(FeedBucket)).Put([]byte(f.ID), buf)
+	})
+}
+
+func (s *SongDB) DeleteFeed(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(FeedBucket)).Delete([]byte(id))
+	})
+}
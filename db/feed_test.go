@@ -0,0 +1,34 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeeds(t *testing.T) {
+	d := newTestDB(t)
+	t.Cleanup(func() { require.NoError(t, d.Close()) })
+
+	_, err := d.GetFeed("feed-1")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Error(t, d.SaveFeed(&model.Feed{URL: "u"}))
+
+	f := &model.Feed{
+		ID: "feed-1", URL: "https://example.com/feed.rss", RFID: "ABCD", Keep: 2,
+		Episodes: []model.FeedEpisode{{GUID: "ep-2", URL: "https://example.com/2.mp3", SongID: "song-2"}},
+	}
+	require.NoError(t, d.SaveFeed(f))
+	got, err := d.GetFeed("feed-1")
+	require.NoError(t, err)
+	require.Equal(t, f.Episodes, got.Episodes)
+
+	list, err := d.ListFeeds()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	require.NoError(t, d.DeleteFeed("feed-1"))
+	_, err = d.GetFeed("feed-1")
+	require.ErrorIs(t, err, ErrNotFound)
+}
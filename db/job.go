@@ -0,0 +1,91 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+const JobBucket = "DownloadJobBucket"
+
+// JobStore is the read/write interface for download jobs.
+type JobStore interface {
+	GetJob(id string) (*model.DownloadJob, error)
+	ListJobs() ([]*model.DownloadJob, error)
+	ListJobsByState(states ...model.JobState) ([]*model.DownloadJob, error)
+	SaveJob(job *model.DownloadJob) error
+	DeleteJob(id string) error
+}
+
+func (s *SongDB) GetJob(id string) (*model.DownloadJob, error) {
+	var job *model.DownloadJob
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket([]byte(JobBucket)).Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+		job = &model.DownloadJob{}
+		return json.Unmarshal(v, job)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// ListJobs returns every job, oldest first.
+func (s *SongDB) ListJobs() ([]*model.DownloadJob, error) {
+	return s.listJobs(func(*model.DownloadJob) bool { return true })
+}
+
+// ListJobsByState returns the jobs in any of states, oldest first.
+func (s *SongDB) ListJobsByState(states ...model.JobState) ([]*model.DownloadJob, error) {
+	return s.listJobs(func(job *model.DownloadJob) bool { return slices.Contains(states, job.State) })
+}
+
+func (s *SongDB) listJobs(keep func(*model.DownloadJob) bool) ([]*model.DownloadJob, error) {
+	var out []*model.DownloadJob
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(JobBucket)).ForEach(func(k, v []byte) error {
+			var job model.DownloadJob
+			if err := json.Unmarshal(v, &job); err != nil {
+				return err
+			}
+			if keep(&job) {
+				out = append(out, &job)
+			}
+			return nil
+		})
+	})
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, err
+}
+
+// SaveJob creates or replaces job, setting its timestamps.
+func (s *SongDB) SaveJob(job *model.DownloadJob) error {
+	if job.ID == "" {
+		return fmt.Errorf("job ID required")
+	}
+	job.UpdatedAt = time.Now()
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = job.UpdatedAt
+	}
+	buf, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(JobBucket)).Put([]byte(job.ID), buf)
+	})
+}
+
+func (s *SongDB) DeleteJob(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(JobBucket)).Delete([]byte(id))
+	})
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobs(t *testing.T) {
+	d := newTestDB(t)
+	t.Cleanup(func() { require.NoError(t, d.Close()) })
+
+	_, err := d.GetJob("job-1")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Error(t, d.SaveJob(&model.DownloadJob{URL: "u"}))
+
+	first := &model.DownloadJob{ID: "job-2", URL: "https://youtu.be/a", State: model.JobQueued}
+	require.NoError(t, d.SaveJob(first))
+	require.False(t, first.CreatedAt.IsZero())
+	second := &model.DownloadJob{ID: "job-1", URL: "https://youtu.be/b", State: model.JobQueued, CreatedAt: first.CreatedAt.Add(time.Second)}
+	require.NoError(t, d.SaveJob(second))
+
+	first.State, first.Attempts = model.JobFailed, 2
+	require.NoError(t, d.SaveJob(first))
+	got, err := d.GetJob("job-2")
+	require.NoError(t, err)
+	require.Equal(t, model.JobFailed, got.State)
+	require.Equal(t, 2, got.Attempts)
+	require.True(t, got.CreatedAt.Equal(first.CreatedAt))
+
+	jobs, err := d.ListJobs()
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	require.Equal(t, "job-2", jobs[0].ID) // oldest first
+	jobs, err = d.ListJobsByState(model.JobQueued, model.JobRunning)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	require.Equal(t, "job-1", jobs[0].ID)
+
+	require.NoError(t, d.DeleteJob("job-2"))
+	_, err = d.GetJob("job-2")
+	require.ErrorIs(t, err, ErrNotFound)
+}
@@ -1,7 +1,10 @@
 package db
 
 import (
+	"slices"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/jaredwarren/rpi_music/model"
 )
@@ -22,13 +25,30 @@ type MockDB struct {
 	ListRFIDSongsResult []*model.RFIDSong
 	ListRFIDSongsErr    error
 	DeleteSongErr       error
+	SetRFIDPlaybackErr  error
+	// GetCommandCardResult is returned by GetCommandCard; nil means ErrNotFound.
+	GetCommandCardResult   *model.CommandCard
+	ListCommandCardsResult []*model.CommandCard
+	// Jobs holds saved download jobs by ID; SaveJob creates it if nil.
+	Jobs map[string]*model.DownloadJob
+	// Playlists holds saved playlists by ID; SavePlaylist creates it if nil.
+	Playlists map[string]*model.Playlist
+	// Feeds holds saved feeds by ID; SaveFeed creates it if nil.
+	Feeds map[string]*model.Feed
+	// Positions holds saved resume positions by song ID; SavePosition creates it if nil.
+	Positions map[string]time.Duration
+	// DeleteSongCalls records the IDs passed to DeleteSong.
+	DeleteSongCalls []string
 
 	CreateSongCalls  []*model.Song
 	UpdateSongCalls  []*model.Song
 	AddRFIDSongCalls []AddRFIDSongCall
-	OnCreateSong     func(*model.Song)
-	OnUpdateSong     func(*model.Song)
-	OnAddRFIDSong    func(rfid, songID string)
+	// ReplaceRFIDSongCalls records the arguments passed to ReplaceRFIDSong.
+	ReplaceRFIDSongCalls []ReplaceRFIDSongCall
+	SetCommandCardCalls  []*model.CommandCard
+	OnCreateSong         func(*model.Song)
+	OnUpdateSong         func(*model.Song)
+	OnAddRFIDSong        func(rfid, songID string)
 }
 
 // AddRFIDSongCall records arguments passed to AddRFIDSong.
@@ -37,6 +57,13 @@ type AddRFIDSongCall struct {
 	SongID string
 }
 
+// ReplaceRFIDSongCall records arguments passed to ReplaceRFIDSong.
+type ReplaceRFIDSongCall struct {
+	RFID   string
+	SongID string
+	With   []string
+}
+
 func (m *MockDB) Close() error { return nil }
 func (m *MockDB) GetSong(id string) (*model.Song, error) {
 	m.mu.RLock()
@@ -73,8 +100,9 @@ func (m *MockDB) UpdateSong(song *model.Song) error {
 }
 
 func (m *MockDB) DeleteSong(id string) error {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.DeleteSongCalls = append(m.DeleteSongCalls, id)
 	return m.DeleteSongErr
 }
 func (m *MockDB) SongExists(id string) (bool, error) { return false, nil }
@@ -95,7 +123,13 @@ func (m *MockDB) AddRFIDSong(rfid, songID string) error {
 	return nil
 }
 func (m *MockDB) RemoveRFIDSong(rfid, songID string) error { return nil }
-func (m *MockDB) DeleteRFID(id string) error               { return nil }
+func (m *MockDB) ReplaceRFIDSong(rfid, songID string, with []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ReplaceRFIDSongCalls = append(m.ReplaceRFIDSongCalls, ReplaceRFIDSongCall{RFID: rfid, SongID: songID, With: with})
+	return nil
+}
+func (m *MockDB) DeleteRFID(id string) error { return nil }
 func (m *MockDB) ListRFIDSongs() ([]*model.RFIDSong, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -103,6 +137,189 @@ func (m *MockDB) ListRFIDSongs() ([]*model.RFIDSong, error) {
 }
 func (m *MockDB) RFIDExists(rfid string) (bool, error)   { return false, nil }
 func (m *MockDB) DeleteSongFromRFID(songID string) error { return nil }
+func (m *MockDB) SetRFIDPlayback(rfid string, opts model.PlaybackOptions) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.SetRFIDPlaybackErr
+}
+
+func (m *MockDB) GetCommandCard(rfid string) (*model.CommandCard, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.GetCommandCardResult == nil {
+		return nil, ErrNotFound
+	}
+	return m.GetCommandCardResult, nil
+}
+
+func (m *MockDB) ListCommandCards() ([]*model.CommandCard, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.ListCommandCardsResult, nil
+}
+
+func (m *MockDB) SetCommandCard(card *model.CommandCard) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.SetCommandCardCalls = append(m.SetCommandCardCalls, card)
+	return nil
+}
+func (m *MockDB) DeleteCommandCard(rfid string) error { return nil }
+
+func (m *MockDB) GetJob(id string) (*model.DownloadJob, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	job, ok := m.Jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *job
+	return &cp, nil
+}
+
+func (m *MockDB) ListJobs() ([]*model.DownloadJob, error) {
+	return m.listJobs(func(*model.DownloadJob) bool { return true })
+}
+
+func (m *MockDB) ListJobsByState(states ...model.JobState) ([]*model.DownloadJob, error) {
+	return m.listJobs(func(job *model.DownloadJob) bool { return slices.Contains(states, job.State) })
+}
+
+func (m *MockDB) listJobs(keep func(*model.DownloadJob) bool) ([]*model.DownloadJob, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]*model.DownloadJob, 0, len(m.Jobs))
+	for _, job := range m.Jobs {
+		if !keep(job) {
+			continue
+		}
+		cp := *job
+		out = append(out, &cp)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (m *MockDB) SaveJob(job *model.DownloadJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Jobs == nil {
+		m.Jobs = make(map[string]*model.DownloadJob)
+	}
+	job.UpdatedAt = time.Now()
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = job.UpdatedAt
+	}
+	cp := *job
+	m.Jobs[job.ID] = &cp
+	return nil
+}
+
+func (m *MockDB) DeleteJob(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.Jobs, id)
+	return nil
+}
+
+func (m *MockDB) GetPlaylist(id string) (*model.Playlist, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, ok := m.Playlists[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *p
+	cp.Entries = append([]model.PlaylistEntry(nil), p.Entries...)
+	return &cp, nil
+}
+
+func (m *MockDB) ListPlaylists() ([]*model.Playlist, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]*model.Playlist, 0, len(m.Playlists))
+	for _, p := range m.Playlists {
+		cp := *p
+		cp.Entries = append([]model.PlaylistEntry(nil), p.Entries...)
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
+func (m *MockDB) SavePlaylist(p *model.Playlist) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Playlists == nil {
+		m.Playlists = make(map[string]*model.Playlist)
+	}
+	cp := *p
+	cp.Entries = append([]model.PlaylistEntry(nil), p.Entries...)
+	m.Playlists[p.ID] = &cp
+	return nil
+}
+
+func (m *MockDB) GetFeed(id string) (*model.Feed, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	f, ok := m.Feeds[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *f
+	cp.Episodes = append([]model.FeedEpisode(nil), f.Episodes...)
+	return &cp, nil
+}
+
+func (m *MockDB) ListFeeds() ([]*model.Feed, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]*model.Feed, 0, len(m.Feeds))
+	for _, f := range m.Feeds {
+		cp := *f
+		cp.Episodes = append([]model.FeedEpisode(nil), f.Episodes...)
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
+func (m *MockDB) SaveFeed(f *model.Feed) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Feeds == nil {
+		m.Feeds = make(map[string]*model.Feed)
+	}
+	cp := *f
+	cp.Episodes = append([]model.FeedEpisode(nil), f.Episodes...)
+	m.Feeds[f.ID] = &cp
+	return nil
+}
+
+func (m *MockDB) DeleteFeed(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.Feeds, id)
+	return nil
+}
+
+func (m *MockDB) GetPosition(songID string) (time.Duration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.Positions[songID], nil
+}
+
+func (m *MockDB) SavePosition(songID string, pos time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Positions == nil {
+		m.Positions = make(map[string]time.Duration)
+	}
+	if pos <= 0 {
+		delete(m.Positions, songID)
+	} else {
+		m.Positions[songID] = pos
+	}
+	return nil
+}
 
 func (m *MockDB) UpdateSongCallCount() int {
 	m.mu.RLock()
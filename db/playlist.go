@@ -0,0 +1,63 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/jaredwarren/rpi_music/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+const PlaylistBucket = "PlaylistBucket"
+
+// PlaylistStore is the read/write interface for imported playlists.
+type PlaylistStore interface {
+	GetPlaylist(id string) (*model.Playlist, error)
+	ListPlaylists() ([]*model.Playlist, error)
+	SavePlaylist(p *model.Playlist) error
+}
+
+func (s *SongDB) GetPlaylist(id string) (*model.Playlist, error) {
+	var p *model.Playlist
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket([]byte(PlaylistBucket)).Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+		p = &model.Playlist{}
+		return json.Unmarshal(v, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *SongDB) ListPlaylists() ([]*model.Playlist, error) {
+	var out []*model.Playlist
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(PlaylistBucket)).ForEach(func(k, v []byte) error {
+			var p model.Playlist
+			if err := json.Unmarshal(v, &p); err != nil {
+				return err
+			}
+			out = append(out, &p)
+			return nil
+		})
+	})
+	return out, err
+}
+
+// SavePlaylist creates or replaces p.
+func (s *SongDB) SavePlaylist(p *model.Playlist) error {
+	if p.ID == "" {
+		return fmt.Errorf("playlist ID required")
+	}
+	buf, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(PlaylistBucket)).Put([]byte(p.ID), buf)
+	})
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaylists(t *testing.T) {
+	d := newTestDB(t)
+	t.Cleanup(func() { require.NoError(t, d.Close()) })
+
+	_, err := d.GetPlaylist("pl-1")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Error(t, d.SavePlaylist(&model.Playlist{URL: "u"}))
+
+	p := &model.Playlist{
+		ID: "pl-1", URL: "https://www.youtube.com/playlist?list=PL1", RFID: "ABCD",
+		Entries: []model.PlaylistEntry{{URL: "https://youtu.be/a", SongID: "song-a"}, {URL: "https://youtu.be/b"}},
+	}
+	require.NoError(t, d.SavePlaylist(p))
+	got, err := d.GetPlaylist("pl-1")
+	require.NoError(t, err)
+	require.Equal(t, p.Entries, got.Entries)
+
+	list, err := d.ListPlaylists()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, "ABCD", list[0].RFID)
+}
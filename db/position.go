@@ -0,0 +1,44 @@
+package db
+
+import (
+	"encoding/json"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const PositionBucket = "PositionBucket"
+
+// PositionStore remembers where each song last stopped so it can resume there.
+type PositionStore interface {
+	// GetPosition returns the saved position of songID, or 0 if there is none.
+	GetPosition(songID string) (time.Duration, error)
+	// SavePosition saves pos for songID; a pos of 0 clears it.
+	SavePosition(songID string, pos time.Duration) error
+}
+
+func (s *SongDB) GetPosition(songID string) (time.Duration, error) {
+	var pos time.Duration
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket([]byte(PositionBucket)).Get([]byte(songID))
+		if v == nil {
+			return nil
+		}
+		return json.Unmarshal(v, &pos)
+	})
+	return pos, err
+}
+
+func (s *SongDB) SavePosition(songID string, pos time.Duration) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(PositionBucket))
+		if pos <= 0 {
+			return b.Delete([]byte(songID))
+		}
+		buf, err := json.Marshal(pos)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(songID), buf)
+	})
+}
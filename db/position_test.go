@@ -0,0 +1,36 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPositions(t *testing.T) {
+	d := newTestDB(t)
+	t.Cleanup(func() { require.NoError(t, d.Close()) })
+
+	pos, err := d.GetPosition("song-1")
+	require.NoError(t, err)
+	require.Zero(t, pos)
+
+	require.NoError(t, d.SavePosition("song-1", 95*time.Second))
+	pos, err = d.GetPosition("song-1")
+	require.NoError(t, err)
+	require.Equal(t, 95*time.Second, pos)
+
+	require.NoError(t, d.SavePosition("song-1", 0))
+	pos, err = d.GetPosition("song-1")
+	require.NoError(t, err)
+	require.Zero(t, pos)
+
+	// Deleting a song forgets where it stopped.
+	require.NoError(t, d.CreateSong(&model.Song{ID: "song-2"}))
+	require.NoError(t, d.SavePosition("song-2", time.Minute))
+	require.NoError(t, d.DeleteSong("song-2"))
+	pos, err = d.GetPosition("song-2")
+	require.NoError(t, err)
+	require.Zero(t, pos)
+}
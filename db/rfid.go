@@ -3,6 +3,7 @@ package db
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	"github.com/jaredwarren/rpi_music/model"
 	bolt "go.etcd.io/bbolt"
@@ -19,10 +20,12 @@ type RFIDStore interface {
 	GetSongRFID(songID string) (*model.RFIDSong, error)
 	AddRFIDSong(rfid, songID string) error
 	RemoveRFIDSong(rfid, songID string) error
+	ReplaceRFIDSong(rfid, songID string, with []string) error
 	DeleteRFID(id string) error
 	ListRFIDSongs() ([]*model.RFIDSong, error)
 	RFIDExists(rfid string) (bool, error)
 	DeleteSongFromRFID(songID string) error
+	SetRFIDPlayback(rfid string, opts model.PlaybackOptions) error
 }
 
 func (s *SongDB) RFIDExists(rfid string) (bool, error) {
@@ -153,6 +156,57 @@ func (s *SongDB) RemoveRFIDSong(rfid, songID string) error {
 	})
 }
 
+// ReplaceRFIDSong puts the with songs on rfid's card where songID was, in
+// order, and takes songID off. If songID is not on the card they are added at
+// the end. Songs already on the card are not added twice.
+func (s *SongDB) ReplaceRFIDSong(rfid, songID string, with []string) error {
+	if rfid == "" || songID == "" {
+		return fmt.Errorf("rfid and songID required")
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(RFIDBucket))
+		idx := tx.Bucket([]byte(SongRFIDIndexBucket))
+		rs := model.RFIDSong{RFID: rfid}
+		if v := b.Get([]byte(rfid)); v != nil {
+			if err := json.Unmarshal(v, &rs); err != nil {
+				return err
+			}
+		}
+		at := slices.Index(rs.Songs, songID)
+		if at < 0 {
+			at = len(rs.Songs)
+		} else {
+			rs.Songs = slices.Delete(rs.Songs, at, at+1)
+			if err := idx.Delete([]byte(songID)); err != nil {
+				return err
+			}
+		}
+		var add []string
+		for _, id := range with {
+			if !slices.Contains(rs.Songs, id) && !slices.Contains(add, id) {
+				add = append(add, id)
+			}
+		}
+		rs.Songs = slices.Insert(rs.Songs, at, add...)
+		if len(rs.Songs) == 0 {
+			return b.Delete([]byte(rfid))
+		}
+		buf, err := json.Marshal(&rs)
+		if err != nil {
+			return err
+		}
+		if err := b.Put([]byte(rfid), buf); err != nil {
+			return err
+		}
+		for _, id := range add {
+			if err := idx.Put([]byte(id), []byte(rfid)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (s *SongDB) GetSongRFID(songID string) (*model.RFIDSong, error) {
 	if songID == "" {
 		return nil, fmt.Errorf("songID required")
@@ -244,3 +298,27 @@ func (s *SongDB) DeleteRFID(id string) error {
 		return b.Delete([]byte(id))
 	})
 }
+
+// SetRFIDPlayback stores the shuffle/repeat options for an existing card.
+func (s *SongDB) SetRFIDPlayback(rfid string, opts model.PlaybackOptions) error {
+	if rfid == "" {
+		return fmt.Errorf("rfid required")
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(RFIDBucket))
+		v := b.Get([]byte(rfid))
+		if v == nil {
+			return ErrNotFound
+		}
+		var rs model.RFIDSong
+		if err := json.Unmarshal(v, &rs); err != nil {
+			return err
+		}
+		rs.Playback = opts
+		buf, err := json.Marshal(&rs)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(rfid), buf)
+	})
+}
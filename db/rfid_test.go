@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/jaredwarren/rpi_music/model"
 	"github.com/stretchr/testify/require"
 )
 
@@ -49,6 +50,28 @@ func TestRemoveRFIDSongRemovesSongIndexEntry(t *testing.T) {
 	require.ErrorIs(t, err, ErrNotFound)
 }
 
+func TestSetRFIDPlayback(t *testing.T) {
+	d := newTestDB(t)
+	t.Cleanup(func() { require.NoError(t, d.Close()) })
+
+	require.ErrorIs(t, d.SetRFIDPlayback("rfid-1", model.PlaybackOptions{Shuffle: true}), ErrNotFound)
+
+	require.NoError(t, d.AddRFIDSong("rfid-1", "song-1"))
+	require.NoError(t, d.AddRFIDSong("rfid-1", "song-2"))
+	require.NoError(t, d.SetRFIDPlayback("rfid-1", model.PlaybackOptions{Shuffle: true, Loop: model.LoopOne}))
+
+	rs, err := d.GetRFIDSong("rfid-1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"song-1", "song-2"}, rs.Songs)
+	require.Equal(t, model.PlaybackOptions{Shuffle: true, Loop: model.LoopOne}, rs.Playback)
+
+	// Adding another song keeps the options.
+	require.NoError(t, d.AddRFIDSong("rfid-1", "song-3"))
+	rs, err = d.GetRFIDSong("rfid-1")
+	require.NoError(t, err)
+	require.True(t, rs.Playback.Shuffle)
+}
+
 func newTestDB(t *testing.T) DBer {
 	t.Helper()
 	dbPath := filepath.Join(t.TempDir(), "test.db")
@@ -56,3 +79,27 @@ func newTestDB(t *testing.T) DBer {
 	require.NoError(t, err)
 	return d
 }
+
+func TestReplaceRFIDSong(t *testing.T) {
+	d := newTestDB(t)
+	t.Cleanup(func() { require.NoError(t, d.Close()) })
+	for _, id := range []string{"intro", "book", "outro"} {
+		require.NoError(t, d.AddRFIDSong("rfid-1", id))
+	}
+
+	require.NoError(t, d.ReplaceRFIDSong("rfid-1", "book", []string{"part-1", "part-2", "outro"}))
+	rs, err := d.GetRFIDSong("rfid-1")
+	require.NoError(t, err)
+	require.Equal(t, []string{"intro", "part-1", "part-2", "outro"}, rs.Songs)
+	_, err = d.GetSongRFID("book")
+	require.ErrorIs(t, err, ErrNotFound)
+	rs, err = d.GetSongRFID("part-2")
+	require.NoError(t, err)
+	require.Equal(t, "rfid-1", rs.RFID)
+
+	// A song that is not on the card has its replacements appended.
+	require.NoError(t, d.ReplaceRFIDSong("rfid-2", "book", []string{"part-1"}))
+	rs, err = d.GetRFIDSong("rfid-2")
+	require.NoError(t, err)
+	require.Equal(t, []string{"part-1"}, rs.Songs)
+}
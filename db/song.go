@@ -25,10 +25,15 @@ type SongStore interface {
 // ErrNotFound is returned when a song or resource is not found in the database.
 var ErrNotFound = errors.New("db: not found")
 
-// DBer is the full database interface embedding both song and RFID stores.
+// DBer is the full database interface embedding every store.
 type DBer interface {
 	SongStore
 	RFIDStore
+	CommandStore
+	JobStore
+	PlaylistStore
+	FeedStore
+	PositionStore
 	Close() error
 }
 
@@ -45,7 +50,7 @@ func NewSongDB(path string) (DBer, error) {
 	}
 
 	err = db.Update(func(tx *bolt.Tx) error {
-		for _, name := range []string{SongBucketV2, RFIDBucket, SongRFIDIndexBucket} {
+		for _, name := range []string{SongBucketV2, RFIDBucket, SongRFIDIndexBucket, CommandBucket, JobBucket, PlaylistBucket, FeedBucket, PositionBucket} {
 			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
 				return fmt.Errorf("create bucket %q: %w", name, err)
 			}
@@ -134,6 +139,9 @@ func (s *SongDB) UpdateSong(song *model.Song) error {
 
 func (s *SongDB) DeleteSong(songID string) error {
 	if err := s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket([]byte(PositionBucket)).Delete([]byte(songID)); err != nil {
+			return err
+		}
 		return tx.Bucket([]byte(SongBucketV2)).Delete([]byte(songID))
 	}); err != nil {
 		return err
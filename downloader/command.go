@@ -1,6 +1,7 @@
 package downloader
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os/exec"
@@ -76,6 +77,54 @@ func (d *DLCommand) ExecCombinedContext(ctx context.Context, exArgs ...string) (
 	return out, nil
 }
 
+// ExecLinesContext runs the command like ExecCombinedContext, also passing each
+// output line to onLine as it is written. Lines may end in \n or \r.
+func (d *DLCommand) ExecLinesContext(ctx context.Context, onLine func(string), exArgs ...string) ([]byte, error) {
+	c, baseArgs := d.GetCommand()
+	args := make([]string, len(baseArgs), len(baseArgs)+len(exArgs))
+	copy(args, baseArgs)
+	args = append(args, exArgs...)
+	cmd := exec.CommandContext(ctx, c, args...)
+	lw := &lineWriter{onLine: onLine}
+	cmd.Stdout = lw
+	cmd.Stderr = lw
+	err := cmd.Run()
+	lw.flush()
+	if err != nil {
+		return nil, fmt.Errorf("cmd err:%w", err)
+	}
+	return lw.out.Bytes(), nil
+}
+
+// lineWriter keeps everything written to it and calls onLine per line.
+type lineWriter struct {
+	out     bytes.Buffer
+	partial []byte
+	onLine  func(string)
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.out.Write(p)
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexAny(w.partial, "\r\n")
+		if i < 0 {
+			return len(p), nil
+		}
+		if i > 0 {
+			w.onLine(string(w.partial[:i]))
+		}
+		w.partial = w.partial[i+1:]
+	}
+}
+
+func (w *lineWriter) flush() {
+	if len(w.partial) > 0 {
+		w.onLine(string(w.partial))
+		w.partial = nil
+	}
+}
+
 func (d *DLCommand) Exec(exArgs ...string) (string, error) {
 	std, err := d.ExecB(exArgs...)
 	return string(std), err
@@ -1,9 +1,10 @@
-// Package downloader provides backends for downloading YouTube audio and thumbnails.
+// Package downloader provides backends for downloading audio and thumbnails.
 //
 // Two implementations are available:
 //   - YoutubeDownloader: uses the go-youtube library (no external binary).
-//   - YoutubeDLDownloader: uses the yt-dlp CLI for downloading; supports more sites and formats.
+//   - YoutubeDLDownloader: uses the yt-dlp CLI for downloading; supports more sites (SoundCloud,
+//     Bandcamp, Archive.org, podcasts, ...) and formats.
 //
 // The implementation is chosen at server startup via config (e.g. downloader: "ytdl" for yt-dlp).
-// All implementations satisfy the Downloader interface.
+// All implementations satisfy the Downloader interface and describe tracks with Metadata.
 package downloader
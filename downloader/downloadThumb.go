@@ -6,18 +6,16 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
-
-	"github.com/kkdai/youtube/v2"
 )
 
 // thumbOutputRegex captures the path from yt-dlp's "Writing ... to: path" line.
 var thumbOutputRegex = regexp.MustCompile(`Writing .+? to: (.+?)(\n|$)`)
 
-func (d *YoutubeDLDownloader) DownloadThumb(video *youtube.Video) (string, error) {
+func (d *YoutubeDLDownloader) DownloadThumb(meta *Metadata) (string, error) {
 	cfg := d.config()
 	thumbRoot := cfg.thumbRoot()
 
-	filename, err := downloadVideoThumb(video.ID, thumbRoot, cfg)
+	filename, err := downloadVideoThumb(meta.ID, thumbRoot, cfg)
 	if err != nil {
 		return "", fmt.Errorf("download thumb: %w", err)
 	}
@@ -18,11 +18,16 @@ func downloadVideo(ctx context.Context, videoID, songRoot string, cfg *YoutubeDL
 	absRoot := absPath(songRoot)
 	cmd := cfg.newDownloadCmd([]string{
 		"--no-call-home", "--no-cache-dir", "--restrict-filenames",
-		"--audio-quality", "0",
+		"--audio-quality", "0", "--newline",
 		"-o", filepath.Join(absRoot, "%(title)s-%(id)s.%(ext)s"),
 	}, absRoot)
 
-	out, err := cmd.ExecCombinedContext(ctx, videoID)
+	report := progressFrom(ctx)
+	out, err := cmd.ExecLinesContext(ctx, func(line string) {
+		if p, ok := parseProgressLine(line); ok {
+			report(p)
+		}
+	}, videoID)
 	if err != nil {
 		return "", err
 	}
@@ -9,4 +9,5 @@ var (
 	ErrExecutableNotFound = errors.New("downloader: yt-dlp executable not found in PATH")
 	ErrNoAudioFormats     = errors.New("downloader: no audio formats found")
 	ErrMissingURL         = errors.New("downloader: missing url")
+	ErrNotPlaylist        = errors.New("downloader: not a playlist")
 )
@@ -0,0 +1,133 @@
+package downloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/kkdai/youtube/v2"
+)
+
+// Metadata describes a track independent of the site it came from.
+type Metadata struct {
+	ID         string // the video ID or URL given to the downloader
+	Title      string
+	Artist     string
+	Album      string
+	Duration   time.Duration
+	Thumbnails []Thumbnail
+	Extractor  string // site the track came from, e.g. "Youtube" or "Soundcloud"
+	Chapters   []Chapter
+}
+
+// Chapter is a titled section of a track.
+type Chapter struct {
+	Title string
+	Start time.Duration
+	End   time.Duration
+}
+
+// Thumbnail is one size of a track's artwork.
+type Thumbnail struct {
+	URL    string
+	Width  int
+	Height int
+}
+
+// bestThumbnail returns the widest thumbnail.
+func (m *Metadata) bestThumbnail() (Thumbnail, bool) {
+	if len(m.Thumbnails) == 0 {
+		return Thumbnail{}, false
+	}
+	best := m.Thumbnails[0]
+	for _, t := range m.Thumbnails {
+		if t.Width > best.Width {
+			best = t
+		}
+	}
+	return best, true
+}
+
+// parseMetadata reads the fields Metadata needs from yt-dlp's -J output.
+func parseMetadata(id string, out []byte) (*Metadata, error) {
+	var info struct {
+		Title      string  `json:"title"`
+		Track      string  `json:"track"`
+		Artist     string  `json:"artist"`
+		Creator    string  `json:"creator"`
+		Uploader   string  `json:"uploader"`
+		Album      string  `json:"album"`
+		Duration   float64 `json:"duration"`
+		Thumbnail  string  `json:"thumbnail"`
+		Thumbnails []struct {
+			URL    string `json:"url"`
+			Width  int    `json:"width"`
+			Height int    `json:"height"`
+		} `json:"thumbnails"`
+		Extractor    string `json:"extractor"`
+		ExtractorKey string `json:"extractor_key"`
+		Chapters     []struct {
+			Title     string  `json:"title"`
+			StartTime float64 `json:"start_time"`
+			EndTime   float64 `json:"end_time"`
+		} `json:"chapters"`
+	}
+	if err := json.Unmarshal(out, &info); err != nil {
+		return nil, fmt.Errorf("getVideoInfo json: %w", err)
+	}
+	m := &Metadata{
+		ID:        id,
+		Title:     firstNonEmpty(info.Title, info.Track),
+		Artist:    firstNonEmpty(info.Artist, info.Creator, info.Uploader),
+		Album:     info.Album,
+		Duration:  time.Duration(info.Duration * float64(time.Second)),
+		Extractor: firstNonEmpty(info.ExtractorKey, info.Extractor),
+	}
+	for _, t := range info.Thumbnails {
+		if t.URL != "" {
+			m.Thumbnails = append(m.Thumbnails, Thumbnail{URL: t.URL, Width: t.Width, Height: t.Height})
+		}
+	}
+	if len(m.Thumbnails) == 0 && info.Thumbnail != "" {
+		m.Thumbnails = []Thumbnail{{URL: info.Thumbnail}}
+	}
+	for i, c := range info.Chapters {
+		title := c.Title
+		if title == "" {
+			title = fmt.Sprintf("Chapter %d", i+1)
+		}
+		m.Chapters = append(m.Chapters, Chapter{
+			Title: title,
+			Start: time.Duration(c.StartTime * float64(time.Second)),
+			End:   time.Duration(c.EndTime * float64(time.Second)),
+		})
+	}
+	return m, nil
+}
+
+// metadataFromVideo converts a kkdai/youtube video.
+func metadataFromVideo(v *youtube.Video) *Metadata {
+	if v == nil {
+		return nil
+	}
+	m := &Metadata{
+		ID:        v.ID,
+		Title:     v.Title,
+		Artist:    v.Author,
+		Duration:  v.Duration,
+		Extractor: "Youtube",
+	}
+	for _, t := range v.Thumbnails {
+		m.Thumbnails = append(m.Thumbnails, Thumbnail{URL: t.URL, Width: int(t.Width), Height: int(t.Height)})
+	}
+	return m
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
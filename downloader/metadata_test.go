@@ -0,0 +1,53 @@
+package downloader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetadata(t *testing.T) {
+	out := []byte(`{
+		"title": "Story Time", "uploader": "Grandma", "album": "Bedtime",
+		"duration": 93.5, "extractor": "soundcloud", "extractor_key": "Soundcloud",
+		"thumbnails": [{"url": "https://i/small.jpg", "width": 100}, {"url": "https://i/big.jpg", "width": 500}, {"url": ""}]
+	}`)
+	m, err := parseMetadata("https://soundcloud.com/x", out)
+	require.NoError(t, err)
+	assert.Equal(t, &Metadata{
+		ID:        "https://soundcloud.com/x",
+		Title:     "Story Time",
+		Artist:    "Grandma",
+		Album:     "Bedtime",
+		Duration:  93500 * time.Millisecond,
+		Extractor: "Soundcloud",
+		Thumbnails: []Thumbnail{
+			{URL: "https://i/small.jpg", Width: 100},
+			{URL: "https://i/big.jpg", Width: 500},
+		},
+	}, m)
+	best, ok := m.bestThumbnail()
+	require.True(t, ok)
+	assert.Equal(t, "https://i/big.jpg", best.URL)
+
+	m, err = parseMetadata("u", []byte(`{"title": "Book", "chapters": [
+		{"start_time": 0, "end_time": 61.5, "title": "Intro"},
+		{"start_time": 61.5, "end_time": 300, "title": ""}
+	]}`))
+	require.NoError(t, err)
+	assert.Equal(t, []Chapter{
+		{Title: "Intro", End: 61500 * time.Millisecond},
+		{Title: "Chapter 2", Start: 61500 * time.Millisecond, End: 300 * time.Second},
+	}, m.Chapters)
+
+	m, err = parseMetadata("u", []byte(`{"track": "T", "artist": "A", "uploader": "U", "thumbnail": "https://i/t.jpg"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "T", m.Title)
+	assert.Equal(t, "A", m.Artist)
+	assert.Equal(t, []Thumbnail{{URL: "https://i/t.jpg"}}, m.Thumbnails)
+
+	_, err = parseMetadata("u", []byte("not json"))
+	require.Error(t, err)
+}
@@ -3,15 +3,14 @@ package downloader
 import (
 	"context"
 	"log/slog"
-
-	"github.com/kkdai/youtube/v2"
 )
 
 type MockDownloader struct {
-	Response map[string]*youtube.Video
+	Response  map[string]*Metadata
+	Playlists map[string]*Playlist
 }
 
-func (d *MockDownloader) GetVideo(videoID string) (*youtube.Video, error) {
+func (d *MockDownloader) GetVideo(videoID string) (*Metadata, error) {
 	v, ok := d.Response[videoID]
 	if !ok {
 		return nil, ErrNotFound
@@ -19,7 +18,7 @@ func (d *MockDownloader) GetVideo(videoID string) (*youtube.Video, error) {
 	return v, nil
 }
 
-func (d *MockDownloader) DownloadVideo(ctx context.Context, videoID string, _ *slog.Logger) (string, *youtube.Video, error) {
+func (d *MockDownloader) DownloadVideo(ctx context.Context, videoID string, _ *slog.Logger) (string, *Metadata, error) {
 	v, ok := d.Response[videoID]
 	if !ok {
 		return "", nil, ErrNotFound
@@ -27,13 +26,21 @@ func (d *MockDownloader) DownloadVideo(ctx context.Context, videoID string, _ *s
 	return v.Title, v, nil
 }
 
-func (d *MockDownloader) DownloadThumb(video *youtube.Video) (string, error) {
-	if len(video.Thumbnails) == 0 {
+func (d *MockDownloader) DownloadThumb(meta *Metadata) (string, error) {
+	if len(meta.Thumbnails) == 0 {
 		return "", ErrNotFound
 	}
-	return video.Thumbnails[0].URL, nil
+	return meta.Thumbnails[0].URL, nil
 }
 
 func (d *MockDownloader) GetVideoFilename(ctx context.Context, _ string, _ *slog.Logger) (string, error) {
 	return "", nil
 }
+
+func (d *MockDownloader) ExpandPlaylist(ctx context.Context, url string) (*Playlist, error) {
+	p, ok := d.Playlists[url]
+	if !ok {
+		return nil, ErrNotPlaylist
+	}
+	return p, nil
+}
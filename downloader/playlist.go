@@ -0,0 +1,72 @@
+package downloader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Playlist is a playlist's title and its videos in order.
+type Playlist struct {
+	Title   string
+	Entries []PlaylistEntry
+}
+
+// PlaylistEntry is one video in a Playlist.
+type PlaylistEntry struct {
+	ID    string
+	URL   string
+	Title string
+}
+
+// PlaylistExpander lists the videos in a playlist without downloading them.
+// YoutubeDLDownloader implements it.
+type PlaylistExpander interface {
+	ExpandPlaylist(ctx context.Context, url string) (*Playlist, error)
+}
+
+var expandPlaylistArgs = []string{
+	"--ignore-errors", "--no-call-home", "--no-cache-dir",
+	"--flat-playlist", "-J",
+}
+
+// ExpandPlaylist lists the videos in the playlist at url using
+// yt-dlp --flat-playlist.
+func (d *YoutubeDLDownloader) ExpandPlaylist(ctx context.Context, url string) (*Playlist, error) {
+	cmd := d.config().newMetaCmd(expandPlaylistArgs)
+	out, err := cmd.ExecBContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("ExpandPlaylist: %w", err)
+	}
+	return parsePlaylist(out)
+}
+
+// parsePlaylist reads yt-dlp's --flat-playlist -J output.
+func parsePlaylist(out []byte) (*Playlist, error) {
+	var info struct {
+		Title   string `json:"title"`
+		Entries []struct {
+			ID    string `json:"id"`
+			URL   string `json:"url"`
+			Title string `json:"title"`
+		} `json:"entries"`
+	}
+	if err := json.Unmarshal(out, &info); err != nil {
+		return nil, fmt.Errorf("ExpandPlaylist json: %w", err)
+	}
+	if info.Entries == nil {
+		return nil, ErrNotPlaylist
+	}
+	p := &Playlist{Title: info.Title}
+	for _, e := range info.Entries {
+		if e.ID == "" && e.URL == "" {
+			continue // unavailable video
+		}
+		url := e.URL
+		if url == "" {
+			url = "https://www.youtube.com/watch?v=" + e.ID
+		}
+		p.Entries = append(p.Entries, PlaylistEntry{ID: e.ID, URL: url, Title: e.Title})
+	}
+	return p, nil
+}
@@ -0,0 +1,27 @@
+package downloader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePlaylist(t *testing.T) {
+	out := `{"_type": "playlist", "title": "Bedtime", "entries": [
+		{"id": "a1", "url": "https://www.youtube.com/watch?v=a1", "title": "One"},
+		{"id": "b2", "title": "Two"},
+		{"title": "[Private video]"}
+	]}`
+	p, err := parsePlaylist([]byte(out))
+	require.NoError(t, err)
+	assert.Equal(t, &Playlist{Title: "Bedtime", Entries: []PlaylistEntry{
+		{ID: "a1", URL: "https://www.youtube.com/watch?v=a1", Title: "One"},
+		{ID: "b2", URL: "https://www.youtube.com/watch?v=b2", Title: "Two"},
+	}}, p)
+
+	_, err = parsePlaylist([]byte(`{"id": "a1", "title": "Just a video"}`))
+	require.ErrorIs(t, err, ErrNotPlaylist)
+	_, err = parsePlaylist([]byte(`not json`))
+	require.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package downloader
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Progress is a snapshot of a running download. Speed and ETA are zero when
+// the backend does not know them.
+type Progress struct {
+	Percent float64       // 0 to 100
+	Speed   float64       // bytes per second
+	ETA     time.Duration // time left
+}
+
+// ProgressFunc receives progress updates while a download runs.
+type ProgressFunc func(Progress)
+
+type progressKey struct{}
+
+// WithProgress returns a context that makes DownloadVideo report progress to fn.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress passes p to the ProgressFunc stored in ctx, if any. Downloader
+// implementations call it while downloading.
+func ReportProgress(ctx context.Context, p Progress) {
+	progressFrom(ctx)(p)
+}
+
+// progressFrom returns the ProgressFunc stored in ctx, or one that does nothing.
+func progressFrom(ctx context.Context) ProgressFunc {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		return fn
+	}
+	return func(Progress) {}
+}
+
+// progressLineRegex matches yt-dlp's --newline progress lines, e.g.
+// "[download]  45.3% of ~  3.52MiB at  512.34KiB/s ETA 00:04".
+var progressLineRegex = regexp.MustCompile(`^\[download\]\s+([\d.]+)%(?:.*?\bat\s+(\S+))?(?:.*?\bETA\s+(\S+))?`)
+
+// parseProgressLine parses one yt-dlp progress line.
+func parseProgressLine(line string) (Progress, bool) {
+	m := progressLineRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return Progress{}, false
+	}
+	pct, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return Progress{}, false
+	}
+	return Progress{Percent: pct, Speed: parseSpeed(m[2]), ETA: parseETA(m[3])}, true
+}
+
+var sizeUnits = map[string]float64{
+	"B": 1, "KiB": 1 << 10, "MiB": 1 << 20, "GiB": 1 << 30,
+	"KB": 1e3, "MB": 1e6, "GB": 1e9,
+}
+
+// parseSpeed parses a yt-dlp speed such as "512.34KiB/s" into bytes per second.
+func parseSpeed(s string) float64 {
+	s, ok := strings.CutSuffix(s, "/s")
+	if !ok {
+		return 0
+	}
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i <= 0 {
+		return 0
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0
+	}
+	return n * sizeUnits[s[i:]]
+}
+
+// parseETA parses a yt-dlp ETA of the form [[HH:]MM:]SS.
+func parseETA(s string) time.Duration {
+	if s == "" {
+		return 0
+	}
+	var secs int
+	for _, part := range strings.Split(s, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return 0
+		}
+		secs = secs*60 + n
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// progressWriter counts bytes written and reports them as a share of total.
+type progressWriter struct {
+	w       io.Writer
+	total   int64
+	written int64
+	started time.Time
+	report  ProgressFunc
+	lastPct int
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	if p.total <= 0 {
+		return n, err
+	}
+	pct := float64(p.written) * 100 / float64(p.total)
+	// Report each whole percent once so fast copies do not flood listeners.
+	if int(pct) == p.lastPct {
+		return n, err
+	}
+	p.lastPct = int(pct)
+	prog := Progress{Percent: min(pct, 100)}
+	if elapsed := time.Since(p.started).Seconds(); elapsed > 0 {
+		prog.Speed = float64(p.written) / elapsed
+		if prog.Speed > 0 && p.written < p.total {
+			prog.ETA = time.Duration(float64(p.total-p.written) / prog.Speed * float64(time.Second))
+		}
+	}
+	p.report(prog)
+	return n, err
+}
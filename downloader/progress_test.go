@@ -0,0 +1,81 @@
+package downloader
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProgressLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want Progress
+		ok   bool
+	}{
+		{
+			line: "[download]  45.3% of    3.52MiB at  512.00KiB/s ETA 00:04",
+			want: Progress{Percent: 45.3, Speed: 512 * 1024, ETA: 4 * time.Second},
+			ok:   true,
+		},
+		{
+			line: "[download]   2.0% of ~  10.00MiB at    1.50MiB/s ETA 01:02:03",
+			want: Progress{Percent: 2, Speed: 1.5 * (1 << 20), ETA: time.Hour + 2*time.Minute + 3*time.Second},
+			ok:   true,
+		},
+		{
+			line: "[download]   0.1% of    3.52MiB at  Unknown B/s ETA Unknown",
+			want: Progress{Percent: 0.1},
+			ok:   true,
+		},
+		{
+			line: "[download] 100% of    3.52MiB in 00:00:02 at 1.00MiB/s",
+			want: Progress{Percent: 100, Speed: 1 << 20},
+			ok:   true,
+		},
+		{line: "[download] Destination: /downloads/Test-abc.webm"},
+		{line: "[youtube] abc: Downloading webpage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, ok := parseProgressLine(tt.line)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLineWriterSplitsLines(t *testing.T) {
+	var lines []string
+	w := &lineWriter{onLine: func(l string) { lines = append(lines, l) }}
+	_, _ = w.Write([]byte("[download]  1.0%\r[down"))
+	_, _ = w.Write([]byte("load]  2.0%\n\nlast"))
+	w.flush()
+	assert.Equal(t, []string{"[download]  1.0%", "[download]  2.0%", "last"}, lines)
+	assert.Equal(t, "[download]  1.0%\r[download]  2.0%\n\nlast", w.out.String())
+}
+
+func TestProgressWriterReportsWholePercents(t *testing.T) {
+	var got []Progress
+	ctx := WithProgress(context.Background(), func(p Progress) { got = append(got, p) })
+	var buf bytes.Buffer
+	pw := &progressWriter{w: &buf, total: 200, started: time.Now(), report: progressFrom(ctx)}
+	for range 4 {
+		_, err := pw.Write(make([]byte, 50))
+		require.NoError(t, err)
+	}
+	_, _ = pw.Write(nil)
+
+	require.Len(t, got, 4)
+	assert.InDelta(t, 25, got[0].Percent, 0.001)
+	assert.InDelta(t, 100, got[3].Percent, 0.001)
+	assert.Zero(t, got[3].ETA)
+	assert.Equal(t, 200, buf.Len())
+}
+
+func TestProgressFromWithoutFunc(t *testing.T) {
+	assert.NotPanics(t, func() { progressFrom(context.Background())(Progress{}) })
+}
@@ -2,15 +2,12 @@ package downloader
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
-
-	"github.com/kkdai/youtube/v2"
 )
 
 // YoutubeDLDownloader downloads audio and thumbnails using the yt-dlp CLI (or Docker fallback).
@@ -41,18 +38,18 @@ func (d *YoutubeDLDownloader) BackendDescription() string {
 	return d.config().BackendDescription()
 }
 
-func (d *YoutubeDLDownloader) GetVideo(videoID string) (*youtube.Video, error) {
-	return &youtube.Video{ID: videoID}, nil
+func (d *YoutubeDLDownloader) GetVideo(videoID string) (*Metadata, error) {
+	return &Metadata{ID: videoID}, nil
 }
 
-func (d *YoutubeDLDownloader) DownloadVideo(ctx context.Context, videoID string, logger *slog.Logger) (string, *youtube.Video, error) {
+func (d *YoutubeDLDownloader) DownloadVideo(ctx context.Context, videoID string, logger *slog.Logger) (string, *Metadata, error) {
 	videoID = normalizeVideoID(videoID)
 	logger.Info("DownloadVideo", "videoID", videoID)
 
 	cfg := d.config()
 	songRoot := cfg.songRoot()
 
-	video := &youtube.Video{ID: videoID}
+	meta := &Metadata{ID: videoID}
 	var filename string
 	var downloadErr error
 	var wg sync.WaitGroup
@@ -61,9 +58,7 @@ func (d *YoutubeDLDownloader) DownloadVideo(ctx context.Context, videoID string,
 	go func() {
 		defer wg.Done()
 		if info, err := getVideoInfo(ctx, videoID, cfg); err == nil {
-			if t, ok := info["title"].(string); ok {
-				video.Title = t
-			}
+			meta = info
 		} else {
 			logger.Error("getVideoInfo", "err", err)
 		}
@@ -96,7 +91,7 @@ func (d *YoutubeDLDownloader) DownloadVideo(ctx context.Context, videoID string,
 		return "", nil, err
 	}
 
-	return filename, video, nil
+	return filename, meta, nil
 }
 
 func (d *YoutubeDLDownloader) GetVideoFilename(ctx context.Context, videoID string, _ *slog.Logger) (string, error) {
@@ -118,6 +113,7 @@ func (d *YoutubeDLDownloader) GetVideoFilename(ctx context.Context, videoID stri
 }
 
 // normalizeVideoID rewrites music.youtube URLs to the standard youtube domain.
+// Links to other sites yt-dlp supports are passed through unchanged.
 func normalizeVideoID(videoID string) string {
 	return strings.Replace(videoID, "//music.", "//", 1)
 }
@@ -127,17 +123,13 @@ var getVideoInfoArgs = []string{
 	"--skip-download", "--restrict-filenames", "-J",
 }
 
-func getVideoInfo(ctx context.Context, videoID string, cfg *YoutubeDLConfig) (map[string]any, error) {
+func getVideoInfo(ctx context.Context, videoID string, cfg *YoutubeDLConfig) (*Metadata, error) {
 	cmd := cfg.newMetaCmd(getVideoInfoArgs)
 	out, err := cmd.ExecBContext(ctx, videoID)
 	if err != nil {
 		return nil, fmt.Errorf("getVideoInfo: %w", err)
 	}
-	var info map[string]any
-	if err := json.Unmarshal(out, &info); err != nil {
-		return nil, fmt.Errorf("getVideoInfo json: %w", err)
-	}
-	return info, nil
+	return parseMetadata(videoID, out)
 }
 
 func getNewestFile(dir string) (string, error) {
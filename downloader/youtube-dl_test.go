@@ -8,7 +8,6 @@ import (
 	"testing"
 
 	"github.com/jaredwarren/rpi_music/log"
-	"github.com/kkdai/youtube/v2"
 	"github.com/stretchr/testify/require"
 )
 
@@ -26,7 +25,7 @@ func TestDownloadThumb(t *testing.T) {
 
 	thumbDir := t.TempDir()
 	v := NewYoutubeDLDownloader(&YoutubeDLConfig{ThumbRoot: thumbDir})
-	thumb, err := v.DownloadThumb(&youtube.Video{
+	thumb, err := v.DownloadThumb(&Metadata{
 		ID: "https://youtu.be/ZJocdnMvTYs",
 	})
 
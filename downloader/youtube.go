@@ -19,11 +19,11 @@ import (
 
 const httpClientTimeout = 60 * time.Second
 
-// Downloader is the interface for downloading YouTube audio and thumbnails.
+// Downloader is the interface for downloading audio and thumbnails.
 type Downloader interface {
-	GetVideo(videoID string) (*youtube.Video, error)
-	DownloadVideo(ctx context.Context, videoID string, logger *slog.Logger) (string, *youtube.Video, error)
-	DownloadThumb(video *youtube.Video) (string, error)
+	GetVideo(videoID string) (*Metadata, error)
+	DownloadVideo(ctx context.Context, videoID string, logger *slog.Logger) (string, *Metadata, error)
+	DownloadThumb(meta *Metadata) (string, error)
 	GetVideoFilename(ctx context.Context, videoID string, logger *slog.Logger) (string, error)
 }
 
@@ -47,21 +47,26 @@ func (d *YoutubeDownloader) thumbRoot() string {
 	return defaultThumbDir
 }
 
-func (d *YoutubeDownloader) GetVideo(videoID string) (*youtube.Video, error) {
+func (d *YoutubeDownloader) GetVideo(videoID string) (*Metadata, error) {
 	client := youtube.Client{Debug: true}
-	return client.GetVideo(videoID)
+	video, err := client.GetVideo(videoID)
+	if err != nil {
+		return nil, err
+	}
+	return metadataFromVideo(video), nil
 }
 
-func (d *YoutubeDownloader) DownloadVideo(ctx context.Context, videoID string, logger *slog.Logger) (string, *youtube.Video, error) {
+func (d *YoutubeDownloader) DownloadVideo(ctx context.Context, videoID string, logger *slog.Logger) (string, *Metadata, error) {
 	client := youtube.Client{}
 	video, err := client.GetVideo(videoID)
 	if err != nil {
-		return "", video, err
+		return "", nil, err
 	}
+	meta := metadataFromVideo(video)
 
 	formats := video.Formats.WithAudioChannels()
 	if len(formats) == 0 {
-		return "", video, ErrNoAudioFormats
+		return "", meta, ErrNoAudioFormats
 	}
 	sort.Slice(formats, func(i, j int) bool {
 		return formats[i].AverageBitrate > formats[j].AverageBitrate
@@ -76,22 +81,23 @@ func (d *YoutubeDownloader) DownloadVideo(ctx context.Context, videoID string, l
 	if _, err := os.Stat(fileName); err != nil && errors.Is(err, os.ErrNotExist) {
 		stream, _, err := client.GetStream(video, &bestFormat)
 		if err != nil {
-			return fileName, video, err
+			return fileName, meta, err
 		}
 
 		file, err := os.Create(fileName)
 		if err != nil {
-			return fileName, video, err
+			return fileName, meta, err
 		}
 		defer func() { _ = file.Close() }()
 
-		if _, err = io.Copy(file, stream); err != nil {
-			return fileName, video, err
+		pw := &progressWriter{w: file, total: bestFormat.ContentLength, started: time.Now(), report: progressFrom(ctx)}
+		if _, err = io.Copy(pw, stream); err != nil {
+			return fileName, meta, err
 		}
 	} else if err != nil {
-		return fileName, video, err
+		return fileName, meta, err
 	}
-	return fileName, video, nil
+	return fileName, meta, nil
 }
 
 func (d *YoutubeDownloader) GetVideoFilename(_ context.Context, _ string, _ *slog.Logger) (string, error) {
@@ -109,21 +115,15 @@ func getExt(mimeType string) string {
 	return ""
 }
 
-func (d *YoutubeDownloader) DownloadThumb(video *youtube.Video) (string, error) {
-	if len(video.Thumbnails) == 0 {
+func (d *YoutubeDownloader) DownloadThumb(meta *Metadata) (string, error) {
+	thumb, ok := meta.bestThumbnail()
+	if !ok {
 		return "", fmt.Errorf("no thumbs for video")
 	}
 
-	thumb := video.Thumbnails[0]
-	for _, t := range video.Thumbnails {
-		if t.Width > thumb.Width {
-			thumb = t
-		}
-	}
-
 	fileURL := thumb.URL
 	ext := strings.Split(filepath.Ext(fileURL), "?")[0]
-	sEnc := base64.StdEncoding.EncodeToString([]byte(video.Title))
+	sEnc := base64.StdEncoding.EncodeToString([]byte(meta.Title))
 	fileName := filepath.Join(d.thumbRoot(), fmt.Sprintf("%s%s", sEnc, ext))
 
 	return fileName, downloadFile(fileURL, fileName)
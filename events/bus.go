@@ -0,0 +1,185 @@
+// Package events is an in-process publish/subscribe bus for typed application
+// events, with a short history so reconnecting clients can catch up.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Type names a kind of event. It is also the SSE event name.
+type Type string
+
+// Event types and the type of their Data.
+const (
+	Notification     Type = "notification"      // Notice
+	PlayerStarted    Type = "player_started"    // *model.Song
+	PlayerStopped    Type = "player_stopped"    // nil
+	DownloadProgress Type = "download_progress" // Download
+	JobChanged       Type = "job_changed"       // *model.DownloadJob
+	RFIDScanned      Type = "rfid_scanned"      // server scan details
+	ConfigChanged    Type = "config_changed"    // nil
+	LibraryChanged   Type = "library_changed"   // LibraryChange
+	SleepChanged     Type = "sleep"             // server sleep timer status
+	LearnChanged     Type = "learn"             // server learn mode status
+)
+
+// DefaultHistory is how many past events NewBus keeps for replay by default.
+const DefaultHistory = 256
+
+// subscriberBuffer is how many events a subscriber may fall behind before
+// further events are dropped for it.
+const subscriberBuffer = 16
+
+// Event is one published event. IDs increase by one per event on a Bus.
+type Event struct {
+	ID   uint64    `json:"id"`
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+	Data any       `json:"data"`
+}
+
+// Notice is a message meant to be shown to the user.
+type Notice struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// Download states.
+const (
+	DownloadStarted  = "started"
+	DownloadRunning  = "downloading"
+	DownloadFinished = "finished"
+	DownloadFailed   = "failed"
+)
+
+// Download reports the progress of one download.
+type Download struct {
+	URL        string  `json:"url"`
+	State      string  `json:"state"`
+	Percent    float64 `json:"percent"`
+	Speed      float64 `json:"speed,omitempty"`       // bytes per second
+	ETASeconds float64 `json:"eta_seconds,omitempty"` // time left
+	SongID     string  `json:"song_id,omitempty"`
+	Title      string  `json:"title,omitempty"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// Library change actions.
+const (
+	SongCreated  = "song_created"
+	SongUpdated  = "song_updated"
+	SongDeleted  = "song_deleted"
+	CardAssigned = "card_assigned"
+	CardRemoved  = "card_removed"
+)
+
+// LibraryChange says what changed in the song library or card mappings.
+type LibraryChange struct {
+	Action string `json:"action"`
+	SongID string `json:"song_id,omitempty"`
+	RFID   string `json:"rfid,omitempty"`
+}
+
+// Bus fans published events out to subscribers. Publishing to a nil *Bus does
+// nothing.
+type Bus struct {
+	mu      sync.Mutex
+	size    int
+	lastID  uint64
+	history []Event
+	subs    map[*Subscription]struct{}
+}
+
+// NewBus returns a Bus that keeps the last history events for replay.
+func NewBus(history int) *Bus {
+	return &Bus{size: history, subs: make(map[*Subscription]struct{})}
+}
+
+// Publish sends an event to every subscriber whose filter accepts it. Events
+// are dropped for subscribers that are behind, so Publish never blocks.
+func (b *Bus) Publish(t Type, data any) Event {
+	if b == nil {
+		return Event{}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastID++
+	ev := Event{ID: b.lastID, Type: t, Time: time.Now(), Data: data}
+	if b.size > 0 {
+		b.history = append(b.history, ev)
+		if len(b.history) > b.size {
+			b.history = b.history[len(b.history)-b.size:]
+		}
+	}
+	for sub := range b.subs {
+		if !sub.accepts(t) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+		}
+	}
+	return ev
+}
+
+// Subscription receives events on C until it is closed.
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	filter map[Type]bool // nil accepts every type
+	bus    *Bus
+}
+
+func (s *Subscription) accepts(t Type) bool {
+	return s.filter == nil || s.filter[t]
+}
+
+// Close stops delivery and closes C.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if _, ok := s.bus.subs[s]; ok {
+		delete(s.bus.subs, s)
+		close(s.ch)
+	}
+}
+
+// Subscribe returns a subscription to events of the given types, or of every
+// type if none are given.
+func (b *Bus) Subscribe(types ...Type) *Subscription {
+	sub, _ := b.subscribe(0, false, types)
+	return sub
+}
+
+// SubscribeSince is Subscribe that also returns the kept events published
+// after lastID, so a client that saw lastID misses nothing in between.
+func (b *Bus) SubscribeSince(lastID uint64, types ...Type) (*Subscription, []Event) {
+	return b.subscribe(lastID, true, types)
+}
+
+func (b *Bus) subscribe(lastID uint64, replay bool, types []Type) (*Subscription, []Event) {
+	ch := make(chan Event, subscriberBuffer)
+	sub := &Subscription{C: ch, ch: ch, bus: b}
+	if len(types) > 0 {
+		sub.filter = make(map[Type]bool, len(types))
+		for _, t := range types {
+			sub.filter[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[sub] = struct{}{}
+	var missed []Event
+	// An ID from the future was handed out before a restart; nothing to replay.
+	if replay && lastID <= b.lastID {
+		for _, ev := range b.history {
+			if ev.ID > lastID && sub.accepts(ev.Type) {
+				missed = append(missed, ev)
+			}
+		}
+	}
+	return sub, missed
+}
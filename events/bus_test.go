@@ -0,0 +1,70 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBusFiltersByType(t *testing.T) {
+	b := NewBus(0)
+	all := b.Subscribe()
+	scans := b.Subscribe(RFIDScanned)
+
+	b.Publish(PlayerStopped, nil)
+	b.Publish(RFIDScanned, "aa")
+
+	assert.Equal(t, PlayerStopped, (<-all.C).Type)
+	assert.Equal(t, RFIDScanned, (<-all.C).Type)
+	ev := <-scans.C
+	assert.Equal(t, uint64(2), ev.ID)
+	assert.Equal(t, "aa", ev.Data)
+	assert.Empty(t, scans.C)
+}
+
+func TestBusSubscribeSinceReplaysHistory(t *testing.T) {
+	b := NewBus(3)
+	for range 5 {
+		b.Publish(Notification, nil)
+	}
+	b.Publish(ConfigChanged, nil)
+
+	sub, missed := b.SubscribeSince(2)
+	defer sub.Close()
+	require.Len(t, missed, 3) // only the last three are kept
+	assert.Equal(t, []uint64{4, 5, 6}, []uint64{missed[0].ID, missed[1].ID, missed[2].ID})
+
+	sub2, missed := b.SubscribeSince(2, ConfigChanged)
+	defer sub2.Close()
+	require.Len(t, missed, 1)
+	assert.Equal(t, uint64(6), missed[0].ID)
+
+	sub3, missed := b.SubscribeSince(99)
+	defer sub3.Close()
+	assert.Empty(t, missed)
+}
+
+func TestBusDropsForSlowSubscriber(t *testing.T) {
+	b := NewBus(0)
+	sub := b.Subscribe()
+	for range subscriberBuffer + 5 {
+		b.Publish(Notification, nil)
+	}
+	assert.Len(t, sub.C, subscriberBuffer)
+}
+
+func TestSubscriptionClose(t *testing.T) {
+	b := NewBus(0)
+	sub := b.Subscribe()
+	sub.Close()
+	sub.Close()
+	b.Publish(Notification, nil)
+	_, ok := <-sub.C
+	assert.False(t, ok)
+}
+
+func TestNilBusPublish(t *testing.T) {
+	var b *Bus
+	assert.Zero(t, b.Publish(Notification, nil))
+}
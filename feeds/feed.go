@@ -0,0 +1,177 @@
+// Package feeds reads podcast RSS and Atom feeds.
+package feeds
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	fetchTimeout = 30 * time.Second
+	maxFeedSize  = 10 << 20
+)
+
+// ErrNotFeed is returned by Parse for documents that are neither RSS nor Atom.
+var ErrNotFeed = errors.New("feeds: not an RSS or Atom feed")
+
+var httpClient = &http.Client{Timeout: fetchTimeout}
+
+// Feed is a podcast and its episodes, newest first.
+type Feed struct {
+	Title    string
+	Episodes []Episode
+}
+
+// Episode is one feed item with an audio enclosure.
+type Episode struct {
+	GUID      string // the item's guid or id; the enclosure URL if it has none
+	Title     string
+	URL       string // enclosure
+	Published time.Time
+}
+
+// Fetch downloads and parses the feed at url.
+func Fetch(ctx context.Context, url string) (*Feed, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feeds: %s: unexpected status %d", url, res.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxFeedSize))
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+type rssDoc struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Items []struct {
+			Title     string `xml:"title"`
+			GUID      string `xml:"guid"`
+			PubDate   string `xml:"pubDate"`
+			Enclosure struct {
+				URL string `xml:"url,attr"`
+			} `xml:"enclosure"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+type atomDoc struct {
+	Title   string `xml:"title"`
+	Entries []struct {
+		ID        string `xml:"id"`
+		Title     string `xml:"title"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Links     []struct {
+			Rel  string `xml:"rel,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+// Parse reads an RSS 2.0 or Atom document. Items without an audio enclosure
+// are skipped.
+func Parse(data []byte) (*Feed, error) {
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, err
+	}
+	f := &Feed{}
+	switch root {
+	case "rss":
+		var doc rssDoc
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("feeds: rss: %w", err)
+		}
+		f.Title = strings.TrimSpace(doc.Channel.Title)
+		for _, it := range doc.Channel.Items {
+			f.add(it.GUID, it.Title, it.Enclosure.URL, parseTime(it.PubDate))
+		}
+	case "feed":
+		var doc atomDoc
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("feeds: atom: %w", err)
+		}
+		f.Title = strings.TrimSpace(doc.Title)
+		for _, e := range doc.Entries {
+			var url string
+			for _, l := range e.Links {
+				if l.Rel == "enclosure" {
+					url = l.Href
+					break
+				}
+			}
+			published := parseTime(e.Published)
+			if published.IsZero() {
+				published = parseTime(e.Updated)
+			}
+			f.add(e.ID, e.Title, url, published)
+		}
+	default:
+		return nil, ErrNotFeed
+	}
+	sort.SliceStable(f.Episodes, func(i, j int) bool {
+		return f.Episodes[i].Published.After(f.Episodes[j].Published)
+	})
+	return f, nil
+}
+
+func (f *Feed) add(guid, title, url string, published time.Time) {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return
+	}
+	guid = strings.TrimSpace(guid)
+	if guid == "" {
+		guid = url
+	}
+	f.Episodes = append(f.Episodes, Episode{GUID: guid, Title: strings.TrimSpace(title), URL: url, Published: published})
+}
+
+// rootElement returns the local name of the document's first element.
+func rootElement(data []byte) (string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", ErrNotFeed
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se.Name.Local, nil
+		}
+	}
+}
+
+// timeLayouts are the date formats seen in the wild, RFC 822 variants first.
+var timeLayouts = []string{
+	time.RFC1123Z, time.RFC1123, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700", time.RFC3339,
+}
+
+func parseTime(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,77 @@
+package feeds
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRSS(t *testing.T) {
+	data, err := os.ReadFile("testdata/podcast.rss")
+	require.NoError(t, err)
+	f, err := Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, "Bedtime Stories", f.Title)
+	require.Len(t, f.Episodes, 2) // the trailer has no enclosure
+	assert.Equal(t, Episode{
+		GUID:      "ep-2",
+		Title:     "Episode 2",
+		URL:       "EPISODE_BASE/ep2.mp3",
+		Published: time.Date(2026, 10, 12, 18, 0, 0, 0, time.UTC),
+	}, f.Episodes[0])
+	assert.Equal(t, "ep-1", f.Episodes[1].GUID)
+}
+
+func TestParseAtom(t *testing.T) {
+	f, err := Parse([]byte(`<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Science Kids</title>
+  <entry>
+    <id>urn:a</id><title>Old</title><updated>2026-01-01T00:00:00Z</updated>
+    <link rel="alternate" href="https://example.com/a"/>
+    <link rel="enclosure" href="https://example.com/a.mp3"/>
+  </entry>
+  <entry>
+    <title>New</title><published>2026-02-01T00:00:00Z</published>
+    <link rel="enclosure" href="https://example.com/b.mp3"/>
+  </entry>
+</feed>`))
+	require.NoError(t, err)
+	assert.Equal(t, "Science Kids", f.Title)
+	require.Len(t, f.Episodes, 2)
+	assert.Equal(t, "https://example.com/b.mp3", f.Episodes[0].GUID)
+	assert.Equal(t, "urn:a", f.Episodes[1].GUID)
+
+	_, err = Parse([]byte(`<html><body>nope</body></html>`))
+	require.ErrorIs(t, err, ErrNotFeed)
+	_, err = Parse([]byte(`not xml`))
+	require.ErrorIs(t, err, ErrNotFeed)
+}
+
+func TestFetch(t *testing.T) {
+	data, err := os.ReadFile("testdata/podcast.rss")
+	require.NoError(t, err)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/feed.rss" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(strings.ReplaceAll(string(data), "EPISODE_BASE", "http://"+r.Host)))
+	}))
+	defer srv.Close()
+
+	f, err := Fetch(context.Background(), srv.URL+"/feed.rss")
+	require.NoError(t, err)
+	require.Len(t, f.Episodes, 2)
+	assert.Equal(t, srv.URL+"/ep2.mp3", f.Episodes[0].URL)
+
+	_, err = Fetch(context.Background(), srv.URL+"/missing")
+	require.Error(t, err)
+}
@@ -0,0 +1,368 @@
+// Package jobs runs download jobs from a persistent queue with a bounded
+// number of workers, retrying failures with exponential backoff.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/model"
+)
+
+// Defaults for the zero values in Config.
+const (
+	DefaultWorkers     = 2
+	DefaultMaxAttempts = 4
+	DefaultBackoff     = 30 * time.Second
+	DefaultMaxBackoff  = 15 * time.Minute
+	DefaultRetention   = 7 * 24 * time.Hour
+)
+
+// pruneInterval is how often finished jobs past their retention are deleted.
+const pruneInterval = time.Hour
+
+// errCancelled is the Error recorded on jobs cancelled by the user.
+const errCancelled = "cancelled"
+
+var (
+	// ErrNotCancellable is returned by Cancel for jobs that are not queued or running.
+	ErrNotCancellable = errors.New("jobs: job is not queued or running")
+	// ErrNotRetryable is returned by Retry for jobs that have not failed.
+	ErrNotRetryable = errors.New("jobs: job has not failed")
+)
+
+// RunFunc downloads job and returns the song it created.
+type RunFunc func(ctx context.Context, job *model.DownloadJob) (*model.Song, error)
+
+// Config tunes a Queue. Zero values use the defaults above.
+type Config struct {
+	Workers     int
+	MaxAttempts int           // attempts before a job fails for good
+	Backoff     time.Duration // wait before the first retry; doubled for each later one
+	MaxBackoff  time.Duration
+	Retention   time.Duration // how long done and failed jobs are kept
+	Events      *events.Bus   // receives events.JobChanged; may be nil
+	// OnFinish is called when a job is done or has failed for good.
+	OnFinish func(job *model.DownloadJob, song *model.Song)
+}
+
+func (c Config) workers() int {
+	if c.Workers <= 0 {
+		return DefaultWorkers
+	}
+	return c.Workers
+}
+
+func (c Config) maxAttempts() int {
+	if c.MaxAttempts <= 0 {
+		return DefaultMaxAttempts
+	}
+	return c.MaxAttempts
+}
+
+func (c Config) retention() time.Duration {
+	if c.Retention <= 0 {
+		return DefaultRetention
+	}
+	return c.Retention
+}
+
+// backoff returns how long to wait after the given number of failed attempts.
+func (c Config) backoff(attempts int) time.Duration {
+	base, limit := c.Backoff, c.MaxBackoff
+	if base <= 0 {
+		base = DefaultBackoff
+	}
+	if limit <= 0 {
+		limit = DefaultMaxBackoff
+	}
+	d := base
+	for i := 1; i < attempts && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+// permanentError marks a failure that retrying cannot fix.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job fails without being retried.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Queue stores jobs in a db.JobStore and runs them with RunFunc.
+type Queue struct {
+	store  db.JobStore
+	run    RunFunc
+	cfg    Config
+	logger *slog.Logger
+
+	mu        sync.Mutex // serialises job state changes
+	running   map[string]context.CancelFunc
+	lastPrune time.Time
+	wake      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// New returns a Queue. Call Run to start working through it.
+func New(store db.JobStore, run RunFunc, cfg Config, logger *slog.Logger) *Queue {
+	return &Queue{
+		store:   store,
+		run:     run,
+		cfg:     cfg,
+		logger:  logger,
+		running: make(map[string]context.CancelFunc),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Enqueue adds a job to download url. If a queued or running job already has
+// the same url, that job is returned instead.
+func (q *Queue) Enqueue(url, rfid string, force bool) (*model.DownloadJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs, err := q.store.ListJobsByState(model.JobQueued, model.JobRunning)
+	if err != nil {
+		return nil, fmt.Errorf("ListJobsByState|%w", err)
+	}
+	for _, job := range jobs {
+		if job.URL == url {
+			return job, nil
+		}
+	}
+	job := &model.DownloadJob{ID: uuid.New().String(), URL: url, RFID: rfid, Force: force, State: model.JobQueued}
+	if err := q.saveLocked(job); err != nil {
+		return nil, err
+	}
+	q.notify()
+	return job, nil
+}
+
+// List returns every job, oldest first.
+func (q *Queue) List() ([]*model.DownloadJob, error) {
+	return q.store.ListJobs()
+}
+
+// Cancel stops a queued or running job and marks it failed.
+func (q *Queue) Cancel(id string) (*model.DownloadJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, err := q.store.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	switch job.State {
+	case model.JobRunning:
+		// The worker records the failure once the download stops.
+		if cancel, ok := q.running[id]; ok {
+			cancel()
+		}
+		return job, nil
+	case model.JobQueued:
+		job.State, job.Error = model.JobFailed, errCancelled
+		if err := q.saveLocked(job); err != nil {
+			return nil, err
+		}
+		return job, nil
+	default:
+		return nil, ErrNotCancellable
+	}
+}
+
+// Retry queues a failed job again with a fresh set of attempts.
+func (q *Queue) Retry(id string) (*model.DownloadJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, err := q.store.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.State != model.JobFailed {
+		return nil, ErrNotRetryable
+	}
+	job.State, job.Attempts, job.Error, job.NextAttempt = model.JobQueued, 0, "", time.Time{}
+	if err := q.saveLocked(job); err != nil {
+		return nil, err
+	}
+	q.notify()
+	return job, nil
+}
+
+// Run starts queued jobs until ctx is done, then waits for running jobs to
+// stop. Jobs left running by an earlier process are queued again first.
+func (q *Queue) Run(ctx context.Context) {
+	q.requeueInterrupted()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		wait := q.dispatch(ctx)
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if wait > 0 {
+			timer.Reset(wait)
+		}
+		select {
+		case <-ctx.Done():
+			q.wg.Wait()
+			return
+		case <-q.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// notify wakes Run to look for jobs to start.
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *Queue) requeueInterrupted() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs, err := q.store.ListJobsByState(model.JobRunning)
+	if err != nil {
+		q.logger.Error("jobs|ListJobsByState", "err", err)
+		return
+	}
+	for _, job := range jobs {
+		job.State = model.JobQueued
+		if err := q.saveLocked(job); err != nil {
+			q.logger.Error("jobs|requeue", "job", job.ID, "err", err)
+		}
+	}
+}
+
+// dispatch starts due jobs while workers are free. It returns how long until
+// the next queued job is due, or 0 if there is nothing to wait for.
+func (q *Queue) dispatch(ctx context.Context) time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if ctx.Err() != nil {
+		return 0
+	}
+	now := time.Now()
+	if now.Sub(q.lastPrune) >= pruneInterval {
+		q.pruneLocked(now)
+	}
+	jobs, err := q.store.ListJobsByState(model.JobQueued)
+	if err != nil {
+		q.logger.Error("jobs|ListJobsByState", "err", err)
+		return q.cfg.backoff(1)
+	}
+	var wait time.Duration
+	for _, job := range jobs {
+		if d := job.NextAttempt.Sub(now); d > 0 {
+			if wait == 0 || d < wait {
+				wait = d
+			}
+			continue
+		}
+		if len(q.running) >= q.cfg.workers() {
+			continue
+		}
+		job.State = model.JobRunning
+		if err := q.saveLocked(job); err != nil {
+			q.logger.Error("jobs|start", "job", job.ID, "err", err)
+			continue
+		}
+		jobCtx, cancel := context.WithCancel(ctx)
+		q.running[job.ID] = cancel
+		q.wg.Add(1)
+		go q.work(ctx, jobCtx, job)
+	}
+	return wait
+}
+
+// pruneLocked deletes done and failed jobs last changed before the retention
+// period, so the store does not grow for as long as the player runs.
+func (q *Queue) pruneLocked(now time.Time) {
+	q.lastPrune = now
+	jobs, err := q.store.ListJobsByState(model.JobDone, model.JobFailed)
+	if err != nil {
+		q.logger.Error("jobs|prune", "err", err)
+		return
+	}
+	cutoff := now.Add(-q.cfg.retention())
+	for _, job := range jobs {
+		if job.UpdatedAt.Before(cutoff) {
+			if err := q.store.DeleteJob(job.ID); err != nil {
+				q.logger.Error("jobs|prune", "job", job.ID, "err", err)
+			}
+		}
+	}
+}
+
+// work runs one attempt at job and records the outcome.
+func (q *Queue) work(ctx, jobCtx context.Context, job *model.DownloadJob) {
+	defer q.wg.Done()
+	defer q.notify()
+	song, err := q.run(jobCtx, job)
+
+	q.mu.Lock()
+	cancel := q.running[job.ID]
+	delete(q.running, job.ID)
+	cancelled := jobCtx.Err() != nil
+	cancel()
+
+	finished := true
+	switch {
+	case err == nil:
+		job.State, job.Error = model.JobDone, ""
+		if song != nil {
+			job.SongID = song.ID
+		}
+	case ctx.Err() != nil:
+		// Shutting down; pick the job up again on the next start.
+		job.State = model.JobQueued
+		finished = false
+	case cancelled:
+		job.State, job.Error = model.JobFailed, errCancelled
+	default:
+		job.Attempts++
+		job.Error = err.Error()
+		var perm permanentError
+		if errors.As(err, &perm) || job.Attempts >= q.cfg.maxAttempts() {
+			job.State = model.JobFailed
+		} else {
+			job.State = model.JobQueued
+			job.NextAttempt = time.Now().Add(q.cfg.backoff(job.Attempts))
+			finished = false
+		}
+		q.logger.Error("jobs|run", "job", job.ID, "url", job.URL, "attempt", job.Attempts, "err", err)
+	}
+	if err := q.saveLocked(job); err != nil {
+		q.logger.Error("jobs|finish", "job", job.ID, "err", err)
+	}
+	q.mu.Unlock()
+	if finished && q.cfg.OnFinish != nil {
+		q.cfg.OnFinish(job, song)
+	}
+}
+
+// saveLocked stores job and publishes the change.
+func (q *Queue) saveLocked(job *model.DownloadJob) error {
+	if err := q.store.SaveJob(job); err != nil {
+		return fmt.Errorf("SaveJob|%w", err)
+	}
+	cp := *job
+	q.cfg.Events.Publish(events.JobChanged, &cp)
+	return nil
+}
@@ -0,0 +1,233 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startQueue runs a queue over store until the test ends.
+func startQueue(t *testing.T, store db.JobStore, run RunFunc, cfg Config) *Queue {
+	t.Helper()
+	q := New(store, run, cfg, log.NewNoOpLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return q
+}
+
+// waitState waits for job id to reach state.
+func waitState(t *testing.T, store db.JobStore, id string, state model.JobState) *model.DownloadJob {
+	t.Helper()
+	var job *model.DownloadJob
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = store.GetJob(id)
+		return err == nil && job.State == state
+	}, 2*time.Second, time.Millisecond)
+	return job
+}
+
+func TestQueueRunsJob(t *testing.T) {
+	store := &db.MockDB{}
+	bus := events.NewBus(0)
+	sub := bus.Subscribe(events.JobChanged)
+	finished := make(chan *model.DownloadJob, 1)
+	q := startQueue(t, store, func(ctx context.Context, job *model.DownloadJob) (*model.Song, error) {
+		return &model.Song{ID: "song-" + job.URL}, nil
+	}, Config{Events: bus, OnFinish: func(job *model.DownloadJob, _ *model.Song) { finished <- job }})
+
+	job, err := q.Enqueue("u1", "rfid", true)
+	require.NoError(t, err)
+	assert.Equal(t, model.JobQueued, job.State)
+
+	done := <-finished
+	assert.Equal(t, model.JobDone, done.State)
+	assert.Equal(t, "song-u1", done.SongID)
+	waitState(t, store, job.ID, model.JobDone)
+
+	var states []model.JobState
+	for range 3 {
+		states = append(states, (<-sub.C).Data.(*model.DownloadJob).State)
+	}
+	assert.Equal(t, []model.JobState{model.JobQueued, model.JobRunning, model.JobDone}, states)
+}
+
+func TestQueueEnqueueReusesActiveJob(t *testing.T) {
+	store := &db.MockDB{}
+	q := New(store, nil, Config{}, log.NewNoOpLogger())
+	a, err := q.Enqueue("u1", "", false)
+	require.NoError(t, err)
+	b, err := q.Enqueue("u1", "", false)
+	require.NoError(t, err)
+	assert.Equal(t, a.ID, b.ID)
+
+	a.State = model.JobDone
+	require.NoError(t, store.SaveJob(a))
+	c, err := q.Enqueue("u1", "", false)
+	require.NoError(t, err)
+	assert.NotEqual(t, a.ID, c.ID)
+}
+
+func TestQueueRetriesWithBackoff(t *testing.T) {
+	store := &db.MockDB{}
+	var mu sync.Mutex
+	var calls []time.Time
+	q := startQueue(t, store, func(ctx context.Context, job *model.DownloadJob) (*model.Song, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, time.Now())
+		if len(calls) < 3 {
+			return nil, errors.New("flaky")
+		}
+		return &model.Song{ID: "s"}, nil
+	}, Config{Backoff: 20 * time.Millisecond})
+
+	job, err := q.Enqueue("u1", "", false)
+	require.NoError(t, err)
+	done := waitState(t, store, job.ID, model.JobDone)
+	assert.Equal(t, 2, done.Attempts)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, calls, 3)
+	assert.GreaterOrEqual(t, calls[1].Sub(calls[0]), 20*time.Millisecond)
+	assert.GreaterOrEqual(t, calls[2].Sub(calls[1]), 40*time.Millisecond)
+}
+
+func TestQueueGivesUp(t *testing.T) {
+	store := &db.MockDB{}
+	q := startQueue(t, store, func(ctx context.Context, job *model.DownloadJob) (*model.Song, error) {
+		if job.URL == "perm" {
+			return nil, Permanent(errors.New("already downloaded"))
+		}
+		return nil, errors.New("offline")
+	}, Config{MaxAttempts: 2, Backoff: time.Millisecond})
+
+	perm, err := q.Enqueue("perm", "", false)
+	require.NoError(t, err)
+	flaky, err := q.Enqueue("flaky", "", false)
+	require.NoError(t, err)
+
+	got := waitState(t, store, perm.ID, model.JobFailed)
+	assert.Equal(t, 1, got.Attempts)
+	assert.Equal(t, "already downloaded", got.Error)
+	got = waitState(t, store, flaky.ID, model.JobFailed)
+	assert.Equal(t, 2, got.Attempts)
+	assert.Equal(t, "offline", got.Error)
+}
+
+func TestQueueCancelAndRetry(t *testing.T) {
+	store := &db.MockDB{}
+	started := make(chan struct{}, 1)
+	block := true
+	var mu sync.Mutex
+	q := startQueue(t, store, func(ctx context.Context, job *model.DownloadJob) (*model.Song, error) {
+		mu.Lock()
+		wait := block
+		mu.Unlock()
+		if !wait {
+			return &model.Song{ID: "s"}, nil
+		}
+		started <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, Config{Workers: 1})
+
+	running, err := q.Enqueue("u1", "", false)
+	require.NoError(t, err)
+	<-started
+	queued, err := q.Enqueue("u2", "", false)
+	require.NoError(t, err)
+
+	// u2 waits for the only worker, so cancelling it never runs it.
+	got, err := q.Cancel(queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.JobFailed, got.State)
+
+	_, err = q.Cancel(running.ID)
+	require.NoError(t, err)
+	got = waitState(t, store, running.ID, model.JobFailed)
+	assert.Equal(t, errCancelled, got.Error)
+	assert.Zero(t, got.Attempts)
+
+	_, err = q.Cancel(running.ID)
+	require.ErrorIs(t, err, ErrNotCancellable)
+	_, err = q.Cancel("missing")
+	require.ErrorIs(t, err, db.ErrNotFound)
+
+	mu.Lock()
+	block = false
+	mu.Unlock()
+	got, err = q.Retry(running.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.JobQueued, got.State)
+	assert.Empty(t, got.Error)
+	waitState(t, store, running.ID, model.JobDone)
+	_, err = q.Retry(running.ID)
+	require.ErrorIs(t, err, ErrNotRetryable)
+}
+
+func TestQueueRequeuesInterruptedJobs(t *testing.T) {
+	store := &db.MockDB{}
+	require.NoError(t, store.SaveJob(&model.DownloadJob{ID: "j1", URL: "u1", State: model.JobRunning}))
+	startQueue(t, store, func(ctx context.Context, job *model.DownloadJob) (*model.Song, error) {
+		return &model.Song{ID: "s"}, nil
+	}, Config{})
+	waitState(t, store, "j1", model.JobDone)
+}
+
+func TestQueuePrunesFinishedJobs(t *testing.T) {
+	store := &db.MockDB{}
+	for _, job := range []*model.DownloadJob{
+		{ID: "old-done", URL: "u1", State: model.JobDone},
+		{ID: "old-failed", URL: "u2", State: model.JobFailed},
+		{ID: "new-done", URL: "u3", State: model.JobDone},
+		{ID: "old-queued", URL: "u4", State: model.JobQueued},
+	} {
+		require.NoError(t, store.SaveJob(job))
+	}
+	for _, id := range []string{"old-done", "old-failed", "old-queued"} {
+		store.Jobs[id].UpdatedAt = time.Now().Add(-2 * time.Hour)
+	}
+	run := make(chan struct{})
+	startQueue(t, store, func(ctx context.Context, job *model.DownloadJob) (*model.Song, error) {
+		<-run
+		return &model.Song{ID: "s"}, nil
+	}, Config{Retention: time.Hour})
+	waitState(t, store, "old-queued", model.JobRunning)
+	close(run)
+
+	jobs, err := store.ListJobs()
+	require.NoError(t, err)
+	var ids []string
+	for _, job := range jobs {
+		ids = append(ids, job.ID)
+	}
+	assert.ElementsMatch(t, []string{"new-done", "old-queued"}, ids)
+}
+
+func TestBackoff(t *testing.T) {
+	cfg := Config{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	assert.Equal(t, time.Second, cfg.backoff(1))
+	assert.Equal(t, 2*time.Second, cfg.backoff(2))
+	assert.Equal(t, 4*time.Second, cfg.backoff(3))
+	assert.Equal(t, 5*time.Second, cfg.backoff(4))
+	assert.Equal(t, DefaultBackoff, Config{}.backoff(1))
+}
@@ -3,17 +3,21 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/signal"
 	"runtime"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/jaredwarren/rpi_music/config"
 	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/events"
 	"github.com/jaredwarren/rpi_music/localtunnel"
 	"github.com/jaredwarren/rpi_music/log"
 	"github.com/jaredwarren/rpi_music/model"
@@ -64,67 +68,73 @@ func main() {
 		}()
 	}
 
+	// Database
+	sdb, err := db.NewSongDB(DBPath)
+	if err != nil {
+		logger.Error("db", "err", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if closeErr := sdb.Close(); closeErr != nil {
+			logger.Warn("db close", "err", closeErr)
+		}
+	}()
+
 	// Player
+	loop, err := model.ParseLoopMode(cfg.Player.Loop)
+	if err != nil {
+		logger.Warn("player.loop", "err", err)
+		loop = model.LoopNone
+	}
+	bus := events.NewBus(events.DefaultHistory)
 	p, err := player.New(player.Config{
 		SongRoot:      cfg.Player.SongRoot,
 		ThumbRoot:     cfg.Player.ThumbRoot,
 		Volume:        cfg.Player.Volume,
-		Loop:          cfg.Player.Loop,
+		Loop:          loop,
 		AllowOverride: cfg.AllowOverride,
 		Restart:       cfg.Restart,
 		Beep:          cfg.Beep,
-		FFPlayBin:     findFFPlay(),
+		Backend:       cfg.Player.Backend,
+		ICYMetadata:   cfg.Player.ICYMetadata,
+		FFPlayBin:     findBinary("ffplay"),
+		MPVBin:        findBinary("mpv"),
+		Events:        bus,
+		Positions:     sdb,
 	}, logger)
 	if err != nil {
 		if runtime.GOOS != "darwin" {
 			logger.Error("player", "err", err)
 			os.Exit(1)
 		}
-		logger.Warn("ffplay not found — playback disabled; install via: brew install ffmpeg", "err", err)
-		trueBin, _ := exec.LookPath("true")
-		p, _ = player.New(player.Config{FFPlayBin: trueBin, Beep: false}, logger)
+		logger.Warn("player backend not found — playback disabled; install via: brew install ffmpeg", "err", err)
+		p, _ = player.NewWithBackend(player.Config{Beep: false, Events: bus}, player.NewFakeBackend(), logger)
 	}
 	defer p.Stop()
-
-	// Database
-	sdb, err := db.NewSongDB(DBPath)
-	if err != nil {
-		logger.Error("db", "err", err)
-		os.Exit(1)
-	}
-	defer func() {
-		if closeErr := sdb.Close(); closeErr != nil {
-			logger.Warn("db close", "err", closeErr)
-		}
-	}()
+	sleep := player.NewSleepTimer(p, cfg.Player.SleepFade.Duration)
+	defer sleep.Cancel()
 
 	// Application lifecycle context
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	var wg sync.WaitGroup
 
+	var (
+		events    chan rfid.Event
+		tagWriter server.TagWriter
+	)
 	if cfg.RFIDEnabled {
-		events := make(chan rfid.Event, 4)
-		r, err := rfid.New(rfid.Config{
-			SPIPort:        cfg.RFID.SPIPort,
-			ResetPin:       cfg.RFID.ResetPin,
-			IRQPin:         cfg.RFID.IRQPin,
-			Cooldown:       cfg.RFID.CooldownOrDefault(),
-			PollInterval:   cfg.RFID.PollIntervalOrDefault(),
-			ReadUIDTimeout: cfg.RFID.ReadUIDTimeoutOrDefault(),
-		}, events, logger)
+		events = make(chan rfid.Event, 4)
+		r, err := newTagSource(cfg.RFID, events, logger)
 		if err != nil {
 			logger.Error("rfid", "err", err)
 			os.Exit(1)
 		}
 		defer r.Close()
 		r.Start(ctx)
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			runRFIDLoop(ctx, events, sdb, p, logger)
-		}()
+		if w, ok := r.(server.TagWriter); ok {
+			tagWriter = w
+		}
 	}
 
 	// HTTP server
@@ -136,11 +146,32 @@ func main() {
 		Db:           sdb,
 		Logger:       logger,
 		Player:       p,
+		Sleep:        sleep,
+		TagWriter:    tagWriter,
+		Events:       bus,
 	})
 	if err != nil {
 		logger.Error("http server init", "err", err)
 		os.Exit(1)
 	}
+
+	if events != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			loop := &rfidLoop{
+				db:       sdb,
+				player:   p,
+				sleep:    sleep,
+				scanned:  htmlServer.TagScanned,
+				capture:  htmlServer.CaptureTag,
+				fetch:    htmlServer.SongForURL,
+				onRemove: cfg.RFID.OnRemove,
+				logger:   logger,
+			}
+			loop.run(ctx, events)
+		}()
+	}
 	// Startup sound
 	if cfg.Startup.Play && cfg.Startup.File != "" {
 		go func() {
@@ -164,18 +195,101 @@ func main() {
 	wg.Wait()
 }
 
-// findFFPlay returns the path to ffplay, checking Homebrew locations on macOS.
-func findFFPlay() string {
-	for _, c := range []string{"ffplay", "/opt/homebrew/bin/ffplay", "/usr/local/bin/ffplay"} {
+// findBinary returns the path to name, checking Homebrew locations on macOS.
+func findBinary(name string) string {
+	for _, c := range []string{name, "/opt/homebrew/bin/" + name, "/usr/local/bin/" + name} {
 		if path, err := exec.LookPath(c); err == nil {
 			return path
 		}
 	}
-	return "ffplay"
+	return name
+}
+
+// newTagSource opens the tag source named by cfg.Source.
+func newTagSource(cfg config.RFIDConfig, events chan<- rfid.Event, logger *slog.Logger) (rfid.TagSource, error) {
+	switch cfg.Source {
+	case "", "reader", "mfrc522":
+		r, err := rfid.New(rfid.Config{
+			Driver:         cfg.Driver,
+			I2CBus:         cfg.I2CBus,
+			UARTPort:       cfg.UARTPort,
+			SPIPort:        cfg.SPIPort,
+			ResetPin:       cfg.ResetPin,
+			IRQPin:         cfg.IRQPin,
+			Cooldown:       cfg.CooldownOrDefault(),
+			PollInterval:   cfg.PollIntervalOrDefault(),
+			ReadUIDTimeout: cfg.ReadUIDTimeoutOrDefault(),
+			DetectRemoval:  cfg.DetectRemoval(),
+			RemovalGrace:   cfg.RemovalGrace.Duration,
+		}, events, logger)
+		if err != nil {
+			return nil, err
+		}
+		return r, nil
+	case "evdev":
+		s, err := rfid.NewEvdevSource(cfg.Device, events, logger)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case "stdin":
+		return rfid.NewLineSource(os.Stdin, events, logger), nil
+	case "pipe":
+		s, err := rfid.NewPipeSource(cfg.Device, events, logger)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case "replay":
+		f, err := os.Open(cfg.Device)
+		if err != nil {
+			return nil, fmt.Errorf("rfid: open script: %w", err)
+		}
+		defer f.Close()
+		steps, err := rfid.ParseScript(f)
+		if err != nil {
+			return nil, err
+		}
+		return rfid.NewReplaySource(steps, events), nil
+	default:
+		return nil, fmt.Errorf("rfid: unknown source %q", cfg.Source)
+	}
+}
+
+// rfidLoop turns tag events into playback, or runs the card's command if it
+// is a command card. Tapping the card that is already queued resumes it if it
+// was paused.
+type rfidLoop struct {
+	db     db.DBer
+	player *player.Player
+	sleep  *player.SleepTimer
+	// scanned is told about every event, before anything acts on it.
+	scanned func(rfid.Event)
+	// capture is offered every event first; it returns true when it consumed
+	// the event, as learn mode does with the next scanned card.
+	capture func(rfid.Event) bool
+	// fetch returns the song for a URL read from an unmapped tag, downloading
+	// it and assigning it to the tag's UID if needed. Nil disables downloads.
+	fetch    func(ctx context.Context, url, uid string) (*model.Song, error)
+	onRemove string // config.RFIDConfig.OnRemove
+	logger   *slog.Logger
+
+	current  string          // UID of the card whose songs were queued last
+	fetching map[string]bool // UIDs whose URL is being downloaded
+	fetched  chan fetchResult
+}
+
+// fetchResult is the outcome of a download started by a tag.
+type fetchResult struct {
+	uid  string
+	song *model.Song
+	err  error
 }
 
-// runRFIDLoop consumes tag events and triggers playback.
-func runRFIDLoop(ctx context.Context, events <-chan rfid.Event, sdb db.DBer, p *player.Player, logger *slog.Logger) {
+// run consumes events until ctx is cancelled or events is closed.
+func (l *rfidLoop) run(ctx context.Context, events <-chan rfid.Event) {
+	l.fetching = make(map[string]bool)
+	l.fetched = make(chan fetchResult)
 	for {
 		select {
 		case <-ctx.Done():
@@ -184,31 +298,206 @@ func runRFIDLoop(ctx context.Context, events <-chan rfid.Event, sdb db.DBer, p *
 			if !ok {
 				return
 			}
-			rs, err := sdb.GetRFIDSong(ev.UID)
-			if err != nil {
-				if !errors.Is(err, db.ErrNotFound) {
-					logger.Error("rfid: GetRFIDSong", "err", err)
-				}
-				continue
+			if l.scanned != nil {
+				l.scanned(ev)
 			}
-			if len(rs.Songs) == 0 {
+			if l.capture != nil && l.capture(ev) {
+				l.player.Beep()
 				continue
 			}
-			song, err := sdb.GetSong(rs.Songs[0])
-			if err != nil {
-				logger.Error("rfid: GetSong", "err", err)
-				continue
+			if ev.Kind == rfid.Removed {
+				l.tagRemoved(ev.UID)
+			} else {
+				l.tagPresent(ctx, ev)
 			}
-			p.Beep()
-			if err := p.Play(song); err != nil {
-				logger.Error("rfid: Play", "err", err)
+		case res := <-l.fetched:
+			delete(l.fetching, res.uid)
+			if res.err != nil {
+				l.logger.Error("rfid: fetch", "uid", res.uid, "err", res.err)
 				continue
 			}
+			l.play(res.uid, []*model.Song{res.song}, model.PlaybackOptions{})
+		}
+	}
+}
 
-			song.Plays++
-			if err := sdb.UpdateSong(song); err != nil {
-				logger.Error("rfid: UpdateSong", "err", err)
-			}
+func (l *rfidLoop) tagPresent(ctx context.Context, ev rfid.Event) {
+	uid := ev.UID
+	card, err := l.db.GetCommandCard(uid)
+	switch {
+	case err == nil:
+		l.player.Beep()
+		if err := runCommand(card, l.db, l.player, l.sleep); err != nil {
+			l.logger.Error("rfid: command", "command", card.Command, "err", err)
+		}
+		return
+	case !errors.Is(err, db.ErrNotFound):
+		l.logger.Error("rfid: GetCommandCard", "err", err)
+	}
+	rs, err := l.db.GetRFIDSong(uid)
+	switch {
+	case errors.Is(err, db.ErrNotFound) || err == nil && rs == nil:
+		l.playTagContent(ctx, ev)
+		return
+	case err != nil:
+		l.logger.Error("rfid: GetRFIDSong", "err", err)
+		return
+	}
+	l.play(uid, loadCardSongs(l.db, rs, l.logger), rs.Playback)
+}
+
+// playTagContent plays what an unmapped tag's NDEF message points at: a song
+// ID written from the assign page, or a YouTube URL, downloaded on first use.
+func (l *rfidLoop) playTagContent(ctx context.Context, ev rfid.Event) {
+	if ev.Text != "" {
+		if song, err := l.db.GetSong(ev.Text); err == nil && song != nil {
+			l.play(ev.UID, []*model.Song{song}, model.PlaybackOptions{})
+			return
+		}
+	}
+	if l.fetch == nil || !isYouTubeURL(ev.URL) || l.fetching[ev.UID] {
+		return
+	}
+	l.fetching[ev.UID] = true
+	l.player.Beep()
+	go func() {
+		song, err := l.fetch(ctx, ev.URL, ev.UID)
+		select {
+		case l.fetched <- fetchResult{uid: ev.UID, song: song, err: err}:
+		case <-ctx.Done():
+		}
+	}()
+}
+
+// play queues a card's songs. A tap that starts them counts as one play of
+// the first song; one that only resumes them does not. A tap refused because
+// another card is playing leaves that card in charge.
+func (l *rfidLoop) play(uid string, songs []*model.Song, opts model.PlaybackOptions) {
+	if len(songs) == 0 {
+		return
+	}
+	started, err := l.player.PlayCard(songs, opts)
+	if errors.Is(err, player.ErrBusy) {
+		return
+	}
+	if err != nil {
+		l.logger.Error("rfid: PlayCard", "err", err)
+		return
+	}
+	l.current = uid
+	if !started {
+		return
+	}
+
+	song := songs[0]
+	song.Plays++
+	if err := l.db.UpdateSong(song); err != nil {
+		l.logger.Error("rfid: UpdateSong", "err", err)
+	}
+}
+
+// tagRemoved pauses or stops playback when the card that started it is lifted.
+func (l *rfidLoop) tagRemoved(uid string) {
+	if uid != l.current {
+		return
+	}
+	switch l.onRemove {
+	case "pause":
+		if err := l.player.Pause(); err != nil && !errors.Is(err, player.ErrNotPlaying) {
+			l.logger.Error("rfid: Pause", "err", err)
+		}
+	case "stop":
+		l.player.Stop()
+		l.current = ""
+	}
+}
+
+// isYouTubeURL reports whether raw is an http(s) link to YouTube.
+func isYouTubeURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	switch strings.TrimPrefix(u.Hostname(), "www.") {
+	case "youtube.com", "m.youtube.com", "music.youtube.com", "youtu.be":
+		return true
+	}
+	return false
+}
+
+// runCommand performs a command card's action.
+func runCommand(card *model.CommandCard, sdb db.SongStore, p *player.Player, sleep *player.SleepTimer) error {
+	var err error
+	switch card.Command {
+	case model.CommandStop:
+		p.Stop()
+	case model.CommandPause:
+		if p.Paused() {
+			err = p.Resume()
+		} else {
+			err = p.Pause()
+		}
+	case model.CommandNext:
+		err = p.Next()
+	case model.CommandPrevious:
+		err = p.Previous()
+	case model.CommandNextChapter:
+		err = p.NextChapter()
+	case model.CommandPreviousChapter:
+		err = p.PreviousChapter()
+	case model.CommandVolumeUp:
+		err = p.SetVolume(p.Volume() + player.VolumeStep)
+	case model.CommandVolumeDown:
+		err = p.SetVolume(p.Volume() - player.VolumeStep)
+	case model.CommandShuffleAll:
+		err = shuffleAll(sdb, p)
+	case model.CommandSleep:
+		if card.SleepMinutes > 0 {
+			sleep.Start(time.Duration(card.SleepMinutes) * time.Minute)
+		} else {
+			sleep.StartAfterSong()
+		}
+	default:
+		return fmt.Errorf("unknown command %q", card.Command)
+	}
+	// Controls tapped with nothing playing, or a shuffle refused while
+	// something else plays, are not errors.
+	if errors.Is(err, player.ErrNoQueue) || errors.Is(err, player.ErrNotPlaying) || errors.Is(err, player.ErrNoChapters) ||
+		errors.Is(err, player.ErrBusy) {
+		return nil
+	}
+	return err
+}
+
+// shuffleAll queues every downloaded song in random order.
+func shuffleAll(sdb db.SongStore, p *player.Player) error {
+	all, err := sdb.ListSongs()
+	if err != nil {
+		return fmt.Errorf("ListSongs|%w", err)
+	}
+	songs := make([]*model.Song, 0, len(all))
+	for _, song := range all {
+		if song.FilePath != "" {
+			songs = append(songs, song)
+		}
+	}
+	if len(songs) == 0 {
+		return nil
+	}
+	_, err = p.PlayQueue(songs, model.PlaybackOptions{Shuffle: true})
+	return err
+}
+
+// loadCardSongs fetches every song on a card in order, skipping any that no longer exist.
+func loadCardSongs(sdb db.SongStore, rs *model.RFIDSong, logger *slog.Logger) []*model.Song {
+	songs := make([]*model.Song, 0, len(rs.Songs))
+	for _, id := range rs.Songs {
+		song, err := sdb.GetSong(id)
+		if err != nil {
+			logger.Error("rfid: GetSong", "song", id, "err", err)
+			continue
 		}
+		songs = append(songs, song)
 	}
+	return songs
 }
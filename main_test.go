@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
-	"os/exec"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/jaredwarren/rpi_music/config"
 	"github.com/jaredwarren/rpi_music/db"
 	"github.com/jaredwarren/rpi_music/log"
 	"github.com/jaredwarren/rpi_music/model"
@@ -14,14 +16,8 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func TestRunRFIDLoopIncrementsPlaysAndUpdatesSong(t *testing.T) {
-	trueBin, err := exec.LookPath("true")
-	require.NoError(t, err)
-
-	p, err := player.New(player.Config{
-		FFPlayBin: trueBin,
-		Beep:      false,
-	}, log.NewNoOpLogger())
+func TestRFIDLoopIncrementsPlaysAndUpdatesSong(t *testing.T) {
+	p, err := player.NewWithBackend(player.Config{Beep: false}, player.NewFakeBackend(), log.NewNoOpLogger())
 	require.NoError(t, err)
 
 	song := &model.Song{
@@ -45,7 +41,8 @@ func TestRunRFIDLoopIncrementsPlaysAndUpdatesSong(t *testing.T) {
 	defer cancel()
 
 	events := make(chan rfid.Event, 1)
-	go runRFIDLoop(ctx, events, mockDB, p, log.NewNoOpLogger())
+	loop := &rfidLoop{db: mockDB, player: p, sleep: player.NewSleepTimer(p, 0), logger: log.NewNoOpLogger()}
+	go loop.run(ctx, events)
 	events <- rfid.Event{UID: "UID123"}
 
 	require.Eventually(t, func() bool {
@@ -58,3 +55,240 @@ func TestRunRFIDLoopIncrementsPlaysAndUpdatesSong(t *testing.T) {
 	require.NotNil(t, last)
 	require.Equal(t, 3, last.Plays)
 }
+
+func TestRFIDLoopResumeIsNotAPlay(t *testing.T) {
+	p, err := player.NewWithBackend(player.Config{}, player.NewFakeBackend(), log.NewNoOpLogger())
+	require.NoError(t, err)
+	t.Cleanup(p.Stop)
+	mockDB := &db.MockDB{}
+	loop := &rfidLoop{db: mockDB, player: p, logger: log.NewNoOpLogger()}
+	songs := []*model.Song{{ID: "song-1", FilePath: "song_files/test.mp3"}}
+
+	loop.play("UID123", songs, model.PlaybackOptions{})
+	require.Equal(t, 1, mockDB.UpdateSongCallCount())
+	require.NoError(t, p.Pause())
+	loop.play("UID123", songs, model.PlaybackOptions{})
+	require.False(t, p.Paused())
+	require.Equal(t, 1, mockDB.UpdateSongCallCount())
+	require.Equal(t, 1, songs[0].Plays)
+}
+
+func TestRFIDLoopRefusedCardDoesNotControlPlayback(t *testing.T) {
+	p, err := player.NewWithBackend(player.Config{}, player.NewFakeBackend(), log.NewNoOpLogger())
+	require.NoError(t, err)
+	t.Cleanup(p.Stop)
+	mockDB := &db.MockDB{}
+	loop := &rfidLoop{db: mockDB, player: p, onRemove: "pause", logger: log.NewNoOpLogger()}
+
+	loop.play("UID1", []*model.Song{{ID: "song-1", FilePath: "song_files/1.mp3"}}, model.PlaybackOptions{})
+	loop.play("UID2", []*model.Song{{ID: "song-2", FilePath: "song_files/2.mp3"}}, model.PlaybackOptions{})
+	require.Equal(t, "song-1", p.GetPlaying().ID)
+	require.Equal(t, 1, mockDB.UpdateSongCallCount())
+
+	// Lifting the refused card leaves the first one playing.
+	loop.tagRemoved("UID2")
+	require.False(t, p.Paused())
+	loop.tagRemoved("UID1")
+	require.True(t, p.Paused())
+}
+
+func TestRFIDLoopRunsCommandCards(t *testing.T) {
+	b := player.NewFakeBackend()
+	p, err := player.NewWithBackend(player.Config{Volume: 50}, b, log.NewNoOpLogger())
+	require.NoError(t, err)
+
+	mockDB := &db.MockDB{
+		GetCommandCardResult: &model.CommandCard{RFID: "UID1", Command: model.CommandVolumeUp},
+		GetRFIDSongResult:    &model.RFIDSong{RFID: "UID1", Songs: []string{"song-1"}},
+		GetSongResult:        &model.Song{ID: "song-1", FilePath: "song_files/test.mp3"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan rfid.Event, 1)
+	loop := &rfidLoop{db: mockDB, player: p, sleep: player.NewSleepTimer(p, 0), logger: log.NewNoOpLogger()}
+	go loop.run(ctx, events)
+	events <- rfid.Event{UID: "UID1"}
+
+	require.Eventually(t, func() bool { return p.Volume() == 50+player.VolumeStep }, time.Second, time.Millisecond)
+	require.Empty(t, b.Started(), "a command card does not play its songs")
+}
+
+func TestRunCommand(t *testing.T) {
+	p, err := player.NewWithBackend(player.Config{AllowOverride: true}, player.NewFakeBackend(), log.NewNoOpLogger())
+	require.NoError(t, err)
+	sleep := player.NewSleepTimer(p, 0)
+	t.Cleanup(sleep.Cancel)
+	mockDB := &db.MockDB{ListSongsResult: []*model.Song{
+		{ID: "a", FilePath: "a.mp3"}, {ID: "b", FilePath: "b.mp3"}, {ID: "c"},
+	}}
+	run := func(cmd model.Command) {
+		t.Helper()
+		require.NoError(t, runCommand(&model.CommandCard{Command: cmd}, mockDB, p, sleep))
+	}
+
+	// Controls with nothing playing are ignored.
+	run(model.CommandNext)
+	run(model.CommandPause)
+
+	run(model.CommandShuffleAll)
+	require.Len(t, p.Queue(), 2)
+
+	run(model.CommandPause)
+	require.True(t, p.Paused())
+	run(model.CommandPause)
+	require.False(t, p.Paused())
+
+	run(model.CommandSleep)
+	require.True(t, sleep.Status().AfterSong)
+
+	run(model.CommandStop)
+	require.False(t, p.Playing())
+}
+
+func TestRFIDLoopPausesWhenCardLifted(t *testing.T) {
+	p, err := player.NewWithBackend(player.Config{}, player.NewFakeBackend(), log.NewNoOpLogger())
+	require.NoError(t, err)
+	t.Cleanup(p.Stop)
+	mockDB := &db.MockDB{
+		GetRFIDSongResult: &model.RFIDSong{RFID: "UID1", Songs: []string{"song-1"}},
+		GetSongResult:     &model.Song{ID: "song-1", FilePath: "song_files/test.mp3"},
+	}
+	loop := &rfidLoop{db: mockDB, player: p, onRemove: "pause", logger: log.NewNoOpLogger()}
+
+	loop.tagPresent(context.Background(), rfid.Event{UID: "UID1"})
+	require.True(t, p.Playing())
+
+	loop.tagRemoved("OTHER")
+	require.False(t, p.Paused())
+
+	loop.tagRemoved("UID1")
+	require.True(t, p.Paused())
+
+	// Putting the card back resumes.
+	loop.tagPresent(context.Background(), rfid.Event{UID: "UID1"})
+	require.False(t, p.Paused())
+
+	loop.onRemove = "stop"
+	loop.tagRemoved("UID1")
+	require.False(t, p.Playing())
+}
+
+func TestReplayedTapsDrivePlayback(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "taps.txt")
+	require.NoError(t, os.WriteFile(script, []byte("0s UID1\n20ms removed UID1\n"), 0o600))
+
+	events := make(chan rfid.Event, 4)
+	src, err := newTagSource(config.RFIDConfig{Source: "replay", Device: script}, events, log.NewNoOpLogger())
+	require.NoError(t, err)
+
+	b := player.NewFakeBackend()
+	p, err := player.NewWithBackend(player.Config{}, b, log.NewNoOpLogger())
+	require.NoError(t, err)
+	t.Cleanup(p.Stop)
+	mockDB := &db.MockDB{
+		GetRFIDSongResult: &model.RFIDSong{RFID: "uid1", Songs: []string{"song-1"}},
+		GetSongResult:     &model.Song{ID: "song-1", FilePath: "song_files/test.mp3"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	src.Start(ctx)
+	loop := &rfidLoop{db: mockDB, player: p, onRemove: "pause", logger: log.NewNoOpLogger()}
+	go loop.run(ctx, events)
+
+	require.Eventually(t, p.Paused, time.Second, time.Millisecond)
+	require.Equal(t, []string{"song_files/test.mp3"}, b.Started())
+}
+
+func TestNewTagSourceUnknown(t *testing.T) {
+	_, err := newTagSource(config.RFIDConfig{Source: "magic"}, nil, log.NewNoOpLogger())
+	require.Error(t, err)
+}
+
+func TestRFIDLoopPlaysSongIDFromTag(t *testing.T) {
+	b := player.NewFakeBackend()
+	p, err := player.NewWithBackend(player.Config{}, b, log.NewNoOpLogger())
+	require.NoError(t, err)
+	t.Cleanup(p.Stop)
+	mockDB := &db.MockDB{
+		GetRFIDSongErr: db.ErrNotFound,
+		GetSongResult:  &model.Song{ID: "song-1", FilePath: "song_files/test.mp3"},
+	}
+	loop := &rfidLoop{db: mockDB, player: p, logger: log.NewNoOpLogger()}
+
+	loop.tagPresent(context.Background(), rfid.Event{UID: "UID1", Text: "song-1"})
+	require.Equal(t, []string{"song_files/test.mp3"}, b.Started())
+}
+
+func TestRFIDLoopDownloadsURLFromTag(t *testing.T) {
+	b := player.NewFakeBackend()
+	p, err := player.NewWithBackend(player.Config{}, b, log.NewNoOpLogger())
+	require.NoError(t, err)
+	t.Cleanup(p.Stop)
+
+	release := make(chan struct{})
+	var fetches []string
+	loop := &rfidLoop{
+		db:     &db.MockDB{GetRFIDSongErr: db.ErrNotFound},
+		player: p,
+		fetch: func(_ context.Context, url, uid string) (*model.Song, error) {
+			fetches = append(fetches, uid+" "+url)
+			<-release
+			return &model.Song{ID: "new", FilePath: "song_files/new.mp3"}, nil
+		},
+		logger: log.NewNoOpLogger(),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan rfid.Event)
+	go loop.run(ctx, events)
+
+	tap := rfid.Event{UID: "UID1", URL: "https://youtu.be/abc"}
+	events <- tap
+	events <- tap // a second tap while downloading does not start another
+	events <- rfid.Event{UID: "UID2", URL: "https://example.com/abc"}
+	close(release)
+
+	require.Eventually(t, func() bool { return len(b.Started()) == 1 }, time.Second, time.Millisecond)
+	require.Equal(t, []string{"UID1 https://youtu.be/abc"}, fetches)
+	require.Equal(t, "song_files/new.mp3", b.Started()[0])
+}
+
+func TestIsYouTubeURL(t *testing.T) {
+	require.True(t, isYouTubeURL("https://www.youtube.com/watch?v=abc"))
+	require.True(t, isYouTubeURL("https://music.youtube.com/watch?v=abc"))
+	require.True(t, isYouTubeURL("http://youtu.be/abc"))
+	require.False(t, isYouTubeURL("https://example.com/youtube.com"))
+	require.False(t, isYouTubeURL("youtube.com/watch?v=abc"))
+	require.False(t, isYouTubeURL(""))
+}
+
+func TestRFIDLoopScannedAndCapturedTagDoesNotPlay(t *testing.T) {
+	b := player.NewFakeBackend()
+	p, err := player.NewWithBackend(player.Config{}, b, log.NewNoOpLogger())
+	require.NoError(t, err)
+	mockDB := &db.MockDB{
+		GetRFIDSongResult: &model.RFIDSong{RFID: "UID1", Songs: []string{"song-1"}},
+		GetSongResult:     &model.Song{ID: "song-1", FilePath: "song_files/test.mp3"},
+	}
+	scanned := make(chan string, 1)
+	captured := make(chan string, 1)
+	loop := &rfidLoop{
+		db:      mockDB,
+		player:  p,
+		scanned: func(ev rfid.Event) { scanned <- ev.UID },
+		capture: func(ev rfid.Event) bool { captured <- ev.UID; return true },
+		logger:  log.NewNoOpLogger(),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan rfid.Event, 1)
+	go loop.run(ctx, events)
+	events <- rfid.Event{UID: "UID1"}
+
+	require.Equal(t, "UID1", <-scanned)
+	require.Equal(t, "UID1", <-captured)
+	require.Empty(t, b.Started())
+}
@@ -0,0 +1,26 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// ExtractClip copies the audio of src between start and end to dst without
+// re-encoding. An end of 0 runs to the end of src.
+func (a *Analyzer) ExtractClip(ctx context.Context, src, dst string, start, end time.Duration) error {
+	args := []string{"-hide_banner", "-v", "error", "-y", "-ss", seconds(start), "-i", src}
+	if end > start {
+		args = append(args, "-t", seconds(end-start))
+	}
+	args = append(args, "-vn", "-map", "0:a", "-c", "copy", dst)
+	if out, err := exec.CommandContext(ctx, a.binary(), args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg clip %s: %w: %s", src, err, out)
+	}
+	return nil
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
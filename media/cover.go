@@ -0,0 +1,18 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// ExtractCover writes the cover art embedded in src to dst as a JPEG.
+func (a *Analyzer) ExtractCover(ctx context.Context, src, dst string) error {
+	cmd := exec.CommandContext(ctx, a.binary(),
+		"-hide_banner", "-v", "error", "-y", "-i", src,
+		"-an", "-map", "0:v:0", "-frames:v", "1", "-f", "image2", dst)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg cover %s: %w: %s", src, err, out)
+	}
+	return nil
+}
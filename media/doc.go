@@ -0,0 +1,2 @@
+// Package media inspects local audio files with the ffmpeg tools (ffprobe, ffmpeg).
+package media
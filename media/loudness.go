@@ -0,0 +1,83 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+const (
+	ffmpegBin = "ffmpeg"
+
+	// TargetLoudness is the integrated loudness (LUFS) songs are normalised to.
+	TargetLoudness = -16.0
+	// TargetTruePeak is the highest true peak (dBTP) the applied gain may reach.
+	TargetTruePeak = -1.5
+)
+
+// Loudness is the EBU R128 measurement of a file and the gain that brings it to TargetLoudness.
+type Loudness struct {
+	Integrated float64 // LUFS
+	TruePeak   float64 // dBTP
+	Gain       float64 // dB
+}
+
+// Analyzer runs ffmpeg against local files to measure loudness and extract
+// cover art. The zero value uses "ffmpeg" from PATH.
+type Analyzer struct {
+	Bin string // ffmpeg executable; defaults to "ffmpeg"
+}
+
+func (a *Analyzer) binary() string {
+	if a != nil && a.Bin != "" {
+		return a.Bin
+	}
+	return ffmpegBin
+}
+
+// Loudness runs a loudnorm analysis pass over the file at path.
+func (a *Analyzer) Loudness(ctx context.Context, path string) (Loudness, error) {
+	filter := fmt.Sprintf("loudnorm=I=%.1f:TP=%.1f:print_format=json", TargetLoudness, TargetTruePeak)
+	cmd := exec.CommandContext(ctx, a.binary(),
+		"-hide_banner", "-nostats", "-i", path, "-af", filter, "-f", "null", "-")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return Loudness{}, fmt.Errorf("ffmpeg loudnorm %s: %w", path, err)
+	}
+	return parseLoudnorm(out)
+}
+
+// loudnormStats is the JSON block loudnorm prints; ffmpeg reports numbers as strings.
+type loudnormStats struct {
+	InputI  string `json:"input_i"`
+	InputTP string `json:"input_tp"`
+}
+
+func parseLoudnorm(out []byte) (Loudness, error) {
+	start := bytes.LastIndexByte(out, '{')
+	end := bytes.LastIndexByte(out, '}')
+	if start < 0 || end < start {
+		return Loudness{}, fmt.Errorf("loudnorm: no stats in ffmpeg output")
+	}
+	var stats loudnormStats
+	if err := json.Unmarshal(out[start:end+1], &stats); err != nil {
+		return Loudness{}, fmt.Errorf("loudnorm json: %w", err)
+	}
+	integrated, err := strconv.ParseFloat(stats.InputI, 64)
+	if err != nil {
+		return Loudness{}, fmt.Errorf("loudnorm input_i %q: %w", stats.InputI, err)
+	}
+	truePeak, err := strconv.ParseFloat(stats.InputTP, 64)
+	if err != nil {
+		return Loudness{}, fmt.Errorf("loudnorm input_tp %q: %w", stats.InputTP, err)
+	}
+	// Silence measures as -inf; leave it alone rather than boosting noise.
+	gain := 0.0
+	if integrated > -70 {
+		gain = min(TargetLoudness-integrated, TargetTruePeak-truePeak)
+	}
+	return Loudness{Integrated: integrated, TruePeak: truePeak, Gain: gain}, nil
+}
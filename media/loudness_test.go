@@ -0,0 +1,50 @@
+package media
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const loudnormOutput = `Input #0, matroska,webm, from 'song.webm':
+  Duration: 00:03:33.46, start: -0.007000, bitrate: 133 kb/s
+[Parsed_loudnorm_0 @ 0x5581c8b0] 
+{
+	"input_i" : "-23.54",
+	"input_tp" : "-9.20",
+	"input_lra" : "7.30",
+	"input_thresh" : "-34.01",
+	"output_i" : "-16.10",
+	"output_tp" : "-1.50",
+	"output_lra" : "6.10",
+	"output_thresh" : "-26.52",
+	"normalization_type" : "dynamic",
+	"target_offset" : "0.10"
+}
+`
+
+func TestParseLoudnorm(t *testing.T) {
+	l, err := parseLoudnorm([]byte(loudnormOutput))
+	require.NoError(t, err)
+	assert.InDelta(t, -23.54, l.Integrated, 0.001)
+	assert.InDelta(t, -9.20, l.TruePeak, 0.001)
+	assert.InDelta(t, 7.54, l.Gain, 0.001)
+}
+
+func TestParseLoudnormLimitsGainByTruePeak(t *testing.T) {
+	l, err := parseLoudnorm([]byte(`{"input_i": "-30.0", "input_tp": "-4.0"}`))
+	require.NoError(t, err)
+	assert.InDelta(t, 2.5, l.Gain, 0.001)
+}
+
+func TestParseLoudnormSilence(t *testing.T) {
+	l, err := parseLoudnorm([]byte(`{"input_i": "-inf", "input_tp": "-inf"}`))
+	require.NoError(t, err)
+	assert.Zero(t, l.Gain)
+}
+
+func TestParseLoudnormNoStats(t *testing.T) {
+	_, err := parseLoudnorm([]byte("error opening file"))
+	require.Error(t, err)
+}
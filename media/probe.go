@@ -0,0 +1,115 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ffprobeBin = "ffprobe"
+
+// Prober runs ffprobe against local files. The zero value uses "ffprobe" from PATH.
+type Prober struct {
+	Bin string // ffprobe executable; defaults to "ffprobe"
+}
+
+func (p *Prober) binary() string {
+	if p != nil && p.Bin != "" {
+		return p.Bin
+	}
+	return ffprobeBin
+}
+
+// probeFormat is the subset of `ffprobe -show_format -show_streams -of json` output we read.
+type probeFormat struct {
+	Format struct {
+		Duration string            `json:"duration"`
+		Tags     map[string]string `json:"tags"`
+	} `json:"format"`
+	Streams []struct {
+		CodecType   string `json:"codec_type"`
+		Disposition struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+	} `json:"streams"`
+}
+
+// Info is the duration and tags of a local file.
+type Info struct {
+	Duration time.Duration
+	Title    string
+	Artist   string
+	Album    string
+	HasAudio bool // the file has an audio stream
+	HasCover bool // the file embeds cover art; see Analyzer.ExtractCover
+}
+
+// Duration returns the playing time of the file at path.
+func (p *Prober) Duration(ctx context.Context, path string) (time.Duration, error) {
+	cmd := exec.CommandContext(ctx, p.binary(),
+		"-v", "error", "-show_format", "-of", "json", path)
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe %s: %w", path, err)
+	}
+	return parseDuration(out)
+}
+
+// Probe reads the duration, tags and cover art presence of the file at path.
+func (p *Prober) Probe(ctx context.Context, path string) (Info, error) {
+	cmd := exec.CommandContext(ctx, p.binary(),
+		"-v", "error", "-show_format", "-show_streams", "-of", "json", path)
+	out, err := cmd.Output()
+	if err != nil {
+		return Info{}, fmt.Errorf("ffprobe %s: %w", path, err)
+	}
+	return parseInfo(out)
+}
+
+func parseInfo(out []byte) (Info, error) {
+	var pf probeFormat
+	if err := json.Unmarshal(out, &pf); err != nil {
+		return Info{}, fmt.Errorf("ffprobe json: %w", err)
+	}
+	var info Info
+	// Tag names are upper case in some containers (e.g. FLAC, Ogg).
+	tags := make(map[string]string, len(pf.Format.Tags))
+	for k, v := range pf.Format.Tags {
+		tags[strings.ToLower(k)] = strings.TrimSpace(v)
+	}
+	info.Title, info.Artist, info.Album = tags["title"], tags["artist"], tags["album"]
+	if info.Artist == "" {
+		info.Artist = tags["album_artist"]
+	}
+	for _, st := range pf.Streams {
+		switch {
+		case st.CodecType == "audio":
+			info.HasAudio = true
+		case st.CodecType == "video" && st.Disposition.AttachedPic == 1:
+			info.HasCover = true
+		}
+	}
+	if d, err := parseDuration(out); err == nil {
+		info.Duration = d
+	}
+	return info, nil
+}
+
+func parseDuration(out []byte) (time.Duration, error) {
+	var pf probeFormat
+	if err := json.Unmarshal(out, &pf); err != nil {
+		return 0, fmt.Errorf("ffprobe json: %w", err)
+	}
+	if pf.Format.Duration == "" {
+		return 0, fmt.Errorf("ffprobe: no duration")
+	}
+	secs, err := strconv.ParseFloat(pf.Format.Duration, 64)
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe duration %q: %w", pf.Format.Duration, err)
+	}
+	return time.Duration(secs * float64(time.Second)), nil
+}
@@ -0,0 +1,47 @@
+package media
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDuration(t *testing.T) {
+	d, err := parseDuration([]byte(`{"format": {"filename": "a.webm", "duration": "213.461000"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, 213461*time.Millisecond, d)
+
+	_, err = parseDuration([]byte(`{"format": {}}`))
+	require.Error(t, err)
+
+	_, err = parseDuration([]byte(`not json`))
+	require.Error(t, err)
+}
+
+func TestParseInfo(t *testing.T) {
+	info, err := parseInfo([]byte(`{
+		"streams": [
+			{"codec_type": "audio", "disposition": {"attached_pic": 0}},
+			{"codec_type": "video", "disposition": {"attached_pic": 1}}
+		],
+		"format": {"duration": "61.5", "tags": {"TITLE": "Story", "album_artist": "Grandma", "album": "Bedtime "}}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, Info{
+		Duration: 61500 * time.Millisecond,
+		Title:    "Story",
+		Artist:   "Grandma",
+		Album:    "Bedtime",
+		HasAudio: true,
+		HasCover: true,
+	}, info)
+
+	info, err = parseInfo([]byte(`{"format": {}}`))
+	require.NoError(t, err)
+	assert.Equal(t, Info{}, info)
+
+	_, err = parseInfo([]byte(`not json`))
+	require.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package model
+
+import "fmt"
+
+// Command is an action a command card triggers instead of playing songs.
+type Command string
+
+const (
+	CommandStop            Command = "stop"
+	CommandPause           Command = "pause" // pauses, or resumes when already paused
+	CommandNext            Command = "next"
+	CommandPrevious        Command = "previous"
+	CommandNextChapter     Command = "next-chapter"
+	CommandPreviousChapter Command = "previous-chapter"
+	CommandVolumeUp        Command = "volume-up"
+	CommandVolumeDown      Command = "volume-down"
+	CommandShuffleAll      Command = "shuffle-all"
+	CommandSleep           Command = "sleep"
+)
+
+// Commands lists every command in the order the UI offers them.
+var Commands = []Command{
+	CommandStop, CommandPause, CommandNext, CommandPrevious, CommandNextChapter, CommandPreviousChapter,
+	CommandVolumeUp, CommandVolumeDown, CommandShuffleAll, CommandSleep,
+}
+
+// ParseCommand validates s as a Command.
+func ParseCommand(s string) (Command, error) {
+	for _, c := range Commands {
+		if string(c) == s {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown command %q", s)
+}
+
+// CommandCard maps an RFID card to a Command.
+type CommandCard struct {
+	RFID         string
+	Command      Command
+	SleepMinutes int // for CommandSleep; 0 stops after the current song
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// Feed is a podcast subscription. The newest downloaded episode is the only
+// song on RFID, if set.
+type Feed struct {
+	ID        string
+	URL       string
+	Title     string
+	RFID      string
+	Keep      int           // episodes kept in the library; 0 uses the configured default
+	Episodes  []FeedEpisode // newest first
+	CheckedAt time.Time     // last time the feed was fetched
+}
+
+// FeedEpisode is one episode of a Feed.
+type FeedEpisode struct {
+	GUID      string
+	Title     string
+	URL       string // audio enclosure
+	Published time.Time
+	SongID    string // set once the episode is in the library
+	JobID     string // download queued for the episode, if any
+	// Downloaded is set when the feed downloaded SongID itself, rather than
+	// finding the URL already in the library; only such songs are pruned.
+	Downloaded bool
+}
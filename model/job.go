@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// JobState is where a download job is in its life.
+type JobState string
+
+const (
+	JobQueued  JobState = "queued"  // waiting for a worker, possibly until NextAttempt
+	JobRunning JobState = "running" // being downloaded
+	JobFailed  JobState = "failed"  // gave up or cancelled; can be retried
+	JobDone    JobState = "done"    // downloaded into SongID
+)
+
+// DownloadJob is a queued request to download a song.
+type DownloadJob struct {
+	ID          string
+	URL         string
+	RFID        string // assigned to the song once it is downloaded, if still free
+	Force       bool   // download even if the file already exists
+	State       JobState
+	Attempts    int       // failed attempts so far
+	Error       string    // last failure
+	SongID      string    // set when done
+	NextAttempt time.Time // a queued job is not started before this
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Active reports whether the job is queued or running.
+func (j *DownloadJob) Active() bool {
+	return j.State == JobQueued || j.State == JobRunning
+}
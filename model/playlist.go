@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// Playlist is an imported YouTube playlist. Its songs go onto RFID, if set,
+// in playlist order.
+type Playlist struct {
+	ID       string
+	URL      string
+	Title    string
+	RFID     string
+	Entries  []PlaylistEntry
+	SyncedAt time.Time // last time the entries were read from YouTube
+}
+
+// PlaylistEntry is one video in a Playlist.
+type PlaylistEntry struct {
+	URL    string
+	Title  string
+	SongID string // set once the video is in the library
+}
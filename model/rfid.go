@@ -1,6 +1,55 @@
 package model
 
+import (
+	"encoding/json"
+	"fmt"
+)
+
 type RFIDSong struct {
-	RFID  string
-	Songs []string
+	RFID     string
+	Songs    []string
+	Playback PlaybackOptions
+}
+
+// LoopMode says what happens when a song ends.
+type LoopMode string
+
+const (
+	LoopDefault LoopMode = ""             // use the player's configured mode
+	LoopNone    LoopMode = "none"         // stop after the last song
+	LoopOne     LoopMode = "repeat-one"   // play the current song again
+	LoopQueue   LoopMode = "repeat-queue" // start over from the first song when the queue ends
+)
+
+// ParseLoopMode validates s as a LoopMode.
+func ParseLoopMode(s string) (LoopMode, error) {
+	switch m := LoopMode(s); m {
+	case LoopDefault, LoopNone, LoopOne, LoopQueue:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown loop mode %q", s)
+}
+
+// PlaybackOptions controls how a card's song list is played.
+type PlaybackOptions struct {
+	Shuffle bool
+	Loop    LoopMode
+}
+
+// UnmarshalJSON also reads cards saved before loop modes existed, whose
+// "Repeat" flag meant LoopQueue.
+func (o *PlaybackOptions) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Shuffle bool
+		Loop    LoopMode
+		Repeat  bool
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	o.Shuffle, o.Loop = v.Shuffle, v.Loop
+	if o.Loop == LoopDefault && v.Repeat {
+		o.Loop = LoopQueue
+	}
+	return nil
 }
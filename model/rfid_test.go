@@ -0,0 +1,27 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaybackOptionsReadsLegacyRepeat(t *testing.T) {
+	var opts PlaybackOptions
+	require.NoError(t, json.Unmarshal([]byte(`{"Shuffle":true,"Repeat":true}`), &opts))
+	assert.Equal(t, PlaybackOptions{Shuffle: true, Loop: LoopQueue}, opts)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"Loop":"repeat-one","Repeat":true}`), &opts))
+	assert.Equal(t, PlaybackOptions{Loop: LoopOne}, opts)
+}
+
+func TestParseLoopMode(t *testing.T) {
+	m, err := ParseLoopMode("repeat-queue")
+	require.NoError(t, err)
+	assert.Equal(t, LoopQueue, m)
+
+	_, err = ParseLoopMode("forever")
+	require.Error(t, err)
+}
@@ -6,16 +6,68 @@ const (
 	NewSongID = "new"
 )
 
+// SongKind says how a song is played.
+type SongKind string
+
+const (
+	SongKindFile   SongKind = ""       // a downloaded or uploaded file at FilePath
+	SongKindStream SongKind = "stream" // a live internet radio stream at URL
+)
+
 type Song struct {
-	ID        string
-	Thumbnail string // path to thumb
-	Title     string // video title
-	RFID      string
-	URL       string
-	FilePath  string
-	Plays     int
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID           string
+	Kind         SongKind
+	Thumbnail    string // path to thumb
+	Title        string // video title
+	Artist       string
+	Album        string
+	Extractor    string // site the song was downloaded from, e.g. "Youtube"; empty for uploads
+	RFID         string
+	URL          string // download source or stream URL; empty for uploaded files
+	FilePath     string
+	Duration     time.Duration // probed with ffprobe when the file is downloaded
+	VolumeOffset int           // added to the player volume to even out quiet or loud recordings
+	Loudness     float64       // integrated loudness in LUFS, measured when the file is downloaded
+	Gain         float64       // dB applied at playback to bring the song to the target loudness
+	Resume       bool          // continue from where the song last stopped instead of starting over
+	Chapters     []Chapter     // from the download source; empty if it has none
+	Plays        int
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// IsStream reports whether the song is a live stream rather than a file.
+func (s *Song) IsStream() bool { return s.Kind == SongKindStream }
+
+// Location is what the player opens: the stream URL or the local file.
+func (s *Song) Location() string {
+	if s.IsStream() {
+		return s.URL
+	}
+	return s.FilePath
+}
+
+// Chapter is a titled section of a song, such as a part of an audiobook.
+type Chapter struct {
+	Title string
+	Start time.Duration
+	End   time.Duration
+}
+
+// ChapterAt returns the index of the chapter playing at pos, or -1 if the song
+// has no chapters.
+func (s *Song) ChapterAt(pos time.Duration) int {
+	i := -1
+	for j, c := range s.Chapters {
+		if pos < c.Start {
+			break
+		}
+		i = j
+	}
+	if i < 0 && len(s.Chapters) > 0 {
+		return 0
+	}
+	return i
 }
 
 func NewSong() *Song {
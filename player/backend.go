@@ -0,0 +1,42 @@
+package player
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUnsupported is returned by a Stream for controls its backend cannot do live.
+var ErrUnsupported = errors.New("player: not supported by backend")
+
+// Backend starts audio playback. Each call to Start plays one file or
+// network stream.
+type Backend interface {
+	Start(path string, opts StartOptions) (Stream, error)
+}
+
+// StartOptions are applied when a file starts playing.
+type StartOptions struct {
+	Volume int           // 0-100; 0 is silent
+	Offset time.Duration // position to start from
+	Gain   float64       // loudness normalisation in dB; 0 leaves the audio untouched
+	Live   bool          // path is a live network stream, which cannot seek and should reconnect
+}
+
+// Stream is a single file being played by a Backend.
+type Stream interface {
+	// Wait blocks until playback ends. It returns nil when the file played to the end.
+	Wait() error
+	Stop() error
+	Pause() error
+	Resume() error
+	SetVolume(volume int) error
+	// Position returns how far into the file playback is.
+	Position() (time.Duration, error)
+	Seek(pos time.Duration) error
+}
+
+// titledStream is implemented by Streams that report the title a live stream
+// announces in its ICY metadata. The title is empty until it sends one.
+type titledStream interface {
+	streamTitle() (string, error)
+}
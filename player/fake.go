@@ -0,0 +1,146 @@
+package player
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var errFakeStopped = errors.New("player: fake stream stopped")
+
+// FakeBackend is an in-memory Backend for tests and machines without an audio player.
+// Streams play until stopped, or until Finish is called on them.
+type FakeBackend struct {
+	mu      sync.Mutex
+	streams []*FakeStream
+}
+
+// NewFakeBackend returns an empty FakeBackend.
+func NewFakeBackend() *FakeBackend {
+	return &FakeBackend{}
+}
+
+func (b *FakeBackend) Start(path string, opts StartOptions) (Stream, error) {
+	s := &FakeStream{Path: path, Opts: opts, volume: opts.Volume, done: make(chan error, 1)}
+	b.mu.Lock()
+	b.streams = append(b.streams, s)
+	b.mu.Unlock()
+	return s, nil
+}
+
+// Started returns the paths of every stream started so far, in order.
+func (b *FakeBackend) Started() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]string, len(b.streams))
+	for i, s := range b.streams {
+		out[i] = s.Path
+	}
+	return out
+}
+
+// Last returns the most recently started stream, or nil.
+func (b *FakeBackend) Last() *FakeStream {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.streams) == 0 {
+		return nil
+	}
+	return b.streams[len(b.streams)-1]
+}
+
+// FakeStream is a Stream started by FakeBackend.
+type FakeStream struct {
+	Path string
+	Opts StartOptions // options the stream was started with
+
+	mu       sync.Mutex
+	volume   int
+	paused   bool
+	position time.Duration
+	ended    bool
+	title    string
+	done     chan error
+}
+
+// Finish ends the stream as if the file played to the end.
+func (s *FakeStream) Finish() { s.end(nil) }
+
+func (s *FakeStream) end(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.ended = true
+	s.done <- err
+}
+
+func (s *FakeStream) Wait() error { return <-s.done }
+
+func (s *FakeStream) Stop() error {
+	s.end(errFakeStopped)
+	return nil
+}
+
+func (s *FakeStream) Pause() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = true
+	return nil
+}
+
+func (s *FakeStream) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.paused = false
+	return nil
+}
+
+func (s *FakeStream) SetVolume(volume int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.volume = volume
+	return nil
+}
+
+func (s *FakeStream) Position() (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.position, nil
+}
+
+func (s *FakeStream) Seek(pos time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.position = pos
+	return nil
+}
+
+// SetTitle sets the title the stream reports, as a radio station announces
+// the song it is playing.
+func (s *FakeStream) SetTitle(title string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.title = title
+}
+
+func (s *FakeStream) streamTitle() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.title, nil
+}
+
+// Volume returns the stream's current volume.
+func (s *FakeStream) Volume() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.volume
+}
+
+// Paused reports whether the stream is paused.
+func (s *FakeStream) Paused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
@@ -0,0 +1,83 @@
+package player
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+const ffplayBin = "ffplay"
+
+// FFPlayBackend plays files by spawning one ffplay process per file.
+// Volume and position are fixed once the process starts; the Player restarts
+// the file at the current position to change them.
+type FFPlayBackend struct {
+	bin string
+}
+
+// NewFFPlayBackend returns an ffplay backend, validating that bin exists.
+// An empty bin means "ffplay" from PATH.
+func NewFFPlayBackend(bin string) (*FFPlayBackend, error) {
+	if bin == "" {
+		bin = ffplayBin
+	}
+	if _, err := exec.LookPath(bin); err != nil {
+		return nil, fmt.Errorf("player: %s not found in PATH: %w", bin, err)
+	}
+	return &FFPlayBackend{bin: bin}, nil
+}
+
+func (b *FFPlayBackend) Start(path string, opts StartOptions) (Stream, error) {
+	cmd := exec.Command(b.bin, buildArgs(path, opts)...)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start ffplay: %w", err)
+	}
+	return &ffplayStream{cmd: cmd}, nil
+}
+
+// reconnectArgs keep ffplay retrying when a live stream drops.
+var reconnectArgs = []string{
+	"-reconnect", "1", "-reconnect_streamed", "1",
+	"-reconnect_on_network_error", "1", "-reconnect_delay_max", "30",
+}
+
+func buildArgs(filePath string, opts StartOptions) []string {
+	args := []string{"-nodisp", "-autoexit"}
+	args = append(args, "-volume", fmt.Sprintf("%d", opts.Volume))
+	if opts.Live {
+		args = append(args, reconnectArgs...)
+	} else if opts.Offset > 0 {
+		args = append(args, "-ss", fmt.Sprintf("%.3f", opts.Offset.Seconds()))
+	}
+	if opts.Gain != 0 {
+		args = append(args, "-af", volumeFilter(opts.Gain))
+	}
+	args = append(args, filePath)
+	return args
+}
+
+// volumeFilter is the ffmpeg audio filter applying gain dB.
+func volumeFilter(gain float64) string {
+	return fmt.Sprintf("volume=%.2fdB", gain)
+}
+
+type ffplayStream struct {
+	cmd *exec.Cmd
+}
+
+func (s *ffplayStream) Wait() error { return s.cmd.Wait() }
+
+func (s *ffplayStream) Stop() error { return s.cmd.Process.Kill() }
+
+// Pause suspends the process so that Resume continues from the same position.
+func (s *ffplayStream) Pause() error { return suspend(s.cmd.Process) }
+
+func (s *ffplayStream) Resume() error { return resume(s.cmd.Process) }
+
+func (s *ffplayStream) SetVolume(int) error { return ErrUnsupported }
+
+func (s *ffplayStream) fixedVolume() {}
+
+func (s *ffplayStream) Position() (time.Duration, error) { return 0, ErrUnsupported }
+
+func (s *ffplayStream) Seek(time.Duration) error { return ErrUnsupported }
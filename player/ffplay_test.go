@@ -0,0 +1,54 @@
+package player
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFFPlay writes a stand-in for ffplay that ignores its arguments and keeps
+// "playing" until killed.
+func fakeFFPlay(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ffplay")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\nexec sleep 30\n"), 0o755))
+	return path
+}
+
+func TestBuildArgs(t *testing.T) {
+	assert.Equal(t,
+		[]string{"-nodisp", "-autoexit", "-volume", "40", "a.mp3"},
+		buildArgs("a.mp3", StartOptions{Volume: 40}))
+	assert.Equal(t,
+		[]string{"-nodisp", "-autoexit", "-volume", "100", "-ss", "62.500", "a.mp3"},
+		buildArgs("a.mp3", StartOptions{Volume: 100, Offset: 62500 * time.Millisecond}))
+	assert.Equal(t,
+		[]string{"-nodisp", "-autoexit", "-volume", "80", "-af", "volume=-4.25dB", "a.mp3"},
+		buildArgs("a.mp3", StartOptions{Volume: 80, Gain: -4.25}))
+	assert.Equal(t,
+		[]string{
+			"-nodisp", "-autoexit", "-volume", "50",
+			"-reconnect", "1", "-reconnect_streamed", "1",
+			"-reconnect_on_network_error", "1", "-reconnect_delay_max", "30",
+			"http://radio/live",
+		},
+		buildArgs("http://radio/live", StartOptions{Volume: 50, Offset: time.Minute, Live: true}))
+}
+
+func TestFFPlayStream(t *testing.T) {
+	b, err := NewFFPlayBackend(fakeFFPlay(t))
+	require.NoError(t, err)
+
+	s, err := b.Start("a.mp3", StartOptions{})
+	require.NoError(t, err)
+	require.NoError(t, s.Pause())
+	require.NoError(t, s.Resume())
+	assert.ErrorIs(t, s.SetVolume(50), ErrUnsupported)
+
+	require.NoError(t, s.Stop())
+	assert.Error(t, s.Wait(), "killed process should not report a natural finish")
+}
@@ -0,0 +1,102 @@
+package player
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultTitlePoll is how often a backend is asked for a live stream's title.
+const defaultTitlePoll = 5 * time.Second
+
+// pollStreamTitle asks s for its title every interval and calls onTitle with
+// each new one until ctx is done. Failed checks are tried again next time.
+func pollStreamTitle(ctx context.Context, s titledStream, interval time.Duration, onTitle func(string)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := ""
+	for {
+		if title, err := s.streamTitle(); err == nil && title != last {
+			last = title
+			onTitle(title)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchICY reads the ICY (Shoutcast/Icecast) metadata of the stream at url
+// and calls onTitle with each new StreamTitle until ctx is done or the
+// stream ends. It returns at once for streams that send no metadata, such as
+// HLS playlists. Metadata is interleaved with the audio, so this opens a
+// second connection and downloads the whole stream alongside the backend.
+func watchICY(ctx context.Context, url string, onTitle func(string)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Icy-MetaData", "1")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("icy: %s: unexpected status %d", url, res.StatusCode)
+	}
+	metaint, err := strconv.Atoi(res.Header.Get("icy-metaint"))
+	if err != nil || metaint <= 0 {
+		return nil
+	}
+
+	r := bufio.NewReader(res.Body)
+	last := ""
+	for {
+		if _, err := io.CopyN(io.Discard, r, int64(metaint)); err != nil {
+			return err
+		}
+		n, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			continue
+		}
+		block := make([]byte, int(n)*16)
+		if _, err := io.ReadFull(r, block); err != nil {
+			return err
+		}
+		if title, ok := parseStreamTitle(string(block)); ok && title != last {
+			last = title
+			onTitle(title)
+		}
+	}
+}
+
+// parseStreamTitle extracts StreamTitle from a metadata block such as
+// "StreamTitle='Artist - Song';" padded with NUL bytes.
+func parseStreamTitle(block string) (string, bool) {
+	const key = "StreamTitle='"
+	i := strings.Index(block, key)
+	if i < 0 {
+		return "", false
+	}
+	rest := block[i+len(key):]
+	// Titles may contain quotes, so look for the field terminator.
+	end := strings.Index(rest, "';")
+	if end < 0 {
+		end = strings.LastIndex(rest, "'")
+	}
+	if end < 0 {
+		return "", false
+	}
+	return strings.TrimSpace(rest[:end]), true
+}
@@ -0,0 +1,112 @@
+package player
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// icyBlock pads meta to a whole number of 16-byte blocks behind its length byte.
+func icyBlock(meta string) string {
+	n := (len(meta) + 15) / 16
+	return string(rune(n)) + meta + strings.Repeat("\x00", n*16-len(meta))
+}
+
+// icyServer serves an endless-looking stream that announces titles in turn.
+func icyServer(t *testing.T, titles ...string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Icy-MetaData") != "1" {
+			return
+		}
+		w.Header().Set("icy-metaint", "8")
+		audio := strings.Repeat("a", 8)
+		body := audio + "\x00" // no metadata this time
+		for _, title := range titles {
+			body += audio + icyBlock("StreamTitle='"+title+"';StreamUrl='';")
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWatchICY(t *testing.T) {
+	srv := icyServer(t, "Artist - First", "Artist - First", "It's Second")
+	var got []string
+	err := watchICY(context.Background(), srv.URL, func(title string) { got = append(got, title) })
+	require.Error(t, err) // the stream ended
+	assert.Equal(t, []string{"Artist - First", "It's Second"}, got)
+
+	// No icy-metaint, as with HLS: nothing to read.
+	hls := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer hls.Close()
+	require.NoError(t, watchICY(context.Background(), hls.URL, func(string) { t.Fatal("unexpected title") }))
+}
+
+func TestParseStreamTitle(t *testing.T) {
+	title, ok := parseStreamTitle("StreamTitle='Band - Song';\x00\x00")
+	assert.True(t, ok)
+	assert.Equal(t, "Band - Song", title)
+	_, ok = parseStreamTitle("StreamUrl='x';")
+	assert.False(t, ok)
+}
+
+func TestPlayStream(t *testing.T) {
+	b := NewFakeBackend()
+	p, err := NewWithBackend(Config{ICYMetadata: true}, fixedBackend{b}, log.NewNoOpLogger())
+	require.NoError(t, err)
+	t.Cleanup(p.Stop)
+	srv := icyServer(t, "Live Now")
+	radio := &model.Song{ID: "r", Kind: model.SongKindStream, URL: srv.URL, Title: "Radio"}
+
+	require.NoError(t, p.Play(radio))
+	assert.Equal(t, srv.URL, b.Last().Path)
+	assert.True(t, b.Last().Opts.Live)
+	require.Eventually(t, func() bool { return p.Status().NowPlaying == "Live Now" }, time.Second, time.Millisecond)
+
+	// Streams cannot seek, so a restart rejoins at the live edge.
+	p.mu.Lock()
+	p.state.startedAt = time.Now().Add(-30 * time.Second)
+	p.mu.Unlock()
+	require.NoError(t, p.SetVolume(40))
+	require.Len(t, b.Started(), 2)
+	assert.Zero(t, b.Last().Opts.Offset)
+}
+
+func TestStreamTitleWithoutSecondConnection(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	t.Cleanup(srv.Close)
+	radio := &model.Song{ID: "r", Kind: model.SongKindStream, URL: srv.URL, Title: "Radio"}
+
+	// The backend reports the title of what it is playing.
+	b := NewFakeBackend()
+	p, err := NewWithBackend(Config{}, b, log.NewNoOpLogger())
+	require.NoError(t, err)
+	t.Cleanup(p.Stop)
+	p.titlePoll = time.Millisecond
+	require.NoError(t, p.Play(radio))
+	b.Last().SetTitle("Live Now")
+	require.Eventually(t, func() bool { return p.Status().NowPlaying == "Live Now" }, time.Second, time.Millisecond)
+
+	// Backends that cannot report it show no title unless ICYMetadata is set.
+	p, err = NewWithBackend(Config{}, fixedBackend{NewFakeBackend()}, log.NewNoOpLogger())
+	require.NoError(t, err)
+	t.Cleanup(p.Stop)
+	require.NoError(t, p.Play(radio))
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, p.Status().NowPlaying)
+	assert.Zero(t, requests.Load(), "the station is only connected to by the backend")
+}
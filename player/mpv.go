@@ -0,0 +1,252 @@
+package player
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	mpvBin = "mpv"
+
+	// mpvConnectTimeout is how long to wait for mpv to open its IPC socket.
+	mpvConnectTimeout = 2 * time.Second
+	// mpvReplyTimeout is how long to wait for a reply. mpv answers within
+	// milliseconds, and callers hold the player's lock while they wait.
+	mpvReplyTimeout = 250 * time.Millisecond
+)
+
+// errMPVUnavailable is returned for properties that have no value yet, such
+// as the title of a stream that has not sent one.
+var errMPVUnavailable = errors.New("mpv: property unavailable")
+
+// MPVBackend plays files with mpv and controls them over its JSON IPC socket,
+// which allows live volume changes and seeking.
+type MPVBackend struct {
+	bin     string
+	sockets atomic.Int64 // counter for unique socket names
+}
+
+// NewMPVBackend returns an mpv backend, validating that bin exists.
+// An empty bin means "mpv" from PATH.
+func NewMPVBackend(bin string) (*MPVBackend, error) {
+	if bin == "" {
+		bin = mpvBin
+	}
+	if _, err := exec.LookPath(bin); err != nil {
+		return nil, fmt.Errorf("player: %s not found in PATH: %w", bin, err)
+	}
+	return &MPVBackend{bin: bin}, nil
+}
+
+func (b *MPVBackend) Start(path string, opts StartOptions) (Stream, error) {
+	socket := filepath.Join(os.TempDir(),
+		fmt.Sprintf("rpi_music-mpv-%d-%d.sock", os.Getpid(), b.sockets.Add(1)))
+	args := []string{
+		"--no-video", "--no-terminal", "--idle=no",
+		"--volume=" + strconv.Itoa(opts.Volume),
+		"--input-ipc-server=" + socket,
+	}
+	if opts.Live {
+		args = append(args, "--stream-lavf-o=reconnect=1,reconnect_streamed=1,reconnect_delay_max=30")
+	} else if opts.Offset > 0 {
+		args = append(args, fmt.Sprintf("--start=%.3f", opts.Offset.Seconds()))
+	}
+	if opts.Gain != 0 {
+		args = append(args, "--af=lavfi=["+volumeFilter(opts.Gain)+"]")
+	}
+	cmd := exec.Command(b.bin, append(args, path)...)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start mpv: %w", err)
+	}
+	return &mpvStream{cmd: cmd, socket: socket, exited: make(chan struct{}), pending: map[int64]chan mpvResponse{}}, nil
+}
+
+// mpvStream is one mpv process. The IPC connection is opened on first use.
+type mpvStream struct {
+	cmd    *exec.Cmd
+	socket string
+	exited chan struct{} // closed once mpv has exited
+
+	mu      sync.Mutex
+	conn    net.Conn
+	nextID  int64
+	pending map[int64]chan mpvResponse
+}
+
+type mpvRequest struct {
+	Command   []any `json:"command"`
+	RequestID int64 `json:"request_id"`
+}
+
+type mpvResponse struct {
+	Data      json.RawMessage `json:"data"`
+	Error     string          `json:"error"`
+	RequestID int64           `json:"request_id"`
+	Event     string          `json:"event"`
+}
+
+func (s *mpvStream) Wait() error {
+	err := s.cmd.Wait()
+	close(s.exited)
+	s.closeConn()
+	_ = os.Remove(s.socket)
+	return err
+}
+
+func (s *mpvStream) Stop() error { return s.cmd.Process.Kill() }
+
+func (s *mpvStream) Pause() error { return s.setProperty("pause", true) }
+
+func (s *mpvStream) Resume() error { return s.setProperty("pause", false) }
+
+func (s *mpvStream) SetVolume(volume int) error { return s.setProperty("volume", volume) }
+
+func (s *mpvStream) Position() (time.Duration, error) {
+	data, err := s.call("get_property", "time-pos")
+	if err != nil {
+		return 0, err
+	}
+	var secs float64
+	if err := json.Unmarshal(data, &secs); err != nil {
+		return 0, fmt.Errorf("mpv time-pos: %w", err)
+	}
+	return time.Duration(secs * float64(time.Second)), nil
+}
+
+// streamTitle reads the StreamTitle mpv has parsed from the stream it is
+// playing, so no second connection to the station is needed.
+func (s *mpvStream) streamTitle() (string, error) {
+	data, err := s.call("get_property", "metadata/by-key/icy-title")
+	if errors.Is(err, errMPVUnavailable) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var title string
+	if err := json.Unmarshal(data, &title); err != nil {
+		return "", fmt.Errorf("mpv icy-title: %w", err)
+	}
+	return title, nil
+}
+
+func (s *mpvStream) Seek(pos time.Duration) error {
+	_, err := s.call("seek", pos.Seconds(), "absolute")
+	return err
+}
+
+func (s *mpvStream) setProperty(name string, value any) error {
+	_, err := s.call("set_property", name, value)
+	return err
+}
+
+// call sends one IPC command and waits for its reply.
+func (s *mpvStream) call(args ...any) (json.RawMessage, error) {
+	s.mu.Lock()
+	if err := s.connectLocked(); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.nextID++
+	id := s.nextID
+	reply := make(chan mpvResponse, 1)
+	s.pending[id] = reply
+	buf, err := json.Marshal(mpvRequest{Command: args, RequestID: id})
+	if err == nil {
+		_, err = s.conn.Write(append(buf, '\n'))
+	}
+	if err != nil {
+		delete(s.pending, id)
+		s.mu.Unlock()
+		return nil, fmt.Errorf("mpv ipc write: %w", err)
+	}
+	s.mu.Unlock()
+
+	select {
+	case res, ok := <-reply:
+		if !ok {
+			return nil, fmt.Errorf("mpv ipc: connection closed")
+		}
+		if res.Error == "property unavailable" {
+			return nil, errMPVUnavailable
+		}
+		if res.Error != "success" {
+			return nil, fmt.Errorf("mpv %v: %s", args[0], res.Error)
+		}
+		return res.Data, nil
+	case <-time.After(mpvReplyTimeout):
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+		return nil, fmt.Errorf("mpv %v: timeout", args[0])
+	}
+}
+
+// connectLocked dials the IPC socket, retrying while mpv starts up. It gives
+// up at once if mpv has exited.
+func (s *mpvStream) connectLocked() error {
+	if s.conn != nil {
+		return nil
+	}
+	deadline := time.Now().Add(mpvConnectTimeout)
+	for {
+		conn, err := net.Dial("unix", s.socket)
+		if err == nil {
+			s.conn = conn
+			go s.readLoop(conn)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("mpv ipc connect: %w", err)
+		}
+		select {
+		case <-s.exited:
+			return fmt.Errorf("mpv ipc connect: mpv has exited")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// readLoop routes replies to waiting callers. Unsolicited events are ignored.
+func (s *mpvStream) readLoop(conn net.Conn) {
+	sc := bufio.NewScanner(conn)
+	for sc.Scan() {
+		var res mpvResponse
+		if err := json.Unmarshal(sc.Bytes(), &res); err != nil || res.Event != "" {
+			continue
+		}
+		s.mu.Lock()
+		if ch, ok := s.pending[res.RequestID]; ok {
+			delete(s.pending, res.RequestID)
+			ch <- res
+		}
+		s.mu.Unlock()
+	}
+	s.mu.Lock()
+	for id, ch := range s.pending {
+		close(ch)
+		delete(s.pending, id)
+	}
+	if s.conn == conn {
+		s.conn = nil
+	}
+	s.mu.Unlock()
+}
+
+func (s *mpvStream) closeConn() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+}
@@ -0,0 +1,119 @@
+package player
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMPVSocket serves mpv's JSON IPC protocol on a unix socket and records the
+// commands it receives. Each reply is preceded by an unsolicited event line.
+func fakeMPVSocket(t *testing.T) (string, <-chan []any) {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "mpv")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "ipc.sock")
+
+	ln, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	commands := make(chan []any, 8)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		sc := bufio.NewScanner(conn)
+		for sc.Scan() {
+			var req mpvRequest
+			if err := json.Unmarshal(sc.Bytes(), &req); err != nil {
+				return
+			}
+			commands <- req.Command
+			res := map[string]any{"request_id": req.RequestID, "error": "success"}
+			switch {
+			case req.Command[0] != "get_property":
+			case req.Command[1] == "metadata/by-key/icy-title":
+				res["error"] = "property unavailable"
+			default:
+				res["data"] = 12.5
+			}
+			buf, _ := json.Marshal(res)
+			_, _ = conn.Write([]byte(`{"event":"playback-restart"}` + "\n"))
+			_, _ = conn.Write(append(buf, '\n'))
+		}
+	}()
+	return socket, commands
+}
+
+func TestMPVStreamIPC(t *testing.T) {
+	socket, commands := fakeMPVSocket(t)
+	s := &mpvStream{socket: socket, pending: map[int64]chan mpvResponse{}}
+	t.Cleanup(s.closeConn)
+
+	require.NoError(t, s.SetVolume(40))
+	assert.Equal(t, []any{"set_property", "volume", float64(40)}, <-commands)
+
+	require.NoError(t, s.Pause())
+	assert.Equal(t, []any{"set_property", "pause", true}, <-commands)
+
+	require.NoError(t, s.Seek(90*time.Second))
+	assert.Equal(t, []any{"seek", float64(90), "absolute"}, <-commands)
+
+	pos, err := s.Position()
+	require.NoError(t, err)
+	assert.Equal(t, 12500*time.Millisecond, pos)
+	assert.Equal(t, []any{"get_property", "time-pos"}, <-commands)
+
+	// A stream that has sent no title yet.
+	title, err := s.streamTitle()
+	require.NoError(t, err)
+	assert.Empty(t, title)
+	assert.Equal(t, []any{"get_property", "metadata/by-key/icy-title"}, <-commands)
+}
+
+func TestMPVStreamGivesUpQuickly(t *testing.T) {
+	dir, err := os.MkdirTemp("", "mpv")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	// mpv accepts the connection but never replies.
+	socket := filepath.Join(dir, "ipc.sock")
+	ln, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		_, _ = io.Copy(io.Discard, conn)
+		_ = conn.Close()
+	}()
+	s := &mpvStream{socket: socket, pending: map[int64]chan mpvResponse{}}
+	t.Cleanup(s.closeConn)
+	start := time.Now()
+	_, err = s.Position()
+	require.ErrorContains(t, err, "timeout")
+	assert.Less(t, time.Since(start), mpvConnectTimeout)
+
+	// mpv has exited, so its socket will never appear.
+	exited := make(chan struct{})
+	close(exited)
+	s = &mpvStream{socket: filepath.Join(dir, "gone.sock"), exited: exited, pending: map[int64]chan mpvResponse{}}
+	start = time.Now()
+	_, err = s.Position()
+	require.ErrorContains(t, err, "exited")
+	assert.Less(t, time.Since(start), mpvConnectTimeout)
+}
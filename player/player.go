@@ -1,15 +1,28 @@
 package player
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"sync"
+	"time"
 
+	"github.com/jaredwarren/rpi_music/events"
 	"github.com/jaredwarren/rpi_music/model"
 )
 
-const ffplayBin = "ffplay"
+var (
+	// ErrNoQueue is returned by queue controls when nothing has been queued.
+	ErrNoQueue = errors.New("player: no queue")
+	// ErrNotPlaying is returned by Pause and Resume when no song is loaded.
+	ErrNotPlaying = errors.New("player: not playing")
+	// ErrNoChapters is returned by chapter controls when the song has no chapters.
+	ErrNoChapters = errors.New("player: song has no chapters")
+	// ErrBusy is returned when other songs are playing and cfg.AllowOverride
+	// is off, so the requested ones were not started.
+	ErrBusy = errors.New("player: another song is playing")
+)
 
 // Logger is the minimal logger contract player depends on.
 // Kept local to this package so callers can satisfy it with any implementation.
@@ -18,43 +31,128 @@ type Logger interface {
 	Error(msg string, args ...any)
 }
 
+// Backend names accepted in Config.Backend.
+const (
+	BackendFFPlay = "ffplay"
+	BackendMPV    = "mpv"
+)
+
+// VolumeStep is how much one press of volume up or down changes the volume.
+const VolumeStep = 10
+
 // Config holds all tunable player settings.
 type Config struct {
 	SongRoot      string
 	ThumbRoot     string
 	Volume        int
-	Loop          bool
+	Loop          model.LoopMode // default for queues whose options leave it unset
 	AllowOverride bool
 	Restart       bool
 	Beep          bool
-	FFPlayBin     string // defaults to "ffplay"
+	Backend       string      // BackendFFPlay (default) or BackendMPV
+	ICYMetadata   bool        // read stream titles the backend cannot report over a second connection
+	FFPlayBin     string      // defaults to "ffplay"
+	MPVBin        string      // defaults to "mpv"
+	Events        *events.Bus // receives player started/stopped events; may be nil
+	Positions     Positions   // remembers where resumable songs stopped; may be nil
 }
 
-func (c Config) binary() string {
-	if c.FFPlayBin != "" {
-		return c.FFPlayBin
+// Positions saves where songs stopped playing. It is satisfied by db.PositionStore.
+type Positions interface {
+	GetPosition(songID string) (time.Duration, error)
+	SavePosition(songID string, pos time.Duration) error
+}
+
+// chapterRewind is how far into a chapter PreviousChapter goes back to the
+// start of that chapter rather than to the one before it.
+const chapterRewind = 3 * time.Second
+
+// resumeTail is how close to its end a song may stop and still start over
+// next time, rather than resuming for a few seconds.
+const resumeTail = 10 * time.Second
+
+func (c Config) volume() int {
+	if c.Volume <= 0 {
+		return 100
 	}
-	return ffplayBin
+	return clampVolume(c.Volume)
 }
 
-// Player manages a single ffplay subprocess.
+func clampVolume(v int) int {
+	return max(0, min(100, v))
+}
+
+// newBackend builds the Backend named by c.Backend.
+func (c Config) newBackend() (Backend, error) {
+	switch c.Backend {
+	case "", BackendFFPlay:
+		return NewFFPlayBackend(c.FFPlayBin)
+	case BackendMPV:
+		return NewMPVBackend(c.MPVBin)
+	default:
+		return nil, fmt.Errorf("player: unknown backend %q", c.Backend)
+	}
+}
+
+// Player plays one stream at a time through a Backend and manages the queue of songs it plays through.
 type Player struct {
-	cfg    Config
-	logger Logger
-	mu     sync.Mutex
-	state  *playState
+	cfg     Config
+	backend Backend
+	logger  Logger
+	mu      sync.Mutex
+	state   *playState
+	queue   *queue
+	volume  int // live volume before the per-song offset
+	// stopAfterSong stops playback when the current song ends instead of advancing.
+	stopAfterSong bool
+	titlePoll     time.Duration // how often a live stream's title is checked
 }
 
 type playState struct {
-	song *model.Song
-	cmd  *exec.Cmd
+	song       *model.Song
+	stream     Stream
+	nowPlaying string             // ICY title of a live stream
+	stopMeta   context.CancelFunc // stops reading stream metadata; nil for files
+	paused     bool
+	startedAt  time.Time
+	pausedAt   time.Time     // when the current pause began
+	pausedFor  time.Duration // total time spent in completed pauses
 }
 
-// New creates a Player, validates that ffplay exists, and ensures song/thumb directories exist.
+// elapsed returns how far into the song playback is, not counting pauses.
+func (st *playState) elapsed(now time.Time) time.Duration {
+	if st.paused {
+		now = st.pausedAt
+	}
+	return now.Sub(st.startedAt) - st.pausedFor
+}
+
+// Status is a snapshot of what the player is doing.
+type Status struct {
+	Song          *model.Song
+	Playing       bool
+	Paused        bool
+	Elapsed       time.Duration
+	Duration      time.Duration
+	QueuePosition int
+	QueueLength   int
+	Loop          model.LoopMode
+	NowPlaying    string // what a live stream says it is playing, if it says
+	Chapter       string // title of the chapter playing, if the song has chapters
+}
+
+// New creates a Player with the backend named in cfg, validates that its binary
+// exists, and ensures song/thumb directories exist.
 func New(cfg Config, logger Logger) (*Player, error) {
-	if _, err := exec.LookPath(cfg.binary()); err != nil {
-		return nil, fmt.Errorf("player: %s not found in PATH: %w", cfg.binary(), err)
+	backend, err := cfg.newBackend()
+	if err != nil {
+		return nil, err
 	}
+	return NewWithBackend(cfg, backend, logger)
+}
+
+// NewWithBackend creates a Player that plays through backend and ensures song/thumb directories exist.
+func NewWithBackend(cfg Config, backend Backend, logger Logger) (*Player, error) {
 	for _, dir := range []string{cfg.SongRoot, cfg.ThumbRoot} {
 		if dir == "" {
 			continue
@@ -63,68 +161,539 @@ func New(cfg Config, logger Logger) (*Player, error) {
 			return nil, fmt.Errorf("player: create directory %s: %w", dir, err)
 		}
 	}
-	return &Player{cfg: cfg, logger: logger}, nil
+	return &Player{cfg: cfg, backend: backend, logger: logger, volume: cfg.volume(), titlePoll: defaultTitlePoll}, nil
 }
 
 // Play starts playing song. If a song is already playing, behaviour depends on cfg.AllowOverride.
 func (p *Player) Play(song *model.Song) error {
-	if song == nil || song.FilePath == "" {
-		return fmt.Errorf("song file path is empty")
+	_, err := p.PlayQueue([]*model.Song{song}, model.PlaybackOptions{})
+	return err
+}
+
+// PlayQueue replaces the queue with songs and starts the first one. When a song
+// finishes the next one starts, following opts for shuffle and looping.
+// If other songs are playing and cfg.AllowOverride is off, it returns ErrBusy.
+// started is false when the same songs were already playing or were only
+// resumed.
+func (p *Player) PlayQueue(songs []*model.Song, opts model.PlaybackOptions) (started bool, err error) {
+	return p.playQueue(songs, opts, false)
+}
+
+// PlayCard is PlayQueue for a card tap. It plays the success sound just before
+// the songs start, and not when they were already playing or are only resumed.
+func (p *Player) PlayCard(songs []*model.Song, opts model.PlaybackOptions) (started bool, err error) {
+	return p.playQueue(songs, opts, true)
+}
+
+func (p *Player) playQueue(songs []*model.Song, opts model.PlaybackOptions, beep bool) (bool, error) {
+	if len(songs) == 0 {
+		return false, fmt.Errorf("queue is empty")
+	}
+	for _, song := range songs {
+		if song == nil || song.Location() == "" {
+			return false, fmt.Errorf("song file path is empty")
+		}
 	}
 
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if p.state != nil && p.state.song != nil && p.state.song.FilePath == song.FilePath && !p.cfg.Restart {
-		p.logger.Info("selected song already playing", "song", song)
-		return nil
+	again := p.alreadyPlayingLocked(songs)
+	if again {
+		if p.state.paused {
+			p.logger.Info("resuming selected song", "song", p.state.song)
+			return false, p.resumeLocked()
+		}
+		if !p.cfg.Restart {
+			p.logger.Info("selected song already playing", "song", songs[0])
+			return false, nil
+		}
 	}
 	if p.state != nil {
 		if !p.cfg.AllowOverride {
-			p.logger.Info("another song already playing", "song", song)
-			p.playSound("sounds/error.wav")
-			return nil
+			p.logger.Info("another song already playing", "song", songs[0])
+			p.playSound("sounds/error.wav", p.volume)
+			return false, ErrBusy
 		}
 		p.killLocked()
 	}
+	if beep {
+		p.playSound("sounds/success.wav", p.volume)
+	}
+
+	p.queue = newQueue(songs, opts)
+	song := p.queue.current()
+	var offset time.Duration
+	if !again {
+		offset = p.savedPositionLocked(song)
+	}
+	if err := p.startLocked(song, offset, false); err != nil {
+		p.queue = nil
+		return false, err
+	}
+	return true, nil
+}
+
+func (p *Player) alreadyPlayingLocked(songs []*model.Song) bool {
+	if p.state == nil || p.queue == nil {
+		return false
+	}
+	if len(songs) == 1 {
+		return p.state.song.Location() == songs[0].Location()
+	}
+	return p.queue.hasSongs(songs)
+}
 
-	args := p.buildArgs(song.FilePath)
-	p.logger.Info("Play song", "song", song, "args", args)
+// startLocked starts a stream for song at offset. When the stream ends on its
+// own the queue advances to the next song. restart is set when the current
+// song is started again only to apply new options.
+func (p *Player) startLocked(song *model.Song, offset time.Duration, restart bool) error {
+	opts := StartOptions{Volume: p.songVolumeLocked(song), Offset: offset, Gain: song.Gain, Live: song.IsStream()}
+	if opts.Live {
+		opts.Offset = 0
+	}
+	p.logger.Info("Play song", "song", song, "opts", opts)
 
-	cmd := exec.Command(p.cfg.binary(), args...)
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("start ffplay: %w", err)
+	stream, err := p.backend.Start(song.Location(), opts)
+	if err != nil {
+		return err
 	}
 
-	st := &playState{song: song, cmd: cmd}
+	st := &playState{song: song, stream: stream, startedAt: time.Now().Add(-opts.Offset)}
 	p.state = st
+	if !restart {
+		p.cfg.Events.Publish(events.PlayerStarted, song)
+	}
+	if opts.Live {
+		p.watchMetadataLocked(st)
+	}
 
 	go func() {
-		_ = cmd.Wait()
+		err := stream.Wait()
+		if st.stopMeta != nil {
+			st.stopMeta()
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.state != st {
+			return
+		}
+		defer p.publishStoppedLocked()
+		p.state = nil
+		if err != nil {
+			p.logger.Error("playback ended with error", "song", song, "err", err)
+			p.queue = nil
+			return
+		}
+		p.savePositionLocked(song, 0)
+		if p.stopAfterSong {
+			p.stopAfterSong = false
+			p.queue = nil
+			return
+		}
+		if p.queue != nil && p.loopLocked() == model.LoopOne {
+			if err := p.startLocked(song, 0, false); err != nil {
+				p.logger.Error("repeat song", "err", err)
+				p.queue = nil
+			}
+			return
+		}
+		p.advanceLocked()
+	}()
+
+	return nil
+}
+
+// watchMetadataLocked follows st's ICY titles until the stream stops. They
+// are asked of the backend when it can report them, and otherwise read over a
+// second connection only if cfg.ICYMetadata is set.
+func (p *Player) watchMetadataLocked(st *playState) {
+	titled, ok := st.stream.(titledStream)
+	if !ok && !p.cfg.ICYMetadata {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	st.stopMeta = cancel
+	onTitle := func(title string) {
 		p.mu.Lock()
 		defer p.mu.Unlock()
-		if p.state == st {
-			p.state = nil
+		st.nowPlaying = title
+	}
+	go func() {
+		var err error
+		if ok {
+			pollStreamTitle(ctx, titled, p.titlePoll, onTitle)
+		} else {
+			err = watchICY(ctx, st.song.URL, onTitle)
+		}
+		if err != nil && ctx.Err() == nil {
+			p.logger.Error("stream metadata", "song", st.song, "err", err)
 		}
 	}()
+}
+
+// advanceLocked starts the next queued song, or clears the queue when it is finished.
+func (p *Player) advanceLocked() {
+	if p.queue == nil || !p.queue.next(p.loopLocked()) {
+		p.queue = nil
+		return
+	}
+	song := p.queue.current()
+	if err := p.startLocked(song, p.savedPositionLocked(song), false); err != nil {
+		p.logger.Error("advance queue", "err", err)
+		p.queue = nil
+	}
+}
+
+// loopLocked returns the queue's loop mode, falling back to the configured default.
+func (p *Player) loopLocked() model.LoopMode {
+	if p.queue != nil && p.queue.opts.Loop != model.LoopDefault {
+		return p.queue.opts.Loop
+	}
+	if p.cfg.Loop == model.LoopDefault {
+		return model.LoopNone
+	}
+	return p.cfg.Loop
+}
+
+// SetLoop changes the default loop mode, including for the current queue when
+// its options leave the mode unset.
+func (p *Player) SetLoop(mode model.LoopMode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cfg.Loop = mode
+}
+
+// Loop returns the loop mode in effect for the current queue.
+func (p *Player) Loop() model.LoopMode {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.loopLocked()
+}
+
+// Next skips to the next song in the queue. Skipping past the last song stops playback.
+func (p *Player) Next() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.nextLocked()
+}
+
+func (p *Player) nextLocked() error {
+	if p.queue == nil {
+		return ErrNoQueue
+	}
+	p.killLocked()
+	p.advanceLocked()
+	p.publishStoppedLocked()
+	return nil
+}
+
+// Previous goes back to the previous song in the queue, or restarts the first one.
+func (p *Player) Previous() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.queue == nil {
+		return ErrNoQueue
+	}
+	p.killLocked()
+	p.queue.prev(p.loopLocked())
+	if err := p.startLocked(p.queue.current(), 0, false); err != nil {
+		p.queue = nil
+		return err
+	}
+	return nil
+}
+
+// Pause pauses the current stream so that Resume continues from the same position.
+func (p *Player) Pause() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return ErrNotPlaying
+	}
+	if p.state.paused {
+		return nil
+	}
+	if err := p.state.stream.Pause(); err != nil {
+		return fmt.Errorf("pause: %w", err)
+	}
+	p.state.paused = true
+	p.state.pausedAt = time.Now()
+	if p.resumes(p.state.song) {
+		p.savePositionLocked(p.state.song, p.positionLocked())
+	}
+	p.logger.Info("Pause song", "song", p.state.song)
+	return nil
+}
+
+// Resume continues a paused song.
+func (p *Player) Resume() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return ErrNotPlaying
+	}
+	return p.resumeLocked()
+}
+
+func (p *Player) resumeLocked() error {
+	if !p.state.paused {
+		return nil
+	}
+	if err := p.state.stream.Resume(); err != nil {
+		return fmt.Errorf("resume: %w", err)
+	}
+	p.state.paused = false
+	p.state.pausedFor += time.Since(p.state.pausedAt)
+	p.logger.Info("Resume song", "song", p.state.song)
+	return nil
+}
+
+// SetVolume changes the volume (0-100) of the current stream right away and of
+// every song started after it. Backends that cannot change volume live have the
+// current song restarted at its current position.
+func (p *Player) SetVolume(volume int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	volume = clampVolume(volume)
+	if volume == p.volume {
+		return nil
+	}
+	p.volume = volume
+	p.logger.Info("Set volume", "volume", p.volume)
+	if p.state == nil {
+		return nil
+	}
+	err := p.state.stream.SetVolume(p.songVolumeLocked(p.state.song))
+	if errors.Is(err, ErrUnsupported) {
+		return p.restartLocked()
+	}
+	return err
+}
+
+// fixedVolumeStream is implemented by Streams whose volume is set when they
+// start, so that every SetVolume restarts the song.
+type fixedVolumeStream interface{ fixedVolume() }
+
+// LiveVolume reports whether SetVolume changes the playing song's volume
+// without restarting it.
+func (p *Player) LiveVolume() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return true
+	}
+	_, fixed := p.state.stream.(fixedVolumeStream)
+	return !fixed
+}
+
+// Volume returns the live volume, before any per-song offset.
+func (p *Player) Volume() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.volume
+}
+
+// songVolumeLocked applies song's volume offset to the live volume.
+func (p *Player) songVolumeLocked(song *model.Song) int {
+	return clampVolume(p.volume + song.VolumeOffset)
+}
+
+// restartLocked starts the current song again from its current position so new
+// start options take effect. A paused song stays paused.
+func (p *Player) restartLocked() error {
+	return p.restartAtLocked(p.positionLocked())
+}
 
+// restartAtLocked starts the current song again from offset.
+func (p *Player) restartAtLocked(offset time.Duration) error {
+	old := p.state
+	p.killLocked()
+	if err := p.startLocked(old.song, offset, true); err != nil {
+		p.queue = nil
+		return err
+	}
+	if old.paused {
+		if err := p.state.stream.Pause(); err != nil {
+			return fmt.Errorf("pause: %w", err)
+		}
+		p.state.paused = true
+		p.state.pausedAt = time.Now()
+	}
 	return nil
 }
 
-// Stop stops the current playback.
+// positionLocked returns how far into the current song playback is, preferring
+// the backend's own position and falling back to wall-clock time.
+func (p *Player) positionLocked() time.Duration {
+	if pos, err := p.state.stream.Position(); err == nil {
+		return pos
+	}
+	return p.state.elapsed(time.Now())
+}
+
+// Paused reports whether the current song is paused.
+func (p *Player) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state != nil && p.state.paused
+}
+
+// Stop stops the current playback and clears the queue.
 func (p *Player) Stop() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	playing := p.state != nil
 	p.killLocked()
+	p.queue = nil
+	p.stopAfterSong = false
+	if playing {
+		p.publishStoppedLocked()
+	}
+}
+
+// publishStoppedLocked publishes events.PlayerStopped if nothing is playing.
+func (p *Player) publishStoppedLocked() {
+	if p.state == nil {
+		p.cfg.Events.Publish(events.PlayerStopped, nil)
+	}
+}
+
+// StopAfterSong makes playback stop when the current song ends rather than
+// moving on through the queue. Passing false cancels it.
+func (p *Player) StopAfterSong(stop bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopAfterSong = stop
 }
 
 func (p *Player) killLocked() {
-	if p.state != nil && p.state.cmd != nil && p.state.cmd.Process != nil {
-		_ = p.state.cmd.Process.Kill()
+	if p.state != nil {
+		if p.resumes(p.state.song) {
+			p.savePositionLocked(p.state.song, p.positionLocked())
+		}
+		if p.state.stopMeta != nil {
+			p.state.stopMeta()
+		}
+		if err := p.state.stream.Stop(); err != nil {
+			p.logger.Error("stop stream", "err", err)
+		}
 	}
 	p.state = nil
 }
 
+// resumes reports whether song's position is remembered between plays.
+func (p *Player) resumes(song *model.Song) bool {
+	return song.Resume && !song.IsStream() && p.cfg.Positions != nil
+}
+
+// savedPositionLocked returns where song should start: its saved position if
+// it resumes, otherwise the beginning.
+func (p *Player) savedPositionLocked(song *model.Song) time.Duration {
+	if !p.resumes(song) {
+		return 0
+	}
+	pos, err := p.cfg.Positions.GetPosition(song.ID)
+	if err != nil {
+		p.logger.Error("get position", "song", song, "err", err)
+		return 0
+	}
+	return pos
+}
+
+// savePositionLocked remembers pos for a resumable song. Stopping near the
+// end counts as finishing, so the song starts over next time.
+func (p *Player) savePositionLocked(song *model.Song, pos time.Duration) {
+	if !p.resumes(song) {
+		return
+	}
+	if song.Duration > 0 && pos > song.Duration-resumeTail {
+		pos = 0
+	}
+	if err := p.cfg.Positions.SavePosition(song.ID, pos); err != nil {
+		p.logger.Error("save position", "song", song, "err", err)
+	}
+}
+
+// Seek moves playback of the current song to pos.
+func (p *Player) Seek(pos time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return ErrNotPlaying
+	}
+	return p.seekLocked(pos)
+}
+
+// seekLocked seeks the current stream, restarting it at pos on backends that
+// cannot seek.
+func (p *Player) seekLocked(pos time.Duration) error {
+	err := p.state.stream.Seek(pos)
+	if errors.Is(err, ErrUnsupported) && !p.state.song.IsStream() {
+		return p.restartAtLocked(pos)
+	}
+	return err
+}
+
+// NextChapter seeks to the start of the next chapter of the current song.
+// From the last chapter it moves on to the next song in the queue.
+func (p *Player) NextChapter() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return ErrNotPlaying
+	}
+	song := p.state.song
+	if len(song.Chapters) == 0 {
+		return ErrNoChapters
+	}
+	i := song.ChapterAt(p.positionLocked())
+	if i+1 >= len(song.Chapters) {
+		return p.nextLocked()
+	}
+	return p.seekLocked(song.Chapters[i+1].Start)
+}
+
+// PreviousChapter seeks back to the start of the current chapter, or to the
+// chapter before it when the current one has only just begun.
+func (p *Player) PreviousChapter() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return ErrNotPlaying
+	}
+	song := p.state.song
+	if len(song.Chapters) == 0 {
+		return ErrNoChapters
+	}
+	pos := p.positionLocked()
+	i := song.ChapterAt(pos)
+	if i > 0 && pos-song.Chapters[i].Start < chapterRewind {
+		i--
+	}
+	return p.seekLocked(song.Chapters[i].Start)
+}
+
+// Queue returns the queued songs in play order, or nil when nothing is queued.
+func (p *Player) Queue() []*model.Song {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.queue == nil {
+		return nil
+	}
+	return p.queue.list()
+}
+
+// QueuePosition returns the index of the current song within Queue.
+func (p *Player) QueuePosition() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.queue == nil {
+		return 0
+	}
+	return p.queue.pos
+}
+
 // GetPlaying returns the currently playing song, or nil.
 func (p *Player) GetPlaying() *model.Song {
 	p.mu.Lock()
@@ -135,7 +704,42 @@ func (p *Player) GetPlaying() *model.Song {
 	return p.state.song
 }
 
-// Playing reports whether audio is currently playing.
+// Status reports the current song, its elapsed and total time, and the queue position.
+func (p *Player) Status() Status {
+	p.mu.Lock()
+	st := Status{Loop: p.loopLocked()}
+	if p.queue != nil {
+		st.QueuePosition = p.queue.pos
+		st.QueueLength = len(p.queue.order)
+	}
+	if p.state == nil {
+		p.mu.Unlock()
+		return st
+	}
+	st.Song = p.state.song
+	st.Playing = true
+	st.Paused = p.state.paused
+	st.Elapsed = p.state.elapsed(time.Now())
+	st.Duration = p.state.song.Duration
+	st.NowPlaying = p.state.nowPlaying
+	stream := p.state.stream
+	p.mu.Unlock()
+
+	// Status is polled, so the backend is asked without holding p.mu. If the
+	// stream stops meanwhile, the wall-clock position stands.
+	if pos, err := stream.Position(); err == nil {
+		st.Elapsed = pos
+	}
+	if st.Duration > 0 && st.Elapsed > st.Duration {
+		st.Elapsed = st.Duration
+	}
+	if i := st.Song.ChapterAt(st.Elapsed); i >= 0 {
+		st.Chapter = st.Song.Chapters[i].Title
+	}
+	return st
+}
+
+// Playing reports whether a song is loaded, including while it is paused.
 func (p *Player) Playing() bool {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -143,27 +747,19 @@ func (p *Player) Playing() bool {
 }
 
 // Beep plays the success sound if beep is enabled.
-func (p *Player) Beep() { p.playSound("sounds/success.wav") }
+func (p *Player) Beep() { p.playSound("sounds/success.wav", p.Volume()) }
 
 // Error plays the error sound if beep is enabled.
-func (p *Player) Error() { p.playSound("sounds/error.wav") }
+func (p *Player) Error() { p.playSound("sounds/error.wav", p.Volume()) }
 
-func (p *Player) playSound(path string) {
+func (p *Player) playSound(path string, volume int) {
 	if !p.cfg.Beep {
 		return
 	}
-	args := p.buildArgs(path)
-	cmd := exec.Command(p.cfg.binary(), args...)
-	_ = cmd.Run()
-}
-
-func (p *Player) buildArgs(filePath string) []string {
-	vol := p.cfg.Volume
-	if vol <= 0 {
-		vol = 100
+	stream, err := p.backend.Start(path, StartOptions{Volume: volume})
+	if err != nil {
+		p.logger.Error("play sound", "path", path, "err", err)
+		return
 	}
-	args := []string{"-nodisp", "-autoexit"}
-	args = append(args, "-volume", fmt.Sprintf("%d", vol))
-	args = append(args, filePath)
-	return args
+	_ = stream.Wait()
 }
@@ -0,0 +1,414 @@
+package player
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPlayer returns a Player backed by a FakeBackend.
+func newTestPlayer(t *testing.T) (*Player, *FakeBackend) {
+	t.Helper()
+	b := NewFakeBackend()
+	p, err := NewWithBackend(Config{AllowOverride: true}, b, log.NewNoOpLogger())
+	require.NoError(t, err)
+	t.Cleanup(p.Stop)
+	return p, b
+}
+
+func TestPlayQueueAdvancesWhenSongEnds(t *testing.T) {
+	p, b := newTestPlayer(t)
+
+	_, err := p.PlayQueue(testSongs("a", "b", "c"), model.PlaybackOptions{})
+	require.NoError(t, err)
+	for _, want := range []string{"a", "b", "c"} {
+		require.Eventually(t, func() bool {
+			s := p.GetPlaying()
+			return s != nil && s.ID == want
+		}, time.Second, time.Millisecond)
+		b.Last().Finish()
+	}
+
+	require.Eventually(t, func() bool {
+		return !p.Playing() && p.Queue() == nil
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"a", "b", "c"}, b.Started())
+}
+
+func TestRepeatOneReplaysSong(t *testing.T) {
+	p, b := newTestPlayer(t)
+	_, err := p.PlayQueue(testSongs("a", "b"), model.PlaybackOptions{Loop: model.LoopOne})
+	require.NoError(t, err)
+
+	for i := 1; i <= 2; i++ {
+		b.Last().Finish()
+		require.Eventually(t, func() bool { return len(b.Started()) == i+1 }, time.Second, time.Millisecond)
+		assert.Equal(t, "a", p.GetPlaying().ID)
+	}
+
+	// Skipping still moves through the queue.
+	require.NoError(t, p.Next())
+	assert.Equal(t, "b", p.GetPlaying().ID)
+}
+
+func TestConfiguredLoopIsDefault(t *testing.T) {
+	p, b := newTestPlayer(t)
+	p.SetLoop(model.LoopQueue)
+	_, err := p.PlayQueue(testSongs("a"), model.PlaybackOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, model.LoopQueue, p.Loop())
+
+	b.Last().Finish()
+	require.Eventually(t, func() bool { return len(b.Started()) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, "a", p.GetPlaying().ID)
+
+	// A card's own mode wins over the default.
+	_, err = p.PlayQueue(testSongs("b"), model.PlaybackOptions{Loop: model.LoopNone})
+	require.NoError(t, err)
+	assert.Equal(t, model.LoopNone, p.Loop())
+	b.Last().Finish()
+	require.Eventually(t, func() bool { return !p.Playing() }, time.Second, time.Millisecond)
+}
+
+func TestPlayQueueReportsStarted(t *testing.T) {
+	p, b := newTestPlayer(t)
+	started, err := p.PlayQueue(testSongs("a", "b"), model.PlaybackOptions{})
+	require.NoError(t, err)
+	assert.True(t, started)
+
+	// The same card again resumes it, or leaves it playing.
+	require.NoError(t, p.Pause())
+	started, err = p.PlayQueue(testSongs("a", "b"), model.PlaybackOptions{})
+	require.NoError(t, err)
+	assert.False(t, started)
+	assert.False(t, p.Paused())
+	started, err = p.PlayQueue(testSongs("a", "b"), model.PlaybackOptions{})
+	require.NoError(t, err)
+	assert.False(t, started)
+	assert.Len(t, b.Started(), 1)
+
+	started, err = p.PlayQueue(testSongs("c"), model.PlaybackOptions{})
+	require.NoError(t, err)
+	assert.True(t, started)
+}
+
+// soundBackend finishes the beep and error sounds at once, as a real backend
+// would after playing them.
+type soundBackend struct{ *FakeBackend }
+
+func (b soundBackend) Start(path string, opts StartOptions) (Stream, error) {
+	s, err := b.FakeBackend.Start(path, opts)
+	if strings.HasPrefix(path, "sounds/") {
+		s.(*FakeStream).Finish()
+	}
+	return s, err
+}
+
+func TestPlayCardBeepsBeforeStarting(t *testing.T) {
+	b := NewFakeBackend()
+	p, err := NewWithBackend(Config{Beep: true}, soundBackend{b}, log.NewNoOpLogger())
+	require.NoError(t, err)
+	t.Cleanup(p.Stop)
+
+	started, err := p.PlayCard(testSongs("a"), model.PlaybackOptions{})
+	require.NoError(t, err)
+	assert.True(t, started)
+	assert.Equal(t, []string{"sounds/success.wav", "a"}, b.Started())
+
+	// Resuming is silent.
+	require.NoError(t, p.Pause())
+	started, err = p.PlayCard(testSongs("a"), model.PlaybackOptions{})
+	require.NoError(t, err)
+	assert.False(t, started)
+	assert.Len(t, b.Started(), 2)
+
+	// Another card is refused with the error sound only.
+	started, err = p.PlayCard(testSongs("b"), model.PlaybackOptions{})
+	require.ErrorIs(t, err, ErrBusy)
+	assert.False(t, started)
+	assert.Equal(t, []string{"sounds/success.wav", "a", "sounds/error.wav"}, b.Started())
+	assert.Equal(t, "a", p.GetPlaying().ID)
+}
+
+func TestNextAndPrevious(t *testing.T) {
+	p, _ := newTestPlayer(t)
+	_, err := p.PlayQueue(testSongs("a", "b"), model.PlaybackOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "a", p.GetPlaying().ID)
+
+	require.NoError(t, p.Next())
+	assert.Equal(t, "b", p.GetPlaying().ID)
+	assert.Equal(t, 1, p.QueuePosition())
+
+	require.NoError(t, p.Previous())
+	assert.Equal(t, "a", p.GetPlaying().ID)
+
+	require.NoError(t, p.Next())
+	require.NoError(t, p.Next())
+	assert.False(t, p.Playing())
+	assert.Nil(t, p.Queue())
+}
+
+func TestNextWithoutQueue(t *testing.T) {
+	p, _ := newTestPlayer(t)
+	require.ErrorIs(t, p.Next(), ErrNoQueue)
+	require.ErrorIs(t, p.Previous(), ErrNoQueue)
+}
+
+func TestPauseAndResume(t *testing.T) {
+	p, b := newTestPlayer(t)
+	require.ErrorIs(t, p.Pause(), ErrNotPlaying)
+
+	songs := testSongs("a", "b")
+	_, err := p.PlayQueue(songs, model.PlaybackOptions{})
+	require.NoError(t, err)
+	require.NoError(t, p.Pause())
+	assert.True(t, p.Paused())
+	assert.True(t, p.Playing())
+	assert.True(t, b.Last().Paused())
+
+	require.NoError(t, p.Resume())
+	assert.False(t, p.Paused())
+	assert.False(t, b.Last().Paused())
+
+	// Re-queueing the same songs while paused resumes instead of restarting.
+	require.NoError(t, p.Pause())
+	_, err = p.PlayQueue(songs, model.PlaybackOptions{})
+	require.NoError(t, err)
+	assert.False(t, p.Paused())
+	assert.Equal(t, "a", p.GetPlaying().ID)
+	assert.Len(t, b.Started(), 1)
+}
+
+func TestStatusExcludesPausedTime(t *testing.T) {
+	st := &playState{startedAt: time.Unix(100, 0)}
+	assert.Equal(t, 10*time.Second, st.elapsed(time.Unix(110, 0)))
+
+	st.paused = true
+	st.pausedAt = time.Unix(110, 0)
+	assert.Equal(t, 10*time.Second, st.elapsed(time.Unix(200, 0)))
+
+	st.paused = false
+	st.pausedFor = 90 * time.Second
+	assert.Equal(t, 15*time.Second, st.elapsed(time.Unix(205, 0)))
+}
+
+func TestStatusReportsSongAndQueue(t *testing.T) {
+	p, _ := newTestPlayer(t)
+	assert.False(t, p.Status().Playing)
+
+	songs := testSongs("a", "b")
+	songs[0].Duration = time.Minute
+	_, err := p.PlayQueue(songs, model.PlaybackOptions{})
+	require.NoError(t, err)
+	require.NoError(t, p.Seek(20*time.Second))
+
+	st := p.Status()
+	assert.True(t, st.Playing)
+	assert.Equal(t, "a", st.Song.ID)
+	assert.Equal(t, time.Minute, st.Duration)
+	assert.Equal(t, 20*time.Second, st.Elapsed)
+	assert.Equal(t, 2, st.QueueLength)
+}
+
+func TestNewRejectsUnknownBackend(t *testing.T) {
+	_, err := New(Config{Backend: "vlc"}, log.NewNoOpLogger())
+	require.Error(t, err)
+}
+
+// fixedBackend wraps FakeBackend with streams that, like ffplay, cannot change
+// volume or report position while playing.
+type fixedBackend struct{ *FakeBackend }
+
+func (b fixedBackend) Start(path string, opts StartOptions) (Stream, error) {
+	s, err := b.FakeBackend.Start(path, opts)
+	return fixedStream{s}, err
+}
+
+type fixedStream struct{ Stream }
+
+func (fixedStream) SetVolume(int) error { return ErrUnsupported }
+
+func (fixedStream) fixedVolume() {}
+
+func (fixedStream) Position() (time.Duration, error) { return 0, ErrUnsupported }
+
+func TestSetVolumeLive(t *testing.T) {
+	p, b := newTestPlayer(t)
+	assert.Equal(t, 100, p.Volume())
+
+	songs := testSongs("a")
+	songs[0].VolumeOffset = -20
+	_, err := p.PlayQueue(songs, model.PlaybackOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 80, b.Last().Volume())
+
+	require.NoError(t, p.SetVolume(50))
+	assert.Equal(t, 50, p.Volume())
+	assert.Equal(t, 30, b.Last().Volume())
+	assert.Len(t, b.Started(), 1)
+
+	require.NoError(t, p.SetVolume(150))
+	assert.Equal(t, 100, p.Volume())
+}
+
+func TestSetVolumeRestartsWhenNotLive(t *testing.T) {
+	b := NewFakeBackend()
+	p, err := NewWithBackend(Config{Volume: 60}, fixedBackend{b}, log.NewNoOpLogger())
+	require.NoError(t, err)
+	t.Cleanup(p.Stop)
+
+	_, err = p.PlayQueue(testSongs("a", "b"), model.PlaybackOptions{})
+	require.NoError(t, err)
+	p.mu.Lock()
+	p.state.startedAt = time.Now().Add(-30 * time.Second)
+	p.mu.Unlock()
+	require.NoError(t, p.Pause())
+
+	require.NoError(t, p.SetVolume(60))
+	require.Len(t, b.Started(), 1, "same volume does not restart")
+
+	require.NoError(t, p.SetVolume(40))
+	require.Len(t, b.Started(), 2)
+	restarted := b.Last()
+	assert.Equal(t, 40, restarted.Opts.Volume)
+	assert.InDelta(t, 30, restarted.Opts.Offset.Seconds(), 1)
+	assert.True(t, p.Paused(), "restart keeps the song paused")
+	assert.True(t, restarted.Paused())
+	assert.Equal(t, "a", p.GetPlaying().ID)
+	assert.Len(t, p.Queue(), 2)
+}
+
+func TestPublishesStartedAndStopped(t *testing.T) {
+	bus := events.NewBus(0)
+	sub := bus.Subscribe(events.PlayerStarted, events.PlayerStopped)
+	b := NewFakeBackend()
+	p, err := NewWithBackend(Config{Events: bus}, b, log.NewNoOpLogger())
+	require.NoError(t, err)
+
+	_, err = p.PlayQueue(testSongs("a", "b"), model.PlaybackOptions{})
+	require.NoError(t, err)
+	b.Last().Finish()
+	for _, want := range []string{"a", "b"} {
+		ev := <-sub.C
+		assert.Equal(t, events.PlayerStarted, ev.Type)
+		assert.Equal(t, want, ev.Data.(*model.Song).ID)
+	}
+	b.Last().Finish()
+	assert.Equal(t, events.PlayerStopped, (<-sub.C).Type)
+
+	require.NoError(t, p.Play(testSongs("c")[0]))
+	<-sub.C
+	p.Stop()
+	assert.Equal(t, events.PlayerStopped, (<-sub.C).Type)
+	p.Stop()
+	assert.Empty(t, sub.C)
+}
+
+func TestResumeFromSavedPosition(t *testing.T) {
+	store := &db.MockDB{}
+	p, err := NewWithBackend(Config{AllowOverride: true, Positions: store}, NewFakeBackend(), log.NewNoOpLogger())
+	require.NoError(t, err)
+	t.Cleanup(p.Stop)
+	b := p.backend.(*FakeBackend)
+
+	songs := testSongs("book", "song")
+	songs[0].Resume = true
+	songs[0].Duration = time.Hour
+	require.NoError(t, p.Play(songs[0]))
+	require.NoError(t, p.Seek(20*time.Minute))
+	p.Stop()
+	assert.Equal(t, 20*time.Minute, store.Positions["book"])
+
+	require.NoError(t, p.Play(songs[0]))
+	assert.Equal(t, 20*time.Minute, b.Last().Opts.Offset)
+
+	// Songs that always restart neither save nor use a position.
+	require.NoError(t, p.Play(songs[1]))
+	assert.Zero(t, b.Last().Opts.Offset)
+	assert.NotContains(t, store.Positions, "song")
+
+	// Stopping in the last seconds, or finishing, starts over next time.
+	require.NoError(t, p.Play(songs[0]))
+	require.NoError(t, p.Seek(time.Hour-5*time.Second))
+	p.Stop()
+	assert.NotContains(t, store.Positions, "book")
+	require.NoError(t, store.SavePosition("book", time.Minute))
+	require.NoError(t, p.Play(songs[0]))
+	b.Last().Finish()
+	require.Eventually(t, func() bool { return !p.Playing() }, time.Second, time.Millisecond)
+	pos, err := store.GetPosition("book")
+	require.NoError(t, err)
+	assert.Zero(t, pos)
+}
+
+func TestChapterControls(t *testing.T) {
+	p, b := newTestPlayer(t)
+	require.ErrorIs(t, p.NextChapter(), ErrNotPlaying)
+
+	songs := testSongs("book", "after")
+	songs[0].Chapters = []model.Chapter{
+		{Title: "One", End: time.Minute},
+		{Title: "Two", Start: time.Minute, End: 2 * time.Minute},
+		{Title: "Three", Start: 2 * time.Minute, End: 3 * time.Minute},
+	}
+	_, err := p.PlayQueue(songs, model.PlaybackOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "One", p.Status().Chapter)
+
+	require.NoError(t, p.NextChapter())
+	assert.Equal(t, time.Minute, mustPosition(t, b.Last()))
+	assert.Equal(t, "Two", p.Status().Chapter)
+
+	// Well into a chapter, previous goes back to its start; right at the
+	// start, it goes to the chapter before.
+	require.NoError(t, p.Seek(90*time.Second))
+	require.NoError(t, p.PreviousChapter())
+	assert.Equal(t, time.Minute, mustPosition(t, b.Last()))
+	require.NoError(t, p.PreviousChapter())
+	assert.Equal(t, time.Duration(0), mustPosition(t, b.Last()))
+
+	// Past the last chapter is the next song, which has none.
+	require.NoError(t, p.Seek(150*time.Second))
+	require.NoError(t, p.NextChapter())
+	assert.Equal(t, "after", p.GetPlaying().ID)
+	require.ErrorIs(t, p.NextChapter(), ErrNoChapters)
+}
+
+func TestSeekRestartsWhenNotLive(t *testing.T) {
+	b := NewFakeBackend()
+	p, err := NewWithBackend(Config{}, seeklessBackend{b}, log.NewNoOpLogger())
+	require.NoError(t, err)
+	t.Cleanup(p.Stop)
+
+	require.NoError(t, p.Play(testSongs("a")[0]))
+	require.NoError(t, p.Seek(40*time.Second))
+	require.Len(t, b.Started(), 2)
+	assert.Equal(t, 40*time.Second, b.Last().Opts.Offset)
+}
+
+func mustPosition(t *testing.T, s Stream) time.Duration {
+	t.Helper()
+	pos, err := s.Position()
+	require.NoError(t, err)
+	return pos
+}
+
+// seeklessBackend starts streams that cannot seek, like ffplay's.
+type seeklessBackend struct{ *FakeBackend }
+
+func (b seeklessBackend) Start(path string, opts StartOptions) (Stream, error) {
+	s, err := b.FakeBackend.Start(path, opts)
+	return seeklessStream{s}, err
+}
+
+type seeklessStream struct{ Stream }
+
+func (seeklessStream) Seek(time.Duration) error { return ErrUnsupported }
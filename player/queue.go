@@ -0,0 +1,91 @@
+package player
+
+import (
+	"math/rand/v2"
+
+	"github.com/jaredwarren/rpi_music/model"
+)
+
+// queue is the ordered list of songs the player works through.
+// order holds indexes into songs; it is a permutation when shuffle is on.
+type queue struct {
+	songs []*model.Song
+	order []int
+	pos   int
+	opts  model.PlaybackOptions
+}
+
+func newQueue(songs []*model.Song, opts model.PlaybackOptions) *queue {
+	q := &queue{
+		songs: songs,
+		order: make([]int, len(songs)),
+		opts:  opts,
+	}
+	for i := range q.order {
+		q.order[i] = i
+	}
+	if opts.Shuffle {
+		q.shuffle()
+	}
+	return q
+}
+
+func (q *queue) shuffle() {
+	rand.Shuffle(len(q.order), func(i, j int) {
+		q.order[i], q.order[j] = q.order[j], q.order[i]
+	})
+}
+
+// current returns the song at the cursor.
+func (q *queue) current() *model.Song {
+	return q.songs[q.order[q.pos]]
+}
+
+// next advances the cursor. It returns false when the end is reached and loop is not LoopQueue.
+func (q *queue) next(loop model.LoopMode) bool {
+	if q.pos+1 < len(q.order) {
+		q.pos++
+		return true
+	}
+	if loop != model.LoopQueue {
+		return false
+	}
+	q.pos = 0
+	if q.opts.Shuffle {
+		q.shuffle()
+	}
+	return true
+}
+
+// prev moves the cursor back one song. At the start it stays put unless loop
+// is LoopQueue, in which case it wraps to the last song.
+func (q *queue) prev(loop model.LoopMode) {
+	switch {
+	case q.pos > 0:
+		q.pos--
+	case loop == model.LoopQueue:
+		q.pos = len(q.order) - 1
+	}
+}
+
+// list returns the songs in play order.
+func (q *queue) list() []*model.Song {
+	out := make([]*model.Song, len(q.order))
+	for i, idx := range q.order {
+		out[i] = q.songs[idx]
+	}
+	return out
+}
+
+// hasSongs reports whether the queue holds exactly songs, by location, in the given order.
+func (q *queue) hasSongs(songs []*model.Song) bool {
+	if len(songs) != len(q.songs) {
+		return false
+	}
+	for i, s := range songs {
+		if q.songs[i].Location() != s.Location() {
+			return false
+		}
+	}
+	return true
+}
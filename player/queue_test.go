@@ -0,0 +1,64 @@
+package player
+
+import (
+	"testing"
+
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSongs(paths ...string) []*model.Song {
+	songs := make([]*model.Song, len(paths))
+	for i, p := range paths {
+		songs[i] = &model.Song{ID: p, FilePath: p}
+	}
+	return songs
+}
+
+func TestQueueNextInOrder(t *testing.T) {
+	q := newQueue(testSongs("a", "b", "c"), model.PlaybackOptions{})
+
+	assert.Equal(t, "a", q.current().ID)
+	require.True(t, q.next(model.LoopNone))
+	assert.Equal(t, "b", q.current().ID)
+	require.True(t, q.next(model.LoopNone))
+	assert.Equal(t, "c", q.current().ID)
+	assert.False(t, q.next(model.LoopNone))
+	assert.False(t, q.next(model.LoopOne))
+}
+
+func TestQueueRepeatWraps(t *testing.T) {
+	q := newQueue(testSongs("a", "b"), model.PlaybackOptions{})
+
+	require.True(t, q.next(model.LoopQueue))
+	require.True(t, q.next(model.LoopQueue))
+	assert.Equal(t, "a", q.current().ID)
+
+	q.prev(model.LoopQueue)
+	assert.Equal(t, "b", q.current().ID)
+}
+
+func TestQueuePrevStaysAtStart(t *testing.T) {
+	q := newQueue(testSongs("a", "b"), model.PlaybackOptions{})
+	q.prev(model.LoopNone)
+	assert.Equal(t, "a", q.current().ID)
+}
+
+func TestQueueShuffleKeepsAllSongs(t *testing.T) {
+	q := newQueue(testSongs("a", "b", "c", "d"), model.PlaybackOptions{Shuffle: true})
+
+	var got []string
+	for _, s := range q.list() {
+		got = append(got, s.ID)
+	}
+	assert.ElementsMatch(t, []string{"a", "b", "c", "d"}, got)
+}
+
+func TestQueueHasSongs(t *testing.T) {
+	q := newQueue(testSongs("a", "b"), model.PlaybackOptions{Shuffle: true})
+
+	assert.True(t, q.hasSongs(testSongs("a", "b")))
+	assert.False(t, q.hasSongs(testSongs("b", "a")))
+	assert.False(t, q.hasSongs(testSongs("a")))
+}
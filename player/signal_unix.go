@@ -0,0 +1,14 @@
+//go:build unix
+
+package player
+
+import (
+	"os"
+	"syscall"
+)
+
+// suspend stops the process in place; the audio position is kept.
+func suspend(proc *os.Process) error { return proc.Signal(syscall.SIGSTOP) }
+
+// resume continues a process stopped by suspend.
+func resume(proc *os.Process) error { return proc.Signal(syscall.SIGCONT) }
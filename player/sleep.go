@@ -0,0 +1,269 @@
+package player
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultSleepFade is how long the sleep timer takes to fade the volume out.
+const DefaultSleepFade = 30 * time.Second
+
+// sleepFadeSteps is how many times the volume drops during a fade on backends
+// that restart the song for each change.
+const sleepFadeSteps = 3
+
+// sleepDriftTolerance is the least a countdown may drift from the clock before
+// listeners are sent a correction.
+const sleepDriftTolerance = 100 * time.Millisecond
+
+// SleepStatus is a snapshot of the sleep timer.
+type SleepStatus struct {
+	Active    bool
+	AfterSong bool          // stopping when the current song ends rather than at a set time
+	Remaining time.Duration // zero when unknown, e.g. a song without a probed duration
+	Paused    bool          // the song is paused, so an after-song countdown is on hold
+}
+
+// SleepTimer stops a Player after a while, fading the volume out over the last
+// stretch and restoring it once playback has stopped.
+type SleepTimer struct {
+	player *Player
+	fade   time.Duration
+	tick   time.Duration
+
+	mu       sync.Mutex
+	status   SleepStatus
+	deadline time.Time
+	stop     chan struct{}
+	volume   int  // volume before fading, restored when the timer ends
+	faded    bool // volume has been lowered since the timer started
+	fadedTo  int  // volume the fade last set; any other volume was set by the user
+	onUpdate func(SleepStatus)
+}
+
+// NewSleepTimer returns an idle timer for p. A fade of 0 uses DefaultSleepFade.
+func NewSleepTimer(p *Player, fade time.Duration) *SleepTimer {
+	if fade <= 0 {
+		fade = DefaultSleepFade
+	}
+	return &SleepTimer{player: p, fade: fade, tick: time.Second}
+}
+
+// OnUpdate registers fn to be called with the timer's status whenever it is
+// started, cancelled or fires, and when the time left stops following the clock
+// (e.g. the song was paused or its duration became known). In between,
+// listeners count down Remaining themselves.
+func (t *SleepTimer) OnUpdate(fn func(SleepStatus)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onUpdate = fn
+}
+
+// Start stops playback after d, replacing any running timer.
+func (t *SleepTimer) Start(d time.Duration) {
+	t.mu.Lock()
+	t.replaceLocked()
+	t.deadline = time.Now().Add(d)
+	t.status = SleepStatus{Active: true, Remaining: d}
+	t.player.StopAfterSong(false)
+	t.runLocked()
+	t.mu.Unlock()
+}
+
+// StartAfterSong stops playback when the current song ends, replacing any running timer.
+func (t *SleepTimer) StartAfterSong() {
+	t.mu.Lock()
+	t.replaceLocked()
+	t.status = SleepStatus{Active: true, AfterSong: true}
+	t.player.StopAfterSong(true)
+	t.runLocked()
+	t.mu.Unlock()
+}
+
+// Cancel stops the timer and restores the volume it faded from.
+func (t *SleepTimer) Cancel() {
+	t.mu.Lock()
+	running := t.cancelLocked()
+	t.status = SleepStatus{}
+	t.restoreVolumeLocked()
+	if running {
+		t.player.StopAfterSong(false)
+	}
+	t.mu.Unlock()
+	if running {
+		t.update(SleepStatus{})
+	}
+}
+
+// Status returns the timer's current state.
+func (t *SleepTimer) Status() SleepStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status
+}
+
+// replaceLocked cancels a running timer, undoing any fade, and records the
+// volume the next timer fades from.
+func (t *SleepTimer) replaceLocked() {
+	t.cancelLocked()
+	t.restoreVolumeLocked()
+	t.volume = t.player.Volume()
+}
+
+func (t *SleepTimer) cancelLocked() bool {
+	if t.stop == nil {
+		return false
+	}
+	close(t.stop)
+	t.stop = nil
+	return true
+}
+
+func (t *SleepTimer) runLocked() {
+	stop := make(chan struct{})
+	t.stop = stop
+	go t.run(stop, t.status.AfterSong, t.volume)
+}
+
+// run ticks until the timer fires or stop is closed.
+func (t *SleepTimer) run(stop chan struct{}, afterSong bool, volume int) {
+	faded := volume
+	var reported SleepStatus
+	var reportedAt time.Time
+	ticker := time.NewTicker(t.tick)
+	defer ticker.Stop()
+	for {
+		remaining, paused, done := t.remaining(afterSong)
+		if done {
+			t.finish(stop)
+			return
+		}
+		if remaining > 0 && remaining < t.fade {
+			target := t.fadeTarget(volume, remaining)
+			if target < faded && t.fadeTo(stop, target) {
+				faded = target
+			}
+		}
+		st, ok := t.setRemaining(stop, remaining, paused)
+		if !ok {
+			return
+		}
+		if reportedAt.IsZero() || t.stale(reported, reportedAt, st) {
+			t.update(st)
+			reported, reportedAt = st, time.Now()
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// remaining returns the time left, whether the countdown is on hold and
+// whether the timer should fire now.
+func (t *SleepTimer) remaining(afterSong bool) (time.Duration, bool, bool) {
+	if !afterSong {
+		t.mu.Lock()
+		left := time.Until(t.deadline)
+		t.mu.Unlock()
+		return max(left, 0), false, left <= 0
+	}
+	st := t.player.Status()
+	if !st.Playing {
+		return 0, false, true
+	}
+	if st.Duration == 0 {
+		return 0, st.Paused, false
+	}
+	return max(st.Duration-st.Elapsed, 0), st.Paused, false
+}
+
+// fadeTarget returns the volume to play at with remaining left of the fade.
+// Backends that restart the song to change volume fade in a few coarse steps.
+func (t *SleepTimer) fadeTarget(volume int, remaining time.Duration) int {
+	steps := int64(max(volume, 1))
+	if !t.player.LiveVolume() {
+		steps = sleepFadeSteps
+	}
+	// Round up so the volume only reaches 0 as the timer fires.
+	step := (steps*int64(remaining) + int64(t.fade) - 1) / int64(t.fade)
+	return int(step * int64(volume) / steps)
+}
+
+// stale reports whether a listener counting down from last, reported at
+// reportedAt, would now be showing something other than st.
+func (t *SleepTimer) stale(last SleepStatus, reportedAt time.Time, st SleepStatus) bool {
+	if last.Active != st.Active || last.AfterSong != st.AfterSong || last.Paused != st.Paused {
+		return true
+	}
+	want := last.Remaining
+	if !last.Paused {
+		want = max(want-time.Since(reportedAt), 0)
+	}
+	return (st.Remaining - want).Abs() > max(2*t.tick, sleepDriftTolerance)
+}
+
+// fadeTo lowers the player's volume, unless the run was cancelled or the user
+// has changed the volume since the last step.
+func (t *SleepTimer) fadeTo(stop chan struct{}, volume int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stop != stop || t.userVolumeLocked() {
+		return false
+	}
+	t.faded, t.fadedTo = true, volume
+	_ = t.player.SetVolume(volume)
+	return true
+}
+
+// userVolumeLocked reports whether the volume was changed during the fade by
+// something other than the timer.
+func (t *SleepTimer) userVolumeLocked() bool {
+	return t.faded && t.player.Volume() != t.fadedTo
+}
+
+// restoreVolumeLocked puts back the volume from before the fade, unless the
+// user has set one since.
+func (t *SleepTimer) restoreVolumeLocked() {
+	if t.faded && !t.userVolumeLocked() {
+		_ = t.player.SetVolume(t.volume)
+	}
+	t.faded = false
+}
+
+// setRemaining records the time left unless the run was cancelled, and
+// returns the new status and whether it was recorded.
+func (t *SleepTimer) setRemaining(stop chan struct{}, remaining time.Duration, paused bool) (SleepStatus, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stop != stop {
+		return SleepStatus{}, false
+	}
+	t.status.Remaining = remaining
+	t.status.Paused = paused
+	return t.status, true
+}
+
+func (t *SleepTimer) finish(stop chan struct{}) {
+	t.mu.Lock()
+	if t.stop != stop {
+		t.mu.Unlock()
+		return
+	}
+	t.stop = nil
+	t.status = SleepStatus{}
+	t.player.Stop()
+	t.restoreVolumeLocked()
+	t.mu.Unlock()
+	t.update(SleepStatus{})
+}
+
+func (t *SleepTimer) update(st SleepStatus) {
+	t.mu.Lock()
+	fn := t.onUpdate
+	t.mu.Unlock()
+	if fn != nil {
+		fn(st)
+	}
+}
@@ -0,0 +1,153 @@
+package player
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSleepTimer(p *Player, fade time.Duration) *SleepTimer {
+	t := NewSleepTimer(p, fade)
+	t.tick = time.Millisecond
+	return t
+}
+
+func TestSleepTimerFadesAndStops(t *testing.T) {
+	p, b := newTestPlayer(t)
+	require.NoError(t, p.SetVolume(80))
+	_, err := p.PlayQueue(testSongs("a", "b"), model.PlaybackOptions{})
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var updates []SleepStatus
+	timer := newTestSleepTimer(p, 100*time.Millisecond)
+	timer.OnUpdate(func(st SleepStatus) {
+		mu.Lock()
+		defer mu.Unlock()
+		updates = append(updates, st)
+	})
+	timer.Start(150 * time.Millisecond)
+	assert.True(t, timer.Status().Active)
+
+	require.Eventually(t, func() bool { return b.Last().Volume() < 80 }, time.Second, time.Millisecond)
+	require.Eventually(t, func() bool { return !p.Playing() }, time.Second, time.Millisecond)
+	assert.False(t, timer.Status().Active)
+	assert.Equal(t, 80, p.Volume(), "volume is restored for the next song")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotEmpty(t, updates)
+	assert.True(t, updates[0].Active)
+	assert.False(t, updates[len(updates)-1].Active)
+}
+
+func TestSleepTimerCancelRestoresVolume(t *testing.T) {
+	p, b := newTestPlayer(t)
+	require.NoError(t, p.SetVolume(60))
+	_, err := p.PlayQueue(testSongs("a"), model.PlaybackOptions{})
+	require.NoError(t, err)
+
+	timer := newTestSleepTimer(p, 2*time.Hour)
+	timer.Start(time.Hour)
+	require.Eventually(t, func() bool { return b.Last().Volume() < 60 }, time.Second, time.Millisecond)
+
+	timer.Cancel()
+	assert.False(t, timer.Status().Active)
+	assert.Equal(t, 60, p.Volume())
+	assert.Equal(t, 60, b.Last().Volume())
+	assert.True(t, p.Playing())
+}
+
+func TestSleepTimerKeepsVolumeSetDuringFade(t *testing.T) {
+	p, b := newTestPlayer(t)
+	require.NoError(t, p.SetVolume(60))
+	_, err := p.PlayQueue(testSongs("a"), model.PlaybackOptions{})
+	require.NoError(t, err)
+
+	timer := newTestSleepTimer(p, 2*time.Hour)
+	timer.Start(time.Hour)
+	require.Eventually(t, func() bool { return b.Last().Volume() < 60 }, time.Second, time.Millisecond)
+
+	require.NoError(t, p.SetVolume(45))
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 45, p.Volume(), "the fade stops once the user takes over")
+	timer.Cancel()
+	assert.Equal(t, 45, p.Volume())
+	assert.Equal(t, 45, b.Last().Volume())
+}
+
+func TestSleepTimerAfterSong(t *testing.T) {
+	p, b := newTestPlayer(t)
+	_, err := p.PlayQueue(testSongs("a", "b"), model.PlaybackOptions{})
+	require.NoError(t, err)
+
+	timer := newTestSleepTimer(p, time.Second)
+	timer.StartAfterSong()
+	assert.True(t, timer.Status().AfterSong)
+
+	b.Last().Finish()
+	require.Eventually(t, func() bool { return !timer.Status().Active }, time.Second, time.Millisecond)
+	assert.False(t, p.Playing())
+	assert.Equal(t, []string{"a"}, b.Started())
+}
+
+func TestSleepTimerFadesInStepsWhenNotLive(t *testing.T) {
+	b := NewFakeBackend()
+	p, err := NewWithBackend(Config{Volume: 90}, fixedBackend{b}, log.NewNoOpLogger())
+	require.NoError(t, err)
+	t.Cleanup(p.Stop)
+	_, err = p.PlayQueue(testSongs("a"), model.PlaybackOptions{})
+	require.NoError(t, err)
+	assert.False(t, p.LiveVolume())
+
+	timer := newTestSleepTimer(p, 90*time.Millisecond)
+	timer.Start(90 * time.Millisecond)
+	require.Eventually(t, func() bool { return !p.Playing() }, time.Second, time.Millisecond)
+
+	started := b.Started()
+	assert.GreaterOrEqual(t, len(started), 2, "fades at least once")
+	assert.LessOrEqual(t, len(started), sleepFadeSteps, "one restart per step, not per tick")
+	assert.Equal(t, 90, p.Volume())
+}
+
+func TestSleepTimerReportsOnlyChanges(t *testing.T) {
+	p, _ := newTestPlayer(t)
+	songs := testSongs("a")
+	songs[0].Duration = time.Hour
+	_, err := p.PlayQueue(songs, model.PlaybackOptions{})
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var updates []SleepStatus
+	timer := newTestSleepTimer(p, time.Millisecond)
+	timer.OnUpdate(func(st SleepStatus) {
+		mu.Lock()
+		defer mu.Unlock()
+		updates = append(updates, st)
+	})
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(updates)
+	}
+	t.Cleanup(timer.Cancel)
+
+	timer.Start(time.Hour)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, count(), "a running countdown is not reported every tick")
+
+	timer.StartAfterSong()
+	require.Eventually(t, func() bool { return count() == 2 }, time.Second, time.Millisecond)
+	require.NoError(t, p.Pause())
+	require.Eventually(t, func() bool { return count() == 3 }, time.Second, time.Millisecond)
+	mu.Lock()
+	assert.True(t, updates[2].Paused)
+	mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 3, count(), "a paused countdown is reported once")
+}
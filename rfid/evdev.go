@@ -0,0 +1,107 @@
+package rfid
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Linux input event constants from <linux/input-event-codes.h>.
+const (
+	evKey      = 0x01
+	keyEnter   = 28
+	keyKPEnter = 96
+	keyDown    = 1
+)
+
+// inputEventSize is sizeof(struct input_event): a timeval of two longs, then
+// type, code and value.
+const inputEventSize = 2*strconv.IntSize/8 + 8
+
+// keyChars maps key codes to the characters USB RFID readers type.
+var keyChars = map[uint16]byte{
+	2: '1', 3: '2', 4: '3', 5: '4', 6: '5', 7: '6', 8: '7', 9: '8', 10: '9', 11: '0',
+	79: '1', 80: '2', 81: '3', 75: '4', 76: '5', 77: '6', 71: '7', 72: '8', 73: '9', 82: '0',
+	30: 'a', 48: 'b', 46: 'c', 32: 'd', 18: 'e', 33: 'f',
+}
+
+// EvdevSource reads a keyboard-wedge USB RFID reader from its
+// /dev/input/event* device. On Linux the device is grabbed so the typed UIDs
+// do not also reach the console.
+type EvdevSource struct {
+	f      *os.File
+	events chan<- Event
+	logger *slog.Logger
+}
+
+// NewEvdevSource opens and grabs the input device at path.
+func NewEvdevSource(path string, events chan<- Event, logger *slog.Logger) (*EvdevSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("rfid: open %s: %w", path, err)
+	}
+	if err := grab(f); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("rfid: grab %s: %w", path, err)
+	}
+	return &EvdevSource{f: f, events: events, logger: logger}, nil
+}
+
+// Start reads key presses until the device is closed or ctx is cancelled.
+// Each UID is emitted as Present when the reader presses Enter.
+func (s *EvdevSource) Start(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		s.Close()
+	}()
+	go func() {
+		if err := readKeys(ctx, s.f, s.events); err != nil && !errors.Is(err, os.ErrClosed) {
+			s.logger.Error("rfid: read input events", "err", err)
+		}
+	}()
+}
+
+// Close closes the device, releasing the grab.
+func (s *EvdevSource) Close() { _ = s.f.Close() }
+
+// readKeys decodes input events from r into tag events.
+func readKeys(ctx context.Context, r io.Reader, events chan<- Event) error {
+	var uid strings.Builder
+	buf := make([]byte, inputEventSize)
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		typ := binary.NativeEndian.Uint16(buf[inputEventSize-8:])
+		code := binary.NativeEndian.Uint16(buf[inputEventSize-6:])
+		value := int32(binary.NativeEndian.Uint32(buf[inputEventSize-4:]))
+		if typ != evKey || value != keyDown {
+			continue
+		}
+		switch code {
+		case keyEnter, keyKPEnter:
+			if uid.Len() == 0 {
+				continue
+			}
+			select {
+			case events <- Event{UID: uid.String(), Kind: Present}:
+			case <-ctx.Done():
+				return nil
+			}
+			uid.Reset()
+		default:
+			if c, ok := keyChars[code]; ok {
+				uid.WriteByte(c)
+			}
+		}
+	}
+}
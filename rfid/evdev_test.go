@@ -0,0 +1,37 @@
+package rfid
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inputEvents encodes key presses (value 1) and releases (value 0) as struct input_event.
+func inputEvents(codes ...uint16) []byte {
+	var b bytes.Buffer
+	for _, code := range codes {
+		for _, value := range []uint32{1, 0} {
+			ev := make([]byte, inputEventSize)
+			binary.NativeEndian.PutUint16(ev[inputEventSize-8:], evKey)
+			binary.NativeEndian.PutUint16(ev[inputEventSize-6:], code)
+			binary.NativeEndian.PutUint32(ev[inputEventSize-4:], value)
+			b.Write(ev)
+		}
+	}
+	return b.Bytes()
+}
+
+func TestReadKeys(t *testing.T) {
+	events := make(chan Event, 2)
+	// "0123" Enter, a bare Enter, then keypad "9" and keypad Enter.
+	data := inputEvents(11, 2, 3, 4, keyEnter, keyEnter, 73, keyKPEnter)
+
+	require.NoError(t, readKeys(context.Background(), bytes.NewReader(data), events))
+	assert.Equal(t, Event{UID: "0123", Kind: Present}, <-events)
+	assert.Equal(t, Event{UID: "9", Kind: Present}, <-events)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,17 @@
+package rfid
+
+import (
+	"os"
+	"syscall"
+)
+
+// evIOCGrab is EVIOCGRAB, _IOW('E', 0x90, int).
+const evIOCGrab = 0x40044590
+
+// grab takes exclusive access to an input device.
+func grab(f *os.File) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), evIOCGrab, 1); errno != 0 {
+		return errno
+	}
+	return nil
+}
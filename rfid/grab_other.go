@@ -0,0 +1,8 @@
+//go:build !linux
+
+package rfid
+
+import "os"
+
+// grab is a no-op where evdev does not exist; reading still works on files.
+func grab(*os.File) error { return nil }
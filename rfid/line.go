@@ -0,0 +1,89 @@
+package rfid
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// ParseLine reads one tag event from a line of text. A bare UID, as typed by
+// keyboard-wedge readers, is Present; "-UID" or "removed UID" is Removed.
+// Blank lines and lines starting with # return ok=false.
+func ParseLine(line string) (ev Event, ok bool, err error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return Event{}, false, nil
+	}
+	fields := strings.Fields(line)
+	switch {
+	case len(fields) == 1 && strings.HasPrefix(fields[0], "-"):
+		ev = Event{UID: fields[0][1:], Kind: Removed}
+	case len(fields) == 1:
+		ev = Event{UID: fields[0], Kind: Present}
+	case len(fields) == 2 && fields[0] == "present":
+		ev = Event{UID: fields[1], Kind: Present}
+	case len(fields) == 2 && fields[0] == "removed":
+		ev = Event{UID: fields[1], Kind: Removed}
+	default:
+		return Event{}, false, fmt.Errorf("rfid: bad tag line %q", line)
+	}
+	if ev.UID == "" {
+		return Event{}, false, fmt.Errorf("rfid: bad tag line %q", line)
+	}
+	ev.UID = strings.ToLower(ev.UID)
+	return ev, true, nil
+}
+
+// LineSource emits one event per line read from r; see ParseLine for the
+// format. Point it at stdin to simulate a reader by typing UIDs, or at the tty
+// of a keyboard-wedge USB reader.
+type LineSource struct {
+	r      io.Reader
+	events chan<- Event
+	logger *slog.Logger
+}
+
+// NewLineSource returns a source reading lines from r.
+func NewLineSource(r io.Reader, events chan<- Event, logger *slog.Logger) *LineSource {
+	return &LineSource{r: r, events: events, logger: logger}
+}
+
+// Start reads lines until EOF or ctx is cancelled.
+func (s *LineSource) Start(ctx context.Context) {
+	go func() { _ = scanEvents(ctx, s.r, s.events, s.logger) }()
+}
+
+// Close closes the underlying reader if it is an io.Closer.
+func (s *LineSource) Close() {
+	if c, ok := s.r.(io.Closer); ok {
+		_ = c.Close()
+	}
+}
+
+// scanEvents sends an event for every valid line in r. It returns false if ctx
+// was cancelled before r was exhausted.
+func scanEvents(ctx context.Context, r io.Reader, events chan<- Event, logger *slog.Logger) bool {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		ev, ok, err := ParseLine(sc.Text())
+		if err != nil {
+			logger.Warn("rfid: skip line", "err", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		select {
+		case events <- ev:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	if err := sc.Err(); err != nil {
+		logger.Error("rfid: read lines", "err", err)
+	}
+	return ctx.Err() == nil
+}
@@ -0,0 +1,51 @@
+package rfid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		want   Event
+		ok     bool
+		hasErr bool
+	}{
+		{line: "AABBCCDD", want: Event{UID: "aabbccdd", Kind: Present}, ok: true},
+		{line: "  0012345678\r", want: Event{UID: "0012345678", Kind: Present}, ok: true},
+		{line: "-aabb", want: Event{UID: "aabb", Kind: Removed}, ok: true},
+		{line: "removed aabb", want: Event{UID: "aabb", Kind: Removed}, ok: true},
+		{line: "present aabb", want: Event{UID: "aabb", Kind: Present}, ok: true},
+		{line: ""},
+		{line: "# comment"},
+		{line: "-", hasErr: true},
+		{line: "lifted aabb", hasErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			ev, ok, err := ParseLine(tt.line)
+			if tt.hasErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, ev)
+		})
+	}
+}
+
+func TestLineSource(t *testing.T) {
+	events := make(chan Event, 4)
+	s := NewLineSource(strings.NewReader("aa\nnot a tag line at all\n-aa\n"), events, log.NewNoOpLogger())
+	s.Start(context.Background())
+
+	assert.Equal(t, Event{UID: "aa", Kind: Present}, nextEvent(t, events))
+	assert.Equal(t, Event{UID: "aa", Kind: Removed}, nextEvent(t, events))
+}
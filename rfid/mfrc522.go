@@ -0,0 +1,84 @@
+package rfid
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"periph.io/x/devices/v3/mfrc522"
+	"periph.io/x/devices/v3/mfrc522/commands"
+)
+
+// mfrc522Device adds Type 2 tag memory access to the periph.io MFRC522 driver.
+type mfrc522Device struct {
+	*mfrc522.Dev
+}
+
+// selectTag reads a UID and, for a 7-byte UID tag that answers as Type 2,
+// calls fn while the tag is selected.
+func (d *mfrc522Device) selectTag(timeout time.Duration, fn func(type2Tag)) ([]byte, error) {
+	uid, err := d.ReadUID(timeout)
+	if err != nil || fn == nil || len(uid) != 7 {
+		return uid, err
+	}
+	// The driver runs cascade level 2 anticollision but never selects it,
+	// which leaves 7-byte UID tags unable to take commands.
+	sak, err := d.selectCL2(uid[3:])
+	if err != nil {
+		return uid, err
+	}
+	if sak == 0x00 {
+		fn(mfrc522Tag{ll: d.LowLevel})
+	}
+	return uid, nil
+}
+
+// selectCL2 sends SELECT for cascade level 2 and returns the tag's SAK.
+func (d *mfrc522Device) selectCL2(uid []byte) (byte, error) {
+	buf := []byte{0x95, 0x70, uid[0], uid[1], uid[2], uid[3], uid[0] ^ uid[1] ^ uid[2] ^ uid[3]}
+	resp, err := transceive(d.LowLevel, buf)
+	if err != nil {
+		return 0, fmt.Errorf("mfrc522: select CL2: %w", err)
+	}
+	return resp[0], nil
+}
+
+// mfrc522Tag reaches the memory of the tag selected on an MFRC522.
+type mfrc522Tag struct {
+	ll *commands.LowLevel
+}
+
+func (t mfrc522Tag) readPages(page byte) ([]byte, error) {
+	resp, err := transceive(t.ll, []byte{type2Read, page})
+	if err != nil {
+		return nil, fmt.Errorf("mfrc522: read page %d: %w", page, err)
+	}
+	return resp, nil
+}
+
+func (t mfrc522Tag) writePage(page byte, data []byte) error {
+	resp, err := transceive(t.ll, append([]byte{type2Write, page}, data...))
+	if err != nil {
+		return fmt.Errorf("mfrc522: write page %d: %w", page, err)
+	}
+	if resp[0]&0x0F != 0x0A {
+		return fmt.Errorf("mfrc522: write page %d: NAK %#x", page, resp[0])
+	}
+	return nil
+}
+
+// transceive appends the CRC to cmd, sends it and returns the tag's answer.
+func transceive(ll *commands.LowLevel, cmd []byte) ([]byte, error) {
+	crc, err := ll.CRC(cmd)
+	if err != nil {
+		return nil, err
+	}
+	resp, _, err := ll.CardWrite(commands.PCD_TRANSCEIVE, append(cmd, crc[0], crc[1]))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) == 0 {
+		return nil, errors.New("no answer from tag")
+	}
+	return resp, nil
+}
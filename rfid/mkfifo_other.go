@@ -0,0 +1,7 @@
+//go:build !unix
+
+package rfid
+
+import "errors"
+
+func mkfifo(string) error { return errors.New("named pipes are not supported on this platform") }
@@ -0,0 +1,228 @@
+package rfid
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// NDEF record type name formats (NFC Forum NDEF 1.0 §3.2.6).
+const (
+	TNFEmpty       = 0x00
+	TNFWellKnown   = 0x01
+	TNFMedia       = 0x02
+	TNFAbsoluteURI = 0x03
+	TNFExternal    = 0x04
+)
+
+// NDEF record header flags.
+const (
+	ndefMB = 0x80 // message begin
+	ndefME = 0x40 // message end
+	ndefCF = 0x20 // chunk flag
+	ndefSR = 0x10 // short record
+	ndefIL = 0x08 // ID length present
+)
+
+// Record is one NDEF record.
+type Record struct {
+	TNF     byte
+	Type    []byte
+	ID      []byte
+	Payload []byte
+}
+
+// uriPrefixes is the URI record identifier code table (NFC Forum RTD URI §3.2.2).
+var uriPrefixes = []string{
+	"", "http://www.", "https://www.", "http://", "https://", "tel:", "mailto:",
+	"ftp://anonymous:anonymous@", "ftp://ftp.", "ftps://", "sftp://", "smb://",
+	"nfs://", "ftp://", "dav://", "news:", "telnet://", "imap:", "rtsp://", "urn:",
+	"pop:", "sip:", "sips:", "tftp:", "btspp://", "btl2cap://", "btgoep://",
+	"tcpobex://", "irdaobex://", "file://", "urn:epc:id:", "urn:epc:tag:",
+	"urn:epc:pat:", "urn:epc:raw:", "urn:epc:", "urn:nfc:",
+}
+
+// URI returns the record's URI if it is a well-known URI record or an
+// absolute-URI record.
+func (r Record) URI() (string, bool) {
+	switch {
+	case r.TNF == TNFAbsoluteURI:
+		return string(r.Type), true
+	case r.TNF == TNFWellKnown && string(r.Type) == "U" && len(r.Payload) > 0:
+		prefix := ""
+		if int(r.Payload[0]) < len(uriPrefixes) {
+			prefix = uriPrefixes[r.Payload[0]]
+		}
+		return prefix + string(r.Payload[1:]), true
+	}
+	return "", false
+}
+
+// Text returns the record's text if it is a well-known text record.
+func (r Record) Text() (string, bool) {
+	if r.TNF != TNFWellKnown || string(r.Type) != "T" || len(r.Payload) == 0 {
+		return "", false
+	}
+	status := r.Payload[0]
+	start := 1 + int(status&0x3F) // skip the language code
+	if start > len(r.Payload) {
+		return "", false
+	}
+	text := r.Payload[start:]
+	if status&0x80 == 0 {
+		return string(text), true
+	}
+	// UTF-16, big endian unless a BOM says otherwise.
+	var order binary.ByteOrder = binary.BigEndian
+	if len(text) >= 2 && text[0] == 0xFF && text[1] == 0xFE {
+		order, text = binary.LittleEndian, text[2:]
+	} else if len(text) >= 2 && text[0] == 0xFE && text[1] == 0xFF {
+		text = text[2:]
+	}
+	units := make([]uint16, len(text)/2)
+	for i := range units {
+		units[i] = order.Uint16(text[2*i:])
+	}
+	return string(utf16.Decode(units)), true
+}
+
+// URIRecord returns a well-known URI record for uri, abbreviated with the
+// longest matching prefix code.
+func URIRecord(uri string) Record {
+	code := 0
+	for i, p := range uriPrefixes {
+		if p != "" && strings.HasPrefix(uri, p) && len(p) > len(uriPrefixes[code]) {
+			code = i
+		}
+	}
+	payload := append([]byte{byte(code)}, uri[len(uriPrefixes[code]):]...)
+	return Record{TNF: TNFWellKnown, Type: []byte("U"), Payload: payload}
+}
+
+// TextRecord returns a well-known UTF-8 text record in English.
+func TextRecord(text string) Record {
+	payload := append([]byte{2, 'e', 'n'}, text...)
+	return Record{TNF: TNFWellKnown, Type: []byte("T"), Payload: payload}
+}
+
+// ParseNDEF decodes an NDEF message. Chunked records are not supported.
+func ParseNDEF(msg []byte) ([]Record, error) {
+	var records []Record
+	for len(msg) > 0 {
+		hdr := msg[0]
+		if hdr&ndefCF != 0 {
+			return nil, errors.New("ndef: chunked records are not supported")
+		}
+		p := 1
+		need := func(n int) error {
+			if len(msg) < p+n {
+				return fmt.Errorf("ndef: record %d truncated", len(records))
+			}
+			return nil
+		}
+		if err := need(1); err != nil {
+			return nil, err
+		}
+		typeLen := int(msg[p])
+		p++
+		var payloadLen int
+		if hdr&ndefSR != 0 {
+			if err := need(1); err != nil {
+				return nil, err
+			}
+			payloadLen = int(msg[p])
+			p++
+		} else {
+			if err := need(4); err != nil {
+				return nil, err
+			}
+			n := binary.BigEndian.Uint32(msg[p:])
+			p += 4
+			// Checked before converting: on 32-bit builds a huge length would go negative.
+			if uint64(n) > uint64(len(msg)-p) {
+				return nil, fmt.Errorf("ndef: record %d truncated", len(records))
+			}
+			payloadLen = int(n)
+		}
+		idLen := 0
+		if hdr&ndefIL != 0 {
+			if err := need(1); err != nil {
+				return nil, err
+			}
+			idLen = int(msg[p])
+			p++
+		}
+		if err := need(typeLen + idLen + payloadLen); err != nil {
+			return nil, err
+		}
+		rec := Record{TNF: hdr & 0x07}
+		rec.Type = msg[p : p+typeLen]
+		p += typeLen
+		if idLen > 0 {
+			rec.ID = msg[p : p+idLen]
+			p += idLen
+		}
+		rec.Payload = msg[p : p+payloadLen]
+		p += payloadLen
+		records = append(records, rec)
+		msg = msg[p:]
+		if hdr&ndefME != 0 {
+			break
+		}
+	}
+	return records, nil
+}
+
+// EncodeNDEF encodes records as one NDEF message.
+func EncodeNDEF(records ...Record) []byte {
+	var msg []byte
+	for i, rec := range records {
+		hdr := rec.TNF & 0x07
+		if i == 0 {
+			hdr |= ndefMB
+		}
+		if i == len(records)-1 {
+			hdr |= ndefME
+		}
+		short := len(rec.Payload) < 256
+		if short {
+			hdr |= ndefSR
+		}
+		if len(rec.ID) > 0 {
+			hdr |= ndefIL
+		}
+		msg = append(msg, hdr, byte(len(rec.Type)))
+		if short {
+			msg = append(msg, byte(len(rec.Payload)))
+		} else {
+			msg = binary.BigEndian.AppendUint32(msg, uint32(len(rec.Payload)))
+		}
+		if len(rec.ID) > 0 {
+			msg = append(msg, byte(len(rec.ID)))
+		}
+		msg = append(msg, rec.Type...)
+		msg = append(msg, rec.ID...)
+		msg = append(msg, rec.Payload...)
+	}
+	return msg
+}
+
+// eventContent fills ev.URL and ev.Text from the first URI and text records
+// of an NDEF message.
+func eventContent(ev *Event, msg []byte) error {
+	records, err := ParseNDEF(msg)
+	if err != nil {
+		return err
+	}
+	for _, rec := range records {
+		if uri, ok := rec.URI(); ok && ev.URL == "" {
+			ev.URL = uri
+		}
+		if text, ok := rec.Text(); ok && ev.Text == "" {
+			ev.Text = text
+		}
+	}
+	return nil
+}
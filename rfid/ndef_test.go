@@ -0,0 +1,62 @@
+package rfid
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNDEFURIAndText(t *testing.T) {
+	// As written by a phone app: a URI record followed by an English text record.
+	msg := []byte{
+		0x91, 0x01, 0x0C, 'U', 0x04, 'y', 'o', 'u', 't', 'u', '.', 'b', 'e', '/', 'a', 'b',
+		0x51, 0x01, 0x06, 'T', 0x02, 'e', 'n', 'h', 'i', '!',
+	}
+	records, err := ParseNDEF(msg)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	uri, ok := records[0].URI()
+	require.True(t, ok)
+	assert.Equal(t, "https://youtu.be/ab", uri)
+	_, ok = records[0].Text()
+	assert.False(t, ok)
+
+	text, ok := records[1].Text()
+	require.True(t, ok)
+	assert.Equal(t, "hi!", text)
+}
+
+func TestParseNDEFTruncated(t *testing.T) {
+	_, err := ParseNDEF([]byte{0xD1, 0x01, 0x0C, 'U', 0x04})
+	require.ErrorContains(t, err, "truncated")
+
+	_, err = ParseNDEF([]byte{0xC1, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 'U', 0x04})
+	require.ErrorContains(t, err, "truncated")
+}
+
+func TestTextRecordUTF16(t *testing.T) {
+	rec := Record{TNF: TNFWellKnown, Type: []byte("T"), Payload: []byte{0x82, 'e', 'n', 0xFF, 0xFE, 'o', 0, 'k', 0}}
+	text, ok := rec.Text()
+	require.True(t, ok)
+	assert.Equal(t, "ok", text)
+}
+
+func TestEncodeNDEFRoundTrip(t *testing.T) {
+	long := bytes.Repeat([]byte("a"), 300)
+	in := []Record{
+		URIRecord("https://www.youtube.com/watch?v=abc"),
+		TextRecord("song-id"),
+		{TNF: TNFMedia, Type: []byte("text/plain"), ID: []byte("x"), Payload: long},
+	}
+	assert.Equal(t, byte(0x02), in[0].Payload[0], "https://www. prefix code")
+
+	out, err := ParseNDEF(EncodeNDEF(in...))
+	require.NoError(t, err)
+	assert.Equal(t, in, out)
+
+	uri, _ := out[0].URI()
+	assert.Equal(t, "https://www.youtube.com/watch?v=abc", uri)
+}
@@ -0,0 +1,72 @@
+package rfid
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// PipeSource reads tag lines from a named pipe, reopening it each time the
+// writer closes it, so that `echo aabbccdd > /tmp/rfid` simulates a tap.
+type PipeSource struct {
+	path   string
+	events chan<- Event
+	logger *slog.Logger
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewPipeSource creates the named pipe at path if it does not exist.
+func NewPipeSource(path string, events chan<- Event, logger *slog.Logger) (*PipeSource, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := mkfifo(path); err != nil {
+			return nil, fmt.Errorf("rfid: mkfifo %s: %w", path, err)
+		}
+	}
+	return &PipeSource{path: path, events: events, logger: logger}, nil
+}
+
+// Start reads the pipe until ctx is cancelled.
+func (s *PipeSource) Start(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		s.Close()
+	}()
+	go func() {
+		for ctx.Err() == nil {
+			// Opening a FIFO for reading blocks until a writer opens it.
+			f, err := os.Open(s.path)
+			if err != nil {
+				s.logger.Error("rfid: open pipe", "path", s.path, "err", err)
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Second):
+				}
+				continue
+			}
+			s.mu.Lock()
+			s.file = f
+			s.mu.Unlock()
+			done := !scanEvents(ctx, f, s.events, s.logger)
+			_ = f.Close()
+			if done {
+				return
+			}
+		}
+	}()
+}
+
+// Close closes the pipe if it is open. A Start goroutine blocked waiting for a
+// writer stays blocked until one arrives.
+func (s *PipeSource) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file != nil {
+		_ = s.file.Close()
+		s.file = nil
+	}
+}
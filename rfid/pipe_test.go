@@ -0,0 +1,30 @@
+//go:build unix
+
+package rfid
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipeSourceReopens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rfid")
+	events := make(chan Event, 2)
+	s, err := NewPipeSource(path, events, log.NewNoOpLogger())
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	s.Start(ctx)
+
+	// Each write opens and closes the pipe, like `echo uid > pipe`.
+	for _, uid := range []string{"01", "02"} {
+		require.NoError(t, os.WriteFile(path, []byte(uid+"\n"), 0o600))
+		assert.Equal(t, Event{UID: uid, Kind: Present}, nextEvent(t, events))
+	}
+}
@@ -0,0 +1,212 @@
+package rfid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PN532 host frames (NXP UM0701-02 §6.2).
+const (
+	pn532HostToChip = 0xD4
+	pn532ChipToHost = 0xD5
+	pn532ErrorFrame = 0x7F
+	pn532MaxFrame   = 64 // enough for every reply this driver asks for
+
+	pn532CmdSAMConfiguration    = 0x14
+	pn532CmdRFConfiguration     = 0x32
+	pn532CmdInDataExchange      = 0x40
+	pn532CmdInListPassiveTarget = 0x4A
+	pn532CmdInRelease           = 0x52
+
+	pn532AckTimeout = 100 * time.Millisecond
+	pn532CmdTimeout = time.Second
+)
+
+var (
+	errIncompleteFrame = errors.New("pn532: incomplete frame")
+	errNoTag           = errors.New("pn532: no tag in field")
+)
+
+// pn532Reply is a parsed frame from the chip: an ACK, or the data of an
+// information frame without its TFI byte.
+type pn532Reply struct {
+	ack  bool
+	data []byte
+}
+
+// pn532Frame builds a normal information frame carrying data to the chip.
+func pn532Frame(data ...byte) []byte {
+	n := byte(len(data) + 1)
+	f := []byte{0x00, 0x00, 0xFF, n, -n, pn532HostToChip}
+	sum := byte(pn532HostToChip)
+	for _, b := range data {
+		f = append(f, b)
+		sum += b
+	}
+	return append(f, -sum, 0x00)
+}
+
+// parsePN532Frame decodes the first frame in b and returns how many bytes it
+// used. It returns errIncompleteFrame when b ends before the frame does.
+func parsePN532Frame(b []byte) (pn532Reply, int, error) {
+	start := bytes.Index(b, []byte{0x00, 0xFF})
+	if start < 0 {
+		return pn532Reply{}, 0, errIncompleteFrame
+	}
+	p := b[start+2:]
+	if len(p) < 2 {
+		return pn532Reply{}, 0, errIncompleteFrame
+	}
+	switch {
+	case p[0] == 0x00 && p[1] == 0xFF:
+		return pn532Reply{ack: true}, start + 4, nil
+	case p[0] == 0xFF && p[1] == 0x00:
+		return pn532Reply{}, start + 4, errors.New("pn532: NACK")
+	case p[0] == 0xFF && p[1] == 0xFF:
+		return pn532Reply{}, start + 4, errors.New("pn532: extended frames are not supported")
+	case p[0]+p[1] != 0:
+		return pn532Reply{}, start + 4, errors.New("pn532: bad length checksum")
+	case p[0] == 0:
+		return pn532Reply{}, start + 4, errors.New("pn532: empty frame")
+	}
+	n := int(p[0])
+	if len(p) < 2+n+1 {
+		return pn532Reply{}, 0, errIncompleteFrame
+	}
+	body := p[2 : 2+n]
+	var sum byte
+	for _, c := range p[2 : 2+n+1] {
+		sum += c
+	}
+	used := start + 2 + 2 + n + 1
+	if sum != 0 {
+		return pn532Reply{}, used, errors.New("pn532: bad data checksum")
+	}
+	switch body[0] {
+	case pn532ChipToHost:
+		return pn532Reply{data: body[1:]}, used, nil
+	case pn532ErrorFrame:
+		return pn532Reply{}, used, errors.New("pn532: application error frame")
+	default:
+		return pn532Reply{}, used, fmt.Errorf("pn532: unexpected frame identifier %#x", body[0])
+	}
+}
+
+// pn532Transport carries frames over I2C or UART.
+type pn532Transport interface {
+	write(frame []byte) error
+	// read returns the next frame from the chip, waiting up to timeout.
+	read(timeout time.Duration) (pn532Reply, error)
+}
+
+// PN532 drives an NXP PN532 in ISO14443A reader mode.
+type PN532 struct {
+	t pn532Transport
+}
+
+// newPN532 configures the chip behind t for reading tags.
+func newPN532(t pn532Transport) (*PN532, error) {
+	d := &PN532{t: t}
+	// Normal mode, no virtual card timeout, IRQ line used.
+	if _, err := d.command(pn532CmdTimeout, pn532CmdSAMConfiguration, 0x01, 0x14, 0x01); err != nil {
+		return nil, fmt.Errorf("pn532: SAMConfiguration: %w", err)
+	}
+	// MaxRetries: give up passive activation after a few tries so
+	// InListPassiveTarget returns when the field is empty.
+	if _, err := d.command(pn532CmdTimeout, pn532CmdRFConfiguration, 0x05, 0xFF, 0x01, 0x10); err != nil {
+		return nil, fmt.Errorf("pn532: RFConfiguration: %w", err)
+	}
+	return d, nil
+}
+
+// command sends cmd with params, waits for the ACK and returns the reply data
+// following the response code.
+func (d *PN532) command(timeout time.Duration, cmd byte, params ...byte) ([]byte, error) {
+	if err := d.t.write(pn532Frame(append([]byte{cmd}, params...)...)); err != nil {
+		return nil, err
+	}
+	ack, err := d.t.read(pn532AckTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("read ack: %w", err)
+	}
+	if !ack.ack {
+		return nil, errors.New("pn532: expected ACK")
+	}
+	reply, err := d.t.read(timeout)
+	if err != nil {
+		return nil, err
+	}
+	if reply.ack || len(reply.data) == 0 || reply.data[0] != cmd+1 {
+		return nil, fmt.Errorf("pn532: unexpected reply to %#x: % x", cmd, reply.data)
+	}
+	return reply.data[1:], nil
+}
+
+// ReadUID waits up to timeout for an ISO14443A tag and returns its UID.
+func (d *PN532) ReadUID(timeout time.Duration) ([]byte, error) {
+	return d.selectTag(timeout, nil)
+}
+
+// selectTag is ReadUID that also hands Type 2 tags to fn before releasing them.
+func (d *PN532) selectTag(timeout time.Duration, fn func(type2Tag)) ([]byte, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		resp, err := d.command(pn532CmdTimeout, pn532CmdInListPassiveTarget, 0x01, 0x00)
+		if err != nil {
+			return nil, err
+		}
+		// NbTg, Tg, SENS_RES (2), SEL_RES, NFCIDLength, NFCID1...
+		if len(resp) > 0 && resp[0] > 0 {
+			if len(resp) < 6 || len(resp) < 6+int(resp[5]) {
+				return nil, fmt.Errorf("pn532: short target data: % x", resp)
+			}
+			uid := resp[6 : 6+int(resp[5])]
+			// SEL_RES 0x00: NFC Forum Type 2 (NTAG, MIFARE Ultralight).
+			if fn != nil && resp[4] == 0x00 {
+				fn(pn532Tag{d: d})
+			}
+			// Release the target so the next poll sees it again while present.
+			if _, err := d.command(pn532CmdTimeout, pn532CmdInRelease, 0x00); err != nil {
+				return nil, fmt.Errorf("pn532: InRelease: %w", err)
+			}
+			return uid, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, errNoTag
+		}
+	}
+}
+
+// Halt releases any selected target.
+func (d *PN532) Halt() error {
+	_, err := d.command(pn532CmdTimeout, pn532CmdInRelease, 0x00)
+	return err
+}
+
+// pn532Tag reaches the memory of the target selected by InListPassiveTarget.
+type pn532Tag struct {
+	d *PN532
+}
+
+// exchange sends a tag command to target 1 and returns the tag's answer.
+func (t pn532Tag) exchange(data ...byte) ([]byte, error) {
+	resp, err := t.d.command(pn532CmdTimeout, pn532CmdInDataExchange, append([]byte{0x01}, data...)...)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) == 0 || resp[0]&0x3F != 0 {
+		return nil, fmt.Errorf("pn532: InDataExchange failed: % x", resp)
+	}
+	return resp[1:], nil
+}
+
+func (t pn532Tag) readPages(page byte) ([]byte, error) {
+	return t.exchange(type2Read, page)
+}
+
+func (t pn532Tag) writePage(page byte, data []byte) error {
+	_, err := t.exchange(append([]byte{type2Write, page}, data...)...)
+	return err
+}
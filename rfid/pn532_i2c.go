@@ -0,0 +1,41 @@
+package rfid
+
+import (
+	"errors"
+	"time"
+)
+
+// pn532I2CAddr is the PN532's fixed 7-bit I2C address.
+const pn532I2CAddr = 0x24
+
+// i2cConn is the part of periph's i2c.Dev the transport uses.
+type i2cConn interface {
+	Tx(w, r []byte) error
+}
+
+// pn532I2C reads whole frames from the chip: each read starts with a status
+// byte whose low bit says a frame is ready.
+type pn532I2C struct {
+	conn i2cConn
+	poll time.Duration
+}
+
+func (t *pn532I2C) write(frame []byte) error { return t.conn.Tx(frame, nil) }
+
+func (t *pn532I2C) read(timeout time.Duration) (pn532Reply, error) {
+	deadline := time.Now().Add(timeout)
+	buf := make([]byte, 1+pn532MaxFrame)
+	for {
+		if err := t.conn.Tx(nil, buf); err != nil {
+			return pn532Reply{}, err
+		}
+		if buf[0]&0x01 == 1 {
+			reply, _, err := parsePN532Frame(buf[1:])
+			return reply, err
+		}
+		if time.Now().After(deadline) {
+			return pn532Reply{}, errors.New("pn532: timeout waiting for frame")
+		}
+		time.Sleep(t.poll)
+	}
+}
@@ -0,0 +1,189 @@
+package rfid
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pn532Ack = []byte{0x00, 0x00, 0xFF, 0x00, 0xFF, 0x00}
+
+// chipFrame builds an information frame as the PN532 sends it.
+func chipFrame(data ...byte) []byte {
+	f := pn532Frame(data...)
+	f[5] = pn532ChipToHost
+	f[len(f)-2]-- // TFI went up by one, so the data checksum goes down by one
+	return f
+}
+
+// pn532Script is the chip side of a ReadUID call that finds no tag on the
+// first poll and uid on the second.
+func pn532Script(uid ...byte) [][]byte {
+	target := append([]byte{0x4B, 0x01, 0x01, 0x00, 0x44, 0x00, byte(len(uid))}, uid...)
+	return [][]byte{
+		pn532Ack, chipFrame(0x15),
+		pn532Ack, chipFrame(0x33),
+		pn532Ack, chipFrame(0x4B, 0x00),
+		pn532Ack, chipFrame(target...),
+		pn532Ack, chipFrame(0x53, 0x00),
+	}
+}
+
+func TestPN532Frame(t *testing.T) {
+	assert.Equal(t,
+		[]byte{0x00, 0x00, 0xFF, 0x04, 0xFC, 0xD4, 0x4A, 0x01, 0x00, 0xE1, 0x00},
+		pn532Frame(0x4A, 0x01, 0x00))
+}
+
+func TestParsePN532Frame(t *testing.T) {
+	info := chipFrame(0x03, 0x32, 0x01, 0x06, 0x07)
+	tests := []struct {
+		name  string
+		in    []byte
+		reply pn532Reply
+		used  int
+		err   string
+	}{
+		{name: "ack", in: pn532Ack, reply: pn532Reply{ack: true}, used: 5},
+		{name: "info", in: info, reply: pn532Reply{data: []byte{0x03, 0x32, 0x01, 0x06, 0x07}}, used: len(info) - 1},
+		{name: "leading noise", in: append([]byte{0x55, 0x00}, info...), reply: pn532Reply{data: []byte{0x03, 0x32, 0x01, 0x06, 0x07}}, used: len(info) + 1},
+		{name: "incomplete", in: info[:7], err: errIncompleteFrame.Error()},
+		{name: "truncated length", in: []byte{0x00, 0x00, 0xFF, 0x04}, err: errIncompleteFrame.Error()},
+		{name: "truncated data", in: []byte{0x00, 0x00, 0xFF, 0x02, 0xFE, 0xD5}, err: errIncompleteFrame.Error()},
+		{name: "zero length", in: []byte{0x00, 0x00, 0xFF, 0x00, 0x00, 0x00}, err: "empty frame"},
+		{name: "no start code", in: []byte{0x01, 0x02}, err: errIncompleteFrame.Error()},
+		{name: "nack", in: []byte{0x00, 0x00, 0xFF, 0xFF, 0x00, 0x00}, err: "NACK"},
+		{name: "bad length checksum", in: []byte{0x00, 0x00, 0xFF, 0x03, 0x01, 0xD5, 0x00, 0x00, 0x00}, err: "length checksum"},
+		{name: "bad data checksum", in: []byte{0x00, 0x00, 0xFF, 0x02, 0xFE, 0xD5, 0x15, 0x00, 0x00}, err: "data checksum"},
+		{name: "error frame", in: []byte{0x00, 0x00, 0xFF, 0x01, 0xFF, 0x7F, 0x81, 0x00}, err: "application error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply, used, err := parsePN532Frame(tt.in)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.reply, reply)
+			assert.Equal(t, tt.used, used)
+		})
+	}
+}
+
+// fakeI2C is a PN532 on an I2C bus: each read returns the status byte and the
+// next scripted frame, after one not-ready read.
+type fakeI2C struct {
+	mu      sync.Mutex
+	frames  [][]byte
+	written [][]byte
+	ready   bool
+}
+
+func (b *fakeI2C) Tx(w, r []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if w != nil {
+		b.written = append(b.written, append([]byte(nil), w...))
+	}
+	if r == nil {
+		return nil
+	}
+	clear(r)
+	if !b.ready || len(b.frames) == 0 {
+		b.ready = true
+		return nil
+	}
+	b.ready = false
+	r[0] = 0x01
+	copy(r[1:], b.frames[0])
+	b.frames = b.frames[1:]
+	return nil
+}
+
+func TestPN532OverI2C(t *testing.T) {
+	bus := &fakeI2C{frames: pn532Script(0x04, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66)}
+	d, err := newPN532(&pn532I2C{conn: bus})
+	require.NoError(t, err)
+
+	uid, err := d.ReadUID(time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x04, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66}, uid)
+
+	assert.Equal(t, pn532Frame(0x14, 0x01, 0x14, 0x01), bus.written[0])
+	assert.Equal(t, pn532Frame(0x4A, 0x01, 0x00), bus.written[2])
+	assert.Equal(t, pn532Frame(0x52, 0x00), bus.written[4])
+}
+
+func TestPN532NoTag(t *testing.T) {
+	script := pn532Script()[:6]
+	d, err := newPN532(&pn532I2C{conn: &fakeI2C{frames: script}})
+	require.NoError(t, err)
+
+	_, err = d.ReadUID(0)
+	require.ErrorIs(t, err, errNoTag)
+	assert.True(t, isTimeoutError(err))
+}
+
+// fakeSerial feeds scripted bytes to the UART transport a few at a time, and
+// reports io.EOF when idle like a serial port with a read timeout.
+type fakeSerial struct {
+	mu      sync.Mutex
+	in      bytes.Buffer
+	written bytes.Buffer
+}
+
+func (s *fakeSerial) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.in.Len() == 0 {
+		return 0, io.EOF
+	}
+	return s.in.Read(p[:min(len(p), 3)])
+}
+
+func (s *fakeSerial) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.written.Write(p)
+}
+
+func TestPN532OverUART(t *testing.T) {
+	port := &fakeSerial{}
+	for _, f := range pn532Script(0xDE, 0xAD, 0xBE, 0xEF) {
+		port.in.Write(f)
+	}
+	tr, err := newPN532UART(port)
+	require.NoError(t, err)
+	d, err := newPN532(tr)
+	require.NoError(t, err)
+
+	uid, err := d.ReadUID(time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xDE, 0xAD, 0xBE, 0xEF}, uid)
+	assert.True(t, bytes.HasPrefix(port.written.Bytes(), pn532Wakeup))
+}
+
+func TestPN532ReadsType2Pages(t *testing.T) {
+	script := pn532Script(0x04, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66)
+	pages := append([]byte{0x41, 0x00}, bytes.Repeat([]byte{0xAB}, 16)...)
+	// The page read goes between the target listing and its release.
+	script = append(script[:8:8], pn532Ack, chipFrame(pages...), script[8], script[9])
+	bus := &fakeI2C{frames: script}
+	d, err := newPN532(&pn532I2C{conn: bus})
+	require.NoError(t, err)
+
+	var got []byte
+	_, err = d.selectTag(time.Second, func(tag type2Tag) {
+		got, err = tag.readPages(4)
+		require.NoError(t, err)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{0xAB}, 16), got)
+	assert.Equal(t, pn532Frame(0x40, 0x01, 0x30, 0x04), bus.written[4])
+}
@@ -0,0 +1,55 @@
+package rfid
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// pn532Wakeup takes a PN532 on HSU (UART) out of power-down: 0x55 0x55 then
+// enough zeros to cover the wake-up delay.
+var pn532Wakeup = append([]byte{0x55, 0x55}, make([]byte, 14)...)
+
+// pn532UART reads frames from a byte stream. rw's reads should return
+// io.EOF or 0 bytes when nothing arrives for a short while, as a serial port
+// with VTIME set does.
+type pn532UART struct {
+	rw  io.ReadWriter
+	buf []byte
+}
+
+func newPN532UART(rw io.ReadWriter) (*pn532UART, error) {
+	if _, err := rw.Write(pn532Wakeup); err != nil {
+		return nil, err
+	}
+	return &pn532UART{rw: rw}, nil
+}
+
+func (t *pn532UART) write(frame []byte) error {
+	_, err := t.rw.Write(frame)
+	return err
+}
+
+func (t *pn532UART) read(timeout time.Duration) (pn532Reply, error) {
+	deadline := time.Now().Add(timeout)
+	chunk := make([]byte, pn532MaxFrame)
+	for {
+		reply, n, err := parsePN532Frame(t.buf)
+		if !errors.Is(err, errIncompleteFrame) {
+			if n == 0 {
+				n = len(t.buf)
+			}
+			t.buf = t.buf[n:]
+			return reply, err
+		}
+		if time.Now().After(deadline) {
+			t.buf = nil
+			return pn532Reply{}, errors.New("pn532: timeout waiting for frame")
+		}
+		n, err = t.rw.Read(chunk)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return pn532Reply{}, err
+		}
+		t.buf = append(t.buf, chunk[:n]...)
+	}
+}
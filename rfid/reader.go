@@ -3,15 +3,19 @@ package rfid
 import (
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"periph.io/x/conn/v3/gpio"
 	"periph.io/x/conn/v3/gpio/gpioreg"
-	"periph.io/x/conn/v3/spi"
+	"periph.io/x/conn/v3/i2c"
+	"periph.io/x/conn/v3/i2c/i2creg"
 	"periph.io/x/conn/v3/spi/spireg"
 	"periph.io/x/devices/v3/mfrc522"
 	host "periph.io/x/host/v3"
@@ -23,19 +27,54 @@ const (
 	defaultIRQPin   = "P1_18" // GPIO 24
 )
 
-// Event is emitted by the Reader each time a tag UID is read.
+// Kind says whether an Event reports a tag arriving or leaving.
+type Kind int
+
+const (
+	Present Kind = iota // a tag was read
+	Removed             // a tag reported Present left the field
+)
+
+func (k Kind) String() string {
+	if k == Removed {
+		return "removed"
+	}
+	return "present"
+}
+
+// Event is emitted by the Reader each time a tag UID is read, and when a tag
+// is removed if Config.DetectRemoval is set.
 type Event struct {
-	UID string
+	UID  string
+	Kind Kind
+	// URL and Text are the first URI and text records of the NDEF message on
+	// an NFC Forum Type 2 tag (NTAG, MIFARE Ultralight), if any.
+	URL  string
+	Text string
 }
 
+// Reader chip drivers accepted in Config.Driver.
+const (
+	DriverMFRC522   = "mfrc522"    // MFRC522 (RC522) over SPI
+	DriverPN532I2C  = "pn532-i2c"  // PN532 over I2C
+	DriverPN532UART = "pn532-uart" // PN532 over UART (HSU)
+)
+
 // Config holds all settings for the RFID reader.
 type Config struct {
+	Driver         string // DriverMFRC522 (default), DriverPN532I2C or DriverPN532UART
+	I2CBus         string // PN532 I2C bus name; empty picks the first bus
+	UARTPort       string // PN532 serial port; defaults to /dev/serial0
 	SPIPort        string
 	ResetPin       string
 	IRQPin         string
 	Cooldown       time.Duration
 	PollInterval   time.Duration
 	ReadUIDTimeout time.Duration
+	// DetectRemoval keeps polling a tag after it is read and emits a Removed
+	// event once it is gone, instead of re-emitting it after every cooldown.
+	DetectRemoval bool
+	RemovalGrace  time.Duration // how long a tag may go unread before it counts as removed
 }
 
 func (c *Config) resetPin() string {
@@ -52,6 +91,13 @@ func (c *Config) irqPin() string {
 	return defaultIRQPin
 }
 
+func (c *Config) uartPort() string {
+	if c.UARTPort != "" {
+		return c.UARTPort
+	}
+	return "/dev/serial0"
+}
+
 func (c *Config) cooldown() time.Duration {
 	if c.Cooldown > 0 {
 		return c.Cooldown
@@ -66,6 +112,13 @@ func (c *Config) pollInterval() time.Duration {
 	return 100 * time.Millisecond
 }
 
+func (c *Config) removalGrace() time.Duration {
+	if c.RemovalGrace > 0 {
+		return c.RemovalGrace
+	}
+	return time.Second
+}
+
 func (c *Config) readUIDTimeout() time.Duration {
 	if c.ReadUIDTimeout > 0 {
 		return c.ReadUIDTimeout
@@ -73,69 +126,148 @@ func (c *Config) readUIDTimeout() time.Duration {
 	return 5 * time.Second
 }
 
-// Reader polls an MFRC522 chip over SPI and emits tag UIDs on the events channel.
+// device is the part of a reader chip driver the Reader uses.
+type device interface {
+	ReadUID(timeout time.Duration) ([]byte, error)
+	Halt() error
+}
+
+// Reader polls a reader chip and emits tag UIDs on the events channel.
 // It has no knowledge of songs, players, or databases.
 type Reader struct {
-	rfid   *mfrc522.Dev
-	port   spi.PortCloser
+	rfid   device
+	port   io.Closer
 	cfg    Config
 	ready  atomic.Bool
 	events chan<- Event
 	logger *slog.Logger
+
+	mu      sync.Mutex
+	pending *pendingWrite
+}
+
+// pendingWrite is an NDEF message waiting for a blank tag.
+type pendingWrite struct {
+	msg  []byte
+	done func(uid string, err error)
 }
 
-// New initialises the SPI bus and MFRC522 device.
+// New initialises the bus and reader chip selected by cfg.Driver.
 func New(cfg Config, events chan<- Event, logger *slog.Logger) (*Reader, error) {
 	if _, err := host.Init(); err != nil {
 		return nil, fmt.Errorf("rfid: host init: %w", err)
 	}
 
+	var (
+		dev  device
+		port io.Closer
+		err  error
+	)
+	switch cfg.Driver {
+	case "", DriverMFRC522:
+		dev, port, err = openMFRC522(cfg)
+	case DriverPN532I2C:
+		dev, port, err = openPN532I2C(cfg)
+	case DriverPN532UART:
+		dev, port, err = openPN532UART(cfg)
+	default:
+		err = fmt.Errorf("rfid: unknown driver %q", cfg.Driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reader{rfid: dev, port: port, cfg: cfg, events: events, logger: logger}
+	r.ready.Store(true)
+	logger.Info("RFID ready", "driver", cfg.Driver)
+	return r, nil
+}
+
+// openMFRC522 opens an MFRC522 on the SPI port.
+func openMFRC522(cfg Config) (device, io.Closer, error) {
 	port, err := spireg.Open(cfg.SPIPort)
 	if err != nil {
-		return nil, fmt.Errorf("rfid: spi open: %w", err)
+		return nil, nil, fmt.Errorf("rfid: spi open: %w", err)
 	}
 
 	var gpioReset gpio.PinOut = gpioreg.ByName(cfg.resetPin())
 	if gpioReset == nil {
 		_ = port.Close()
-		return nil, fmt.Errorf("rfid: reset pin %q not found", cfg.resetPin())
+		return nil, nil, fmt.Errorf("rfid: reset pin %q not found", cfg.resetPin())
 	}
 
 	var gpioIRQ gpio.PinIn = gpioreg.ByName(cfg.irqPin())
 	if gpioIRQ == nil {
 		_ = port.Close()
-		return nil, fmt.Errorf("rfid: IRQ pin %q not found", cfg.irqPin())
+		return nil, nil, fmt.Errorf("rfid: IRQ pin %q not found", cfg.irqPin())
 	}
 
 	dev, err := mfrc522.NewSPI(port, gpioReset, gpioIRQ, mfrc522.WithSync())
 	if err != nil {
 		_ = port.Close()
-		return nil, fmt.Errorf("rfid: mfrc522 init: %w", err)
+		return nil, nil, fmt.Errorf("rfid: mfrc522 init: %w", err)
 	}
 	if err := dev.SetAntennaGain(5); err != nil {
 		_ = port.Close()
-		return nil, fmt.Errorf("rfid: set antenna gain: %w", err)
+		return nil, nil, fmt.Errorf("rfid: set antenna gain: %w", err)
 	}
+	return &mfrc522Device{Dev: dev}, port, nil
+}
 
-	r := &Reader{rfid: dev, port: port, cfg: cfg, events: events, logger: logger}
-	r.ready.Store(true)
-	logger.Info("RFID ready")
-	return r, nil
+// openPN532I2C opens a PN532 on an I2C bus.
+func openPN532I2C(cfg Config) (device, io.Closer, error) {
+	bus, err := i2creg.Open(cfg.I2CBus)
+	if err != nil {
+		return nil, nil, fmt.Errorf("rfid: i2c open: %w", err)
+	}
+	conn := &i2c.Dev{Bus: bus, Addr: pn532I2CAddr}
+	dev, err := newPN532(&pn532I2C{conn: conn, poll: 5 * time.Millisecond})
+	if err != nil {
+		_ = bus.Close()
+		return nil, nil, err
+	}
+	return dev, bus, nil
+}
+
+// openPN532UART opens a PN532 on a serial port.
+func openPN532UART(cfg Config) (device, io.Closer, error) {
+	port, err := openSerial(cfg.uartPort())
+	if err != nil {
+		return nil, nil, fmt.Errorf("rfid: %w", err)
+	}
+	t, err := newPN532UART(port)
+	if err != nil {
+		_ = port.Close()
+		return nil, nil, fmt.Errorf("rfid: pn532 wake-up: %w", err)
+	}
+	dev, err := newPN532(t)
+	if err != nil {
+		_ = port.Close()
+		return nil, nil, err
+	}
+	return dev, port, nil
 }
 
 // Start launches the polling goroutine. It exits when ctx is cancelled.
 func (r *Reader) Start(ctx context.Context) {
 	go func() {
 		for {
-			uid := r.readID(ctx)
-			if uid == "" {
+			ev, ok := r.readTag(ctx)
+			if !ok {
 				return
 			}
 
-			if !r.emitEvent(ctx, uid) {
+			if !r.emitEvent(ctx, ev) {
 				return
 			}
 
+			if r.cfg.DetectRemoval {
+				if !r.watchRemoval(ctx, ev.UID) {
+					return
+				}
+				continue
+			}
+
 			select {
 			case <-time.After(r.cfg.cooldown()):
 			case <-ctx.Done():
@@ -145,19 +277,54 @@ func (r *Reader) Start(ctx context.Context) {
 	}()
 }
 
-func (r *Reader) emitEvent(ctx context.Context, uid string) bool {
+func (r *Reader) emitEvent(ctx context.Context, ev Event) bool {
 	select {
-	case r.events <- Event{UID: uid}:
+	case r.events <- ev:
 		return true
 	case <-ctx.Done():
 		return false
 	}
 }
 
-// readID blocks until one UID is read or ctx is cancelled.
-// Returns "" on cancellation.
-func (r *Reader) readID(ctx context.Context) string {
-	cb := make(chan []byte, 1)
+// watchRemoval keeps reading the tag uid until it has gone unread for the
+// removal grace period, or another tag is read, then emits Removed for it.
+// Returns false on cancellation.
+func (r *Reader) watchRemoval(ctx context.Context, uid string) bool {
+	poll := r.cfg.pollInterval()
+	grace := r.cfg.removalGrace()
+	lastSeen := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(poll):
+		}
+		if !r.ready.Load() {
+			continue
+		}
+		data, err := r.rfid.ReadUID(poll)
+		switch {
+		case err == nil && hex.EncodeToString(data) == uid:
+			lastSeen = time.Now()
+			continue
+		case err == nil:
+			// A different tag replaced this one without a gap.
+		case !isTimeoutError(err):
+			r.logger.Error("ReadUID error", "err", err)
+			fallthrough
+		default:
+			if time.Since(lastSeen) < grace {
+				continue
+			}
+		}
+		return r.emitEvent(ctx, Event{UID: uid, Kind: Removed})
+	}
+}
+
+// readTag blocks until one tag is read or ctx is cancelled.
+// Returns false on cancellation.
+func (r *Reader) readTag(ctx context.Context) (Event, bool) {
+	cb := make(chan Event, 1)
 	inner, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -174,9 +341,9 @@ func (r *Reader) readID(ctx context.Context) string {
 				time.Sleep(poll)
 				continue
 			}
-			data, err := r.rfid.ReadUID(timeout)
-			if len(data) > 0 {
-				r.logger.Info("ReadUID", "hex", hex.EncodeToString(data))
+			ev, err := r.read(timeout)
+			if ev.UID != "" {
+				r.logger.Info("ReadUID", "hex", ev.UID, "url", ev.URL, "text", ev.Text)
 			}
 			if err != nil {
 				if !isTimeoutError(err) {
@@ -186,7 +353,7 @@ func (r *Reader) readID(ctx context.Context) string {
 				continue
 			}
 			select {
-			case cb <- data:
+			case cb <- ev:
 			case <-inner.Done():
 			}
 			return
@@ -194,18 +361,88 @@ func (r *Reader) readID(ctx context.Context) string {
 	}()
 
 	select {
-	case data := <-cb:
-		return hex.EncodeToString(data)
+	case ev := <-cb:
+		return ev, true
 	case <-ctx.Done():
-		return ""
+		return Event{}, false
 	}
 }
 
+// read reads one tag, with its NDEF content when the driver can reach tag
+// memory. A pending write is stored on the tag first if it is blank.
+func (r *Reader) read(timeout time.Duration) (Event, error) {
+	dev, ok := r.rfid.(type2Device)
+	if !ok {
+		uid, err := r.rfid.ReadUID(timeout)
+		return Event{UID: hex.EncodeToString(uid), Kind: Present}, err
+	}
+
+	ev := Event{Kind: Present}
+	var (
+		written *pendingWrite
+		wErr    error
+	)
+	uid, err := dev.selectTag(timeout, func(tag type2Tag) {
+		msg, err := readNDEF(tag)
+		if errors.Is(err, errNotNDEF) {
+			return
+		}
+		if err != nil {
+			r.logger.Warn("rfid: read NDEF", "err", err)
+			return
+		}
+		if len(msg) == 0 {
+			if written = r.takePending(); written != nil {
+				if wErr = writeNDEF(tag, written.msg); wErr == nil {
+					msg = written.msg
+				}
+			}
+		}
+		if err := eventContent(&ev, msg); err != nil {
+			r.logger.Warn("rfid: parse NDEF", "err", err)
+		}
+	})
+	ev.UID = hex.EncodeToString(uid)
+	if written != nil {
+		if wErr == nil {
+			wErr = err
+		}
+		written.done(ev.UID, wErr)
+	}
+	return ev, err
+}
+
+// WriteNDEF stores msg on the next blank NDEF formatted tag that is read, then
+// calls done with its UID. The tag is reported as read with the new content.
+// A later call replaces a write still waiting for a tag.
+func (r *Reader) WriteNDEF(msg []byte, done func(uid string, err error)) error {
+	if _, ok := r.rfid.(type2Device); !ok {
+		return ErrWriteUnsupported
+	}
+	r.mu.Lock()
+	old := r.pending
+	r.pending = &pendingWrite{msg: msg, done: done}
+	r.mu.Unlock()
+	if old != nil {
+		old.done("", errors.New("rfid: replaced by a newer write"))
+	}
+	return nil
+}
+
+func (r *Reader) takePending() *pendingWrite {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	w := r.pending
+	r.pending = nil
+	return w
+}
+
 func isTimeoutError(err error) bool {
-	return err != nil && strings.Contains(err.Error(), "timeout waiting for IRQ")
+	return errors.Is(err, errNoTag) ||
+		err != nil && strings.Contains(err.Error(), "timeout waiting for IRQ")
 }
 
-// Close halts the device and closes the SPI port.
+// Close halts the device and closes its port.
 func (r *Reader) Close() {
 	r.ready.Store(false)
 	if err := r.rfid.Halt(); err != nil {
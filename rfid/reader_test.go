@@ -2,13 +2,60 @@ package rfid
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/jaredwarren/rpi_music/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// fakeDevice returns scripted UIDs from ReadUID; a nil entry, or running out, is a timeout.
+type fakeDevice struct {
+	mu    sync.Mutex
+	reads [][]byte
+}
+
+func (d *fakeDevice) ReadUID(time.Duration) ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.reads) == 0 {
+		return nil, errors.New("mfrc522: timeout waiting for IRQ")
+	}
+	data := d.reads[0]
+	d.reads = d.reads[1:]
+	if data == nil {
+		return nil, errors.New("mfrc522: timeout waiting for IRQ")
+	}
+	return data, nil
+}
+
+func (d *fakeDevice) Halt() error { return nil }
+
+func startFakeReader(t *testing.T, cfg Config, reads ...[]byte) <-chan Event {
+	t.Helper()
+	events := make(chan Event, 4)
+	r := &Reader{rfid: &fakeDevice{reads: reads}, cfg: cfg, events: events, logger: log.NewNoOpLogger()}
+	r.ready.Store(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	r.Start(ctx)
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("expected RFID event on channel")
+		return Event{}
+	}
+}
+
 func TestEmitEventSendsUID(t *testing.T) {
 	events := make(chan Event, 1)
 	r := &Reader{
@@ -16,7 +63,7 @@ func TestEmitEventSendsUID(t *testing.T) {
 		logger: log.NewNoOpLogger(),
 	}
 
-	ok := r.emitEvent(context.Background(), "AABBCCDD")
+	ok := r.emitEvent(context.Background(), Event{UID: "AABBCCDD"})
 	assert.True(t, ok)
 
 	select {
@@ -37,6 +84,104 @@ func TestEmitEventReturnsFalseWhenContextDone(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	ok := r.emitEvent(ctx, "AABBCCDD")
+	ok := r.emitEvent(ctx, Event{UID: "AABBCCDD"})
 	assert.False(t, ok)
 }
+
+func TestReaderDetectsRemoval(t *testing.T) {
+	tag := []byte{0xaa, 0xbb}
+	events := startFakeReader(t, Config{
+		PollInterval:  time.Millisecond,
+		DetectRemoval: true,
+		RemovalGrace:  20 * time.Millisecond,
+	}, tag, tag, nil, tag, tag)
+
+	assert.Equal(t, Event{UID: "aabb", Kind: Present}, nextEvent(t, events))
+	// The single missed read inside the grace period is not a removal.
+	assert.Equal(t, Event{UID: "aabb", Kind: Removed}, nextEvent(t, events))
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected event %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestReaderReportsSwappedTag(t *testing.T) {
+	events := startFakeReader(t, Config{
+		PollInterval:  time.Millisecond,
+		DetectRemoval: true,
+		RemovalGrace:  time.Hour,
+	}, []byte{0x01}, []byte{0x01}, []byte{0x02}, []byte{0x02})
+
+	require.Equal(t, Event{UID: "01", Kind: Present}, nextEvent(t, events))
+	require.Equal(t, Event{UID: "01", Kind: Removed}, nextEvent(t, events))
+	require.Equal(t, Event{UID: "02", Kind: Present}, nextEvent(t, events))
+}
+
+func TestReaderWithoutRemovalDetection(t *testing.T) {
+	events := startFakeReader(t, Config{PollInterval: time.Millisecond, Cooldown: time.Millisecond},
+		[]byte{0x01}, nil, []byte{0x01})
+
+	assert.Equal(t, Event{UID: "01", Kind: Present}, nextEvent(t, events))
+	assert.Equal(t, Event{UID: "01", Kind: Present}, nextEvent(t, events))
+}
+
+// fakeNFCDevice is a fakeDevice whose tags are all Type 2 tags sharing tag's memory.
+type fakeNFCDevice struct {
+	fakeDevice
+	tag *fakeTag
+}
+
+func (d *fakeNFCDevice) selectTag(timeout time.Duration, fn func(type2Tag)) ([]byte, error) {
+	uid, err := d.ReadUID(timeout)
+	if err == nil {
+		fn(d.tag)
+	}
+	return uid, err
+}
+
+func TestReaderReadsNDEF(t *testing.T) {
+	msg := EncodeNDEF(URIRecord("https://youtu.be/abc"), TextRecord("hello"))
+	dev := &fakeNFCDevice{
+		fakeDevice: fakeDevice{reads: [][]byte{{0x04, 0x01}}},
+		tag:        newFakeTag(append([]byte{tlvNDEF, byte(len(msg))}, msg...)...),
+	}
+	events := make(chan Event, 1)
+	r := &Reader{rfid: dev, events: events, logger: log.NewNoOpLogger()}
+	r.ready.Store(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	r.Start(ctx)
+
+	assert.Equal(t, Event{UID: "0401", Kind: Present, URL: "https://youtu.be/abc", Text: "hello"}, nextEvent(t, events))
+}
+
+func TestReaderWritesBlankTag(t *testing.T) {
+	dev := &fakeNFCDevice{
+		fakeDevice: fakeDevice{reads: [][]byte{{0x04, 0x02}}},
+		tag:        newFakeTag(tlvNDEF, 0x00, tlvTerminator),
+	}
+	events := make(chan Event, 1)
+	r := &Reader{rfid: dev, events: events, logger: log.NewNoOpLogger()}
+	r.ready.Store(true)
+
+	done := make(chan string, 1)
+	require.NoError(t, r.WriteNDEF(EncodeNDEF(TextRecord("song-1")), func(uid string, err error) {
+		assert.NoError(t, err)
+		done <- uid
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	r.Start(ctx)
+
+	assert.Equal(t, Event{UID: "0402", Kind: Present, Text: "song-1"}, nextEvent(t, events))
+	assert.Equal(t, "0402", <-done)
+	msg, err := readNDEF(dev.tag)
+	require.NoError(t, err)
+	assert.Equal(t, EncodeNDEF(TextRecord("song-1")), msg)
+}
+
+func TestWriteNDEFUnsupported(t *testing.T) {
+	r := &Reader{rfid: &fakeDevice{}}
+	require.ErrorIs(t, r.WriteNDEF(nil, nil), ErrWriteUnsupported)
+}
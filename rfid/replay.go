@@ -0,0 +1,77 @@
+package rfid
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Step is one scripted event, sent After the previous step.
+type Step struct {
+	After time.Duration
+	Event Event
+}
+
+// ParseScript reads a replay script with one step per line: a delay followed
+// by an event in the ParseLine format, e.g. "1s aabbccdd" or
+// "30s removed aabbccdd". Blank lines and # comments are skipped.
+func ParseScript(r io.Reader) ([]Step, error) {
+	var steps []Step
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		delay, rest, _ := strings.Cut(line, " ")
+		after, err := time.ParseDuration(delay)
+		if err != nil {
+			return nil, fmt.Errorf("rfid: script line %d: %w", n, err)
+		}
+		ev, ok, err := ParseLine(rest)
+		if err != nil || !ok {
+			return nil, fmt.Errorf("rfid: script line %d: missing tag", n)
+		}
+		steps = append(steps, Step{After: after, Event: ev})
+	}
+	return steps, sc.Err()
+}
+
+// ReplaySource plays back a fixed list of steps, for tests and demos.
+type ReplaySource struct {
+	steps  []Step
+	events chan<- Event
+	done   chan struct{}
+}
+
+// NewReplaySource returns a source that sends steps in order once started.
+func NewReplaySource(steps []Step, events chan<- Event) *ReplaySource {
+	return &ReplaySource{steps: steps, events: events, done: make(chan struct{})}
+}
+
+// Start sends the steps in the background.
+func (s *ReplaySource) Start(ctx context.Context) {
+	go func() {
+		defer close(s.done)
+		for _, step := range s.steps {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(step.After):
+			}
+			select {
+			case s.events <- step.Event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Done is closed once every step has been sent or the source was cancelled.
+func (s *ReplaySource) Done() <-chan struct{} { return s.done }
+
+func (s *ReplaySource) Close() {}
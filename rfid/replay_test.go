@@ -0,0 +1,38 @@
+package rfid
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScript(t *testing.T) {
+	steps, err := ParseScript(strings.NewReader("# bedtime\n0s aabb\n\n1m30s removed aabb\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []Step{
+		{Event: Event{UID: "aabb", Kind: Present}},
+		{After: 90 * time.Second, Event: Event{UID: "aabb", Kind: Removed}},
+	}, steps)
+
+	_, err = ParseScript(strings.NewReader("soon aabb"))
+	require.Error(t, err)
+	_, err = ParseScript(strings.NewReader("1s"))
+	require.Error(t, err)
+}
+
+func TestReplaySource(t *testing.T) {
+	events := make(chan Event, 2)
+	s := NewReplaySource([]Step{
+		{Event: Event{UID: "01"}},
+		{After: time.Millisecond, Event: Event{UID: "01", Kind: Removed}},
+	}, events)
+	s.Start(context.Background())
+
+	<-s.Done()
+	assert.Equal(t, Event{UID: "01", Kind: Present}, <-events)
+	assert.Equal(t, Event{UID: "01", Kind: Removed}, <-events)
+}
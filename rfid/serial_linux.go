@@ -0,0 +1,29 @@
+package rfid
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// openSerial opens a serial port at 115200 8N1 in raw mode. Reads return
+// after 100ms without data.
+func openSerial(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	t := syscall.Termios{
+		Cflag:  syscall.CS8 | syscall.CREAD | syscall.CLOCAL | syscall.B115200,
+		Ispeed: syscall.B115200,
+		Ospeed: syscall.B115200,
+	}
+	t.Cc[syscall.VMIN] = 0
+	t.Cc[syscall.VTIME] = 1
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TCSETS, uintptr(unsafe.Pointer(&t))); errno != 0 {
+		_ = f.Close()
+		return nil, fmt.Errorf("configure %s: %w", path, errno)
+	}
+	return f, nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package rfid
+
+import (
+	"errors"
+	"os"
+)
+
+func openSerial(string) (*os.File, error) {
+	return nil, errors.New("serial ports are only supported on linux")
+}
@@ -0,0 +1,21 @@
+package rfid
+
+import "context"
+
+// TagSource produces tag Events on the channel it was constructed with.
+// The MFRC522 Reader is one; the others let the RFID flow run on machines
+// without the SPI hardware and in tests.
+type TagSource interface {
+	// Start begins emitting events in the background until ctx is cancelled.
+	Start(ctx context.Context)
+	// Close releases the underlying device or file.
+	Close()
+}
+
+var (
+	_ TagSource = (*Reader)(nil)
+	_ TagSource = (*LineSource)(nil)
+	_ TagSource = (*PipeSource)(nil)
+	_ TagSource = (*EvdevSource)(nil)
+	_ TagSource = (*ReplaySource)(nil)
+)
@@ -0,0 +1,147 @@
+package rfid
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// NFC Forum Type 2 tag layout and commands (NTAG21x, MIFARE Ultralight).
+const (
+	type2Read     = 0x30 // READ: 16 bytes from four pages
+	type2Write    = 0xA2 // WRITE: one 4-byte page
+	type2CCPage   = 3    // capability container
+	type2DataPage = 4    // first page of the data area
+	type2Magic    = 0xE1 // CC byte 0 of an NDEF formatted tag
+
+	tlvNull       = 0x00
+	tlvNDEF       = 0x03
+	tlvTerminator = 0xFE
+)
+
+var (
+	// ErrWriteUnsupported is returned by Reader.WriteNDEF when the reader
+	// chip driver cannot access tag memory.
+	ErrWriteUnsupported = errors.New("rfid: reader cannot write tags")
+
+	errNotNDEF   = errors.New("rfid: tag is not NDEF formatted")
+	errShortTLV  = errors.New("rfid: TLV continues past data read")
+	errReadOnly  = errors.New("rfid: tag is read-only")
+	errTagTooBig = errors.New("rfid: NDEF message does not fit on tag")
+)
+
+// type2Tag is the memory of the Type 2 tag a driver has selected.
+type type2Tag interface {
+	readPages(page byte) ([]byte, error)    // 16 bytes, four pages from page
+	writePage(page byte, data []byte) error // one 4-byte page
+}
+
+// type2Device is a driver that can keep a tag selected to access its memory.
+type type2Device interface {
+	device
+	// selectTag reads a UID like ReadUID and, if the tag is a Type 2 tag,
+	// calls fn while it is still selected.
+	selectTag(timeout time.Duration, fn func(type2Tag)) ([]byte, error)
+}
+
+// readCC returns the size of the tag's data area from its capability container.
+func readCC(tag type2Tag, write bool) (int, error) {
+	cc, err := tag.readPages(type2CCPage)
+	if err != nil {
+		return 0, fmt.Errorf("rfid: read CC: %w", err)
+	}
+	if len(cc) < 4 || cc[0] != type2Magic {
+		return 0, errNotNDEF
+	}
+	if write && cc[3]&0x0F != 0 {
+		return 0, errReadOnly
+	}
+	return int(cc[2]) * 8, nil
+}
+
+// readNDEF returns the NDEF message stored on tag, reading only as many pages
+// as it needs. An empty message means the tag is formatted but blank.
+func readNDEF(tag type2Tag) ([]byte, error) {
+	size, err := readCC(tag, false)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	for {
+		msg, err := findNDEFTLV(data)
+		if !errors.Is(err, errShortTLV) {
+			return msg, err
+		}
+		if len(data) >= size {
+			return nil, errors.New("rfid: NDEF TLV runs past the data area")
+		}
+		b, err := tag.readPages(byte(type2DataPage + len(data)/4))
+		if err != nil {
+			return nil, fmt.Errorf("rfid: read data: %w", err)
+		}
+		if len(b) < 16 {
+			return nil, fmt.Errorf("rfid: short read of %d bytes", len(b))
+		}
+		data = append(data, b[:16]...)
+	}
+}
+
+// findNDEFTLV returns the value of the first NDEF TLV in a Type 2 data area,
+// or nil if the area ends without one.
+func findNDEFTLV(data []byte) ([]byte, error) {
+	for i := 0; i < len(data); {
+		switch data[i] {
+		case tlvNull:
+			i++
+			continue
+		case tlvTerminator:
+			return nil, nil
+		}
+		if i+1 >= len(data) {
+			return nil, errShortTLV
+		}
+		n, hdr := int(data[i+1]), 2
+		if n == 0xFF {
+			if i+3 >= len(data) {
+				return nil, errShortTLV
+			}
+			n, hdr = int(binary.BigEndian.Uint16(data[i+2:])), 4
+		}
+		if i+hdr+n > len(data) {
+			return nil, errShortTLV
+		}
+		if data[i] == tlvNDEF {
+			return data[i+hdr : i+hdr+n], nil
+		}
+		i += hdr + n
+	}
+	return nil, errShortTLV
+}
+
+// writeNDEF stores msg as the only TLV in the tag's data area.
+func writeNDEF(tag type2Tag, msg []byte) error {
+	size, err := readCC(tag, true)
+	if err != nil {
+		return err
+	}
+	tlv := []byte{tlvNDEF}
+	if len(msg) < 0xFF {
+		tlv = append(tlv, byte(len(msg)))
+	} else {
+		tlv = binary.BigEndian.AppendUint16(append(tlv, 0xFF), uint16(len(msg)))
+	}
+	tlv = append(append(tlv, msg...), tlvTerminator)
+	for len(tlv)%4 != 0 {
+		tlv = append(tlv, tlvNull)
+	}
+	if len(tlv) > size {
+		return errTagTooBig
+	}
+	for i := 0; i < len(tlv); i += 4 {
+		if err := tag.writePage(byte(type2DataPage+i/4), tlv[i:i+4]); err != nil {
+			return fmt.Errorf("rfid: write page %d: %w", type2DataPage+i/4, err)
+		}
+	}
+	return nil
+}
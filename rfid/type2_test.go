@@ -0,0 +1,71 @@
+package rfid
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTag is Type 2 tag memory; it counts page reads.
+type fakeTag struct {
+	mem   []byte
+	reads int
+}
+
+// newFakeTag returns an NTAG213-sized tag whose data area starts with data.
+func newFakeTag(data ...byte) *fakeTag {
+	t := &fakeTag{mem: make([]byte, 45*4)}
+	copy(t.mem[type2CCPage*4:], []byte{type2Magic, 0x10, 0x12, 0x00})
+	copy(t.mem[type2DataPage*4:], data)
+	return t
+}
+
+func (t *fakeTag) readPages(page byte) ([]byte, error) {
+	t.reads++
+	out := make([]byte, 16)
+	copy(out, t.mem[int(page)*4:])
+	return out, nil
+}
+
+func (t *fakeTag) writePage(page byte, data []byte) error {
+	copy(t.mem[int(page)*4:int(page)*4+4], data)
+	return nil
+}
+
+func TestReadNDEF(t *testing.T) {
+	msg := EncodeNDEF(URIRecord("https://youtu.be/abc"))
+	// A lock control TLV before the NDEF TLV, as on NTAG216.
+	tag := newFakeTag(append([]byte{0x01, 0x03, 0xA0, 0x10, 0x44, tlvNDEF, byte(len(msg))}, append(msg, tlvTerminator)...)...)
+
+	got, err := readNDEF(tag)
+	require.NoError(t, err)
+	assert.Equal(t, msg, got)
+	assert.Equal(t, 3, tag.reads, "CC and only the data pages needed")
+}
+
+func TestReadNDEFBlankAndUnformatted(t *testing.T) {
+	msg, err := readNDEF(newFakeTag(tlvNDEF, 0x00, tlvTerminator))
+	require.NoError(t, err)
+	assert.Empty(t, msg)
+
+	tag := newFakeTag()
+	tag.mem[type2CCPage*4] = 0
+	_, err = readNDEF(tag)
+	require.ErrorIs(t, err, errNotNDEF)
+}
+
+func TestWriteNDEF(t *testing.T) {
+	tag := newFakeTag(tlvNDEF, 0x00, tlvTerminator)
+	msg := EncodeNDEF(TextRecord("3f2a9c"))
+	require.NoError(t, writeNDEF(tag, msg))
+
+	got, err := readNDEF(tag)
+	require.NoError(t, err)
+	assert.Equal(t, msg, got)
+
+	require.ErrorIs(t, writeNDEF(tag, make([]byte, 200)), errTagTooBig)
+
+	tag.mem[type2CCPage*4+3] = 0x0F
+	require.ErrorIs(t, writeNDEF(tag, msg), errReadOnly)
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// apiPrefix is where the versioned JSON API is served.
+const apiPrefix = "/api/v1"
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// apiHandler is an /api/v1 handler. A returned error is written as an
+// apiError with the status code of its HTTPError, or 500.
+type apiHandler func(w http.ResponseWriter, r *http.Request) error
+
+// apiError is the envelope every /api/v1 error is returned in.
+type apiError struct {
+	Error apiErrorBody `json:"error"`
+}
+
+type apiErrorBody struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// page is one page of a list response.
+type page[T any] struct {
+	Items  []T `json:"items"`
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+func (s *Server) registerAPIRoutes(mux *http.ServeMux) {
+	handle := func(pattern string, h apiHandler) {
+		method, path, _ := strings.Cut(pattern, " ")
+		mux.HandleFunc(method+" "+apiPrefix+path, s.api(h))
+	}
+
+	// Songs
+	handle("GET /songs", s.apiListSongs)
+	handle("POST /songs", s.apiCreateSong)
+	handle("GET /songs/{song_id}", s.apiGetSong)
+	handle("PATCH /songs/{song_id}", s.apiPatchSong)
+	handle("DELETE /songs/{song_id}", s.apiDeleteSong)
+
+	// Cards
+	handle("GET /cards", s.apiListCards)
+	handle("GET /cards/{rfid}", s.apiGetCard)
+	handle("PUT /cards/{rfid}/songs/{song_id}", s.apiAssignCardSong)
+	handle("DELETE /cards/{rfid}/songs/{song_id}", s.apiUnassignCardSong)
+	handle("PUT /cards/{rfid}/playback", s.apiSetCardPlayback)
+	handle("POST /cards/{rfid}/play", s.apiPlayCard)
+
+	// Player
+	handle("GET /player", s.apiPlayerStatus)
+	handle("POST /player/{action}", s.apiPlayerCommand)
+
+	// Config
+	handle("GET /config", s.apiGetConfig)
+	handle("PATCH /config", s.apiPatchConfig)
+
+	// Download jobs
+	handle("GET /jobs", s.apiListJobs)
+	handle("GET /jobs/{job_id}", s.apiGetJob)
+	handle("POST /jobs/{job_id}/cancel", s.apiCancelJob)
+	handle("POST /jobs/{job_id}/retry", s.apiRetryJob)
+
+	// Anything else under the prefix is a JSON 404 rather than the song list.
+	mux.HandleFunc(apiPrefix+"/", s.api(func(w http.ResponseWriter, r *http.Request) error {
+		return asHTTPError(http.StatusNotFound, fmt.Errorf("no such endpoint: %s %s", r.Method, r.URL.Path))
+	}))
+}
+
+// api adapts h to http.HandlerFunc, writing its error as an apiError.
+func (s *Server) api(h apiHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := h(w, r)
+		if err == nil {
+			return
+		}
+		code := http.StatusInternalServerError
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) {
+			code = httpErr.Code
+		}
+		if code >= 500 {
+			s.logger.Error("api", "path", r.URL.Path, "err", err)
+		} else {
+			s.logger.Warn("api", "path", r.URL.Path, "err", err)
+		}
+		writeAPIJSON(w, code, apiError{Error: apiErrorBody{Status: code, Message: err.Error()}})
+	}
+}
+
+// writeAPIJSON writes v as JSON with the given status code.
+func writeAPIJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// decodeAPIBody reads a JSON request body into v, rejecting unknown fields.
+// An empty body leaves v unchanged.
+func decodeAPIBody(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return asHTTPError(http.StatusBadRequest, fmt.Errorf("invalid JSON body: %w", err))
+	}
+	return nil
+}
+
+// paginate returns the page of items selected by the "limit" and "offset"
+// query parameters.
+func paginate[T any](r *http.Request, items []T) (page[T], error) {
+	p := page[T]{Total: len(items), Limit: defaultPageLimit}
+	q := r.URL.Query()
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return p, asHTTPError(http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", maxPageLimit))
+		}
+		p.Limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return p, asHTTPError(http.StatusBadRequest, errors.New("offset must be 0 or more"))
+		}
+		p.Offset = n
+	}
+	start := min(p.Offset, len(items))
+	end := min(start+p.Limit, len(items))
+	p.Items = items[start:end]
+	if p.Items == nil {
+		p.Items = []T{}
+	}
+	return p, nil
+}
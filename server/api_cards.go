@@ -0,0 +1,186 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/jaredwarren/rpi_music/player"
+)
+
+// playbackRequest is the body of PUT /api/v1/cards/{rfid}/playback, and how
+// cards report their playback options.
+type playbackRequest struct {
+	Shuffle bool   `json:"shuffle"`
+	Loop    string `json:"loop"`
+}
+
+// apiCard is a song card as the API returns it.
+type apiCard struct {
+	RFID     string          `json:"rfid"`
+	Songs    []string        `json:"songs"`
+	Playback playbackRequest `json:"playback"`
+}
+
+func newAPICard(card *model.RFIDSong) *apiCard {
+	songs := card.Songs
+	if songs == nil {
+		songs = []string{}
+	}
+	return &apiCard{
+		RFID:     card.RFID,
+		Songs:    songs,
+		Playback: newPlaybackRequest(card.Playback),
+	}
+}
+
+func newPlaybackRequest(opts model.PlaybackOptions) playbackRequest {
+	return playbackRequest{Shuffle: opts.Shuffle, Loop: string(opts.Loop)}
+}
+
+func (s *Server) apiListCards(w http.ResponseWriter, r *http.Request) error {
+	cards, err := s.db.ListRFIDSongs()
+	if err != nil {
+		return fmt.Errorf("apiListCards|ListRFIDSongs|%w", err)
+	}
+	sort.Slice(cards, func(i, j int) bool { return cards[i].RFID < cards[j].RFID })
+	list := make([]*apiCard, len(cards))
+	for i, card := range cards {
+		list[i] = newAPICard(card)
+	}
+	p, err := paginate(r, list)
+	if err != nil {
+		return err
+	}
+	writeAPIJSON(w, http.StatusOK, p)
+	return nil
+}
+
+func (s *Server) apiGetCard(w http.ResponseWriter, r *http.Request) error {
+	card, err := s.apiCard(normalizeRFID(r.PathValue("rfid")))
+	if err != nil {
+		return err
+	}
+	writeAPIJSON(w, http.StatusOK, newAPICard(card))
+	return nil
+}
+
+// apiAssignCardSong adds a song to the end of a card, creating the card if
+// needed. Adding a song that is already on the card changes nothing.
+func (s *Server) apiAssignCardSong(w http.ResponseWriter, r *http.Request) error {
+	rfid := normalizeRFID(r.PathValue("rfid"))
+	song, err := s.apiSong(r.PathValue("song_id"))
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.GetCommandCard(rfid); err == nil {
+		return asHTTPError(http.StatusConflict, fmt.Errorf("card %s is a command card", rfid))
+	} else if !errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("apiAssignCardSong|GetCommandCard|%w", err)
+	}
+	card, err := s.db.GetRFIDSong(rfid)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("apiAssignCardSong|GetRFIDSong|%w", err)
+	}
+	if card == nil || !slices.Contains(card.Songs, song.ID) {
+		if err := s.db.AddRFIDSong(rfid, song.ID); err != nil {
+			return fmt.Errorf("apiAssignCardSong|AddRFIDSong|%w", err)
+		}
+		s.libraryChanged(events.CardAssigned, song.ID, rfid)
+	}
+	card, err = s.apiCard(rfid)
+	if err != nil {
+		return err
+	}
+	writeAPIJSON(w, http.StatusOK, newAPICard(card))
+	return nil
+}
+
+func (s *Server) apiUnassignCardSong(w http.ResponseWriter, r *http.Request) error {
+	rfid := normalizeRFID(r.PathValue("rfid"))
+	songID := r.PathValue("song_id")
+	card, err := s.apiCard(rfid)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(card.Songs, songID) {
+		return asHTTPError(http.StatusNotFound, fmt.Errorf("song %q is not on card %s", songID, rfid))
+	}
+	if err := s.db.RemoveRFIDSong(rfid, songID); err != nil {
+		return fmt.Errorf("apiUnassignCardSong|RemoveRFIDSong|%w", err)
+	}
+	s.libraryChanged(events.CardRemoved, songID, rfid)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) apiSetCardPlayback(w http.ResponseWriter, r *http.Request) error {
+	rfid := normalizeRFID(r.PathValue("rfid"))
+	var req playbackRequest
+	if err := decodeAPIBody(r, &req); err != nil {
+		return err
+	}
+	loop, err := model.ParseLoopMode(req.Loop)
+	if err != nil {
+		return asHTTPError(http.StatusBadRequest, err)
+	}
+	err = s.db.SetRFIDPlayback(rfid, model.PlaybackOptions{Shuffle: req.Shuffle, Loop: loop})
+	if errors.Is(err, db.ErrNotFound) {
+		return asHTTPError(http.StatusNotFound, fmt.Errorf("card %s not found", rfid))
+	}
+	if err != nil {
+		return fmt.Errorf("apiSetCardPlayback|SetRFIDPlayback|%w", err)
+	}
+	card, err := s.apiCard(rfid)
+	if err != nil {
+		return err
+	}
+	writeAPIJSON(w, http.StatusOK, newAPICard(card))
+	return nil
+}
+
+// apiPlayCard queues a card's songs as if it was tapped.
+func (s *Server) apiPlayCard(w http.ResponseWriter, r *http.Request) error {
+	card, err := s.apiCard(normalizeRFID(r.PathValue("rfid")))
+	if err != nil {
+		return err
+	}
+	songs := make([]*model.Song, 0, len(card.Songs))
+	for _, id := range card.Songs {
+		song, err := s.db.GetSong(id)
+		if err != nil || song == nil {
+			s.logger.Error("apiPlayCard|GetSong", "song", id, "err", err)
+			continue
+		}
+		songs = append(songs, song)
+	}
+	if len(songs) == 0 {
+		return asHTTPError(http.StatusConflict, fmt.Errorf("card %s has no songs", card.RFID))
+	}
+	_, err = s.player.PlayQueue(songs, card.Playback)
+	if errors.Is(err, player.ErrBusy) {
+		return asHTTPError(http.StatusConflict, err)
+	}
+	if err != nil {
+		return fmt.Errorf("apiPlayCard|PlayQueue|%w", err)
+	}
+	writeAPIJSON(w, http.StatusOK, s.apiStatus())
+	return nil
+}
+
+// apiCard looks up a song card, failing with 404 when there is none.
+func (s *Server) apiCard(rfid string) (*model.RFIDSong, error) {
+	card, err := s.db.GetRFIDSong(rfid)
+	if errors.Is(err, db.ErrNotFound) || (err == nil && card == nil) {
+		return nil, asHTTPError(http.StatusNotFound, fmt.Errorf("card %s not found", rfid))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetRFIDSong|%w", err)
+	}
+	return card, nil
+}
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+)
+
+func (s *Server) apiGetConfig(w http.ResponseWriter, r *http.Request) error {
+	writeAPIJSON(w, http.StatusOK, s.cfg.ToMap())
+	return nil
+}
+
+// apiPatchConfig changes the settings configUpdate covers; other keys are rejected.
+func (s *Server) apiPatchConfig(w http.ResponseWriter, r *http.Request) error {
+	var update configUpdate
+	if err := decodeAPIBody(r, &update); err != nil {
+		return err
+	}
+	if err := s.applyConfig(update); err != nil {
+		return fmt.Errorf("apiPatchConfig|%w", err)
+	}
+	writeAPIJSON(w, http.StatusOK, s.cfg.ToMap())
+	return nil
+}
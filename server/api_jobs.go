@@ -0,0 +1,85 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/model"
+)
+
+// apiJob is a download job as the API returns it.
+type apiJob struct {
+	ID          string     `json:"id"`
+	URL         string     `json:"url"`
+	RFID        string     `json:"rfid"`
+	State       string     `json:"state"`
+	Attempts    int        `json:"attempts"`
+	Error       string     `json:"error"`
+	SongID      string     `json:"song_id"`
+	NextAttempt *time.Time `json:"next_attempt,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func newAPIJob(job *model.DownloadJob) *apiJob {
+	j := &apiJob{
+		ID:        job.ID,
+		URL:       job.URL,
+		RFID:      job.RFID,
+		State:     string(job.State),
+		Attempts:  job.Attempts,
+		Error:     job.Error,
+		SongID:    job.SongID,
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+	}
+	if !job.NextAttempt.IsZero() {
+		j.NextAttempt = &job.NextAttempt
+	}
+	return j
+}
+
+func (s *Server) apiListJobs(w http.ResponseWriter, r *http.Request) error {
+	list, err := s.jobs.List()
+	if err != nil {
+		return fmt.Errorf("apiListJobs|List|%w", err)
+	}
+	out := make([]*apiJob, len(list))
+	for i, job := range list {
+		out[i] = newAPIJob(job)
+	}
+	p, err := paginate(r, out)
+	if err != nil {
+		return err
+	}
+	writeAPIJSON(w, http.StatusOK, p)
+	return nil
+}
+
+func (s *Server) apiGetJob(w http.ResponseWriter, r *http.Request) error {
+	job, err := s.db.GetJob(r.PathValue("job_id"))
+	if err != nil {
+		return asHTTPError(jobErrorCode(err), fmt.Errorf("apiGetJob|GetJob|%w", err))
+	}
+	writeAPIJSON(w, http.StatusOK, newAPIJob(job))
+	return nil
+}
+
+func (s *Server) apiCancelJob(w http.ResponseWriter, r *http.Request) error {
+	job, err := s.jobs.Cancel(r.PathValue("job_id"))
+	if err != nil {
+		return asHTTPError(jobErrorCode(err), fmt.Errorf("apiCancelJob|Cancel|%w", err))
+	}
+	writeAPIJSON(w, http.StatusOK, newAPIJob(job))
+	return nil
+}
+
+func (s *Server) apiRetryJob(w http.ResponseWriter, r *http.Request) error {
+	job, err := s.jobs.Retry(r.PathValue("job_id"))
+	if err != nil {
+		return asHTTPError(jobErrorCode(err), fmt.Errorf("apiRetryJob|Retry|%w", err))
+	}
+	writeAPIJSON(w, http.StatusOK, newAPIJob(job))
+	return nil
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/player"
+)
+
+// playerCommand is the optional body of POST /api/v1/player/{action}.
+type playerCommand struct {
+	SongID          string   `json:"song_id"`          // play
+	Volume          *int     `json:"volume"`           // volume
+	PositionSeconds *float64 `json:"position_seconds"` // seek
+}
+
+// apiPlayerStatus is playerStatus with the song in its API shape.
+type apiPlayerStatus struct {
+	playerStatus
+	Song *apiSong `json:"song"`
+}
+
+func (s *Server) apiStatus() apiPlayerStatus {
+	st := s.currentStatus()
+	return apiPlayerStatus{playerStatus: st, Song: newAPISong(st.Song)}
+}
+
+func (s *Server) apiPlayerStatus(w http.ResponseWriter, r *http.Request) error {
+	writeAPIJSON(w, http.StatusOK, s.apiStatus())
+	return nil
+}
+
+// apiPlayerCommand runs one player action and answers with the new status.
+// Controls that need something playing fail with 409 when nothing is.
+func (s *Server) apiPlayerCommand(w http.ResponseWriter, r *http.Request) error {
+	var cmd playerCommand
+	if err := decodeAPIBody(r, &cmd); err != nil {
+		return err
+	}
+	var err error
+	switch action := r.PathValue("action"); action {
+	case "play":
+		song, lookupErr := s.apiSong(cmd.SongID)
+		if lookupErr != nil {
+			return lookupErr
+		}
+		err = s.player.Play(song)
+	case "stop":
+		s.player.Stop()
+	case "pause":
+		err = s.player.Pause()
+	case "resume":
+		err = s.player.Resume()
+	case "next":
+		err = s.player.Next()
+	case "previous":
+		err = s.player.Previous()
+	case "next-chapter":
+		err = s.player.NextChapter()
+	case "previous-chapter":
+		err = s.player.PreviousChapter()
+	case "volume":
+		if cmd.Volume == nil {
+			return asHTTPError(http.StatusBadRequest, errors.New("volume required"))
+		}
+		err = s.player.SetVolume(*cmd.Volume)
+	case "seek":
+		if cmd.PositionSeconds == nil || *cmd.PositionSeconds < 0 {
+			return asHTTPError(http.StatusBadRequest, errors.New("position_seconds must be 0 or more"))
+		}
+		err = s.player.Seek(time.Duration(*cmd.PositionSeconds * float64(time.Second)))
+	default:
+		return asHTTPError(http.StatusNotFound, fmt.Errorf("unknown player action %q", action))
+	}
+	if errors.Is(err, player.ErrNotPlaying) || errors.Is(err, player.ErrNoQueue) || errors.Is(err, player.ErrNoChapters) ||
+		errors.Is(err, player.ErrBusy) {
+		return asHTTPError(http.StatusConflict, err)
+	}
+	if err != nil {
+		return fmt.Errorf("apiPlayerCommand|%w", err)
+	}
+	writeAPIJSON(w, http.StatusOK, s.apiStatus())
+	return nil
+}
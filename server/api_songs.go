@@ -0,0 +1,232 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/downloader"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/model"
+)
+
+// songRequest is the body of POST /api/v1/songs. Kind "file" (or none) queues
+// the URL for download; kind "stream" adds an internet radio stream.
+type songRequest struct {
+	Kind  string `json:"kind"`
+	URL   string `json:"url"`
+	Title string `json:"title"` // streams only
+	RFID  string `json:"rfid"`
+	Force bool   `json:"force"` // download again even if the URL is in the library
+}
+
+// apiSong is a song as the API returns it. Durations are in seconds and the
+// file's location on disk is left out.
+type apiSong struct {
+	ID              string       `json:"id"`
+	Kind            string       `json:"kind"`
+	Title           string       `json:"title"`
+	Artist          string       `json:"artist"`
+	Album           string       `json:"album"`
+	Extractor       string       `json:"extractor"`
+	RFID            string       `json:"rfid"`
+	URL             string       `json:"url"`
+	Thumbnail       string       `json:"thumbnail"`
+	DurationSeconds float64      `json:"duration_seconds"`
+	VolumeOffset    int          `json:"volume_offset"`
+	Resume          bool         `json:"resume"`
+	Chapters        []apiChapter `json:"chapters"`
+	Plays           int          `json:"plays"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+}
+
+type apiChapter struct {
+	Title        string  `json:"title"`
+	StartSeconds float64 `json:"start_seconds"`
+	EndSeconds   float64 `json:"end_seconds"`
+}
+
+func newAPISong(song *model.Song) *apiSong {
+	if song == nil {
+		return nil
+	}
+	kind := "file"
+	if song.IsStream() {
+		kind = string(model.SongKindStream)
+	}
+	chapters := make([]apiChapter, len(song.Chapters))
+	for i, c := range song.Chapters {
+		chapters[i] = apiChapter{Title: c.Title, StartSeconds: c.Start.Seconds(), EndSeconds: c.End.Seconds()}
+	}
+	return &apiSong{
+		ID:              song.ID,
+		Kind:            kind,
+		Title:           song.Title,
+		Artist:          song.Artist,
+		Album:           song.Album,
+		Extractor:       song.Extractor,
+		RFID:            song.RFID,
+		URL:             song.URL,
+		Thumbnail:       song.Thumbnail,
+		DurationSeconds: song.Duration.Seconds(),
+		VolumeOffset:    song.VolumeOffset,
+		Resume:          song.Resume,
+		Chapters:        chapters,
+		Plays:           song.Plays,
+		CreatedAt:       song.CreatedAt,
+		UpdatedAt:       song.UpdatedAt,
+	}
+}
+
+// songPatch is the body of PATCH /api/v1/songs/{song_id}; nil fields are left
+// alone. The read-only fields of apiSong are accepted and ignored, so a song
+// read from the API can be sent back as it is.
+type songPatch struct {
+	Title        *string `json:"title"`
+	Artist       *string `json:"artist"`
+	Album        *string `json:"album"`
+	VolumeOffset *int    `json:"volume_offset"`
+	Resume       *bool   `json:"resume"`
+	songReadOnly
+}
+
+type songReadOnly struct {
+	ID              any `json:"id"`
+	Kind            any `json:"kind"`
+	Extractor       any `json:"extractor"`
+	RFID            any `json:"rfid"`
+	URL             any `json:"url"`
+	Thumbnail       any `json:"thumbnail"`
+	DurationSeconds any `json:"duration_seconds"`
+	Chapters        any `json:"chapters"`
+	Plays           any `json:"plays"`
+	CreatedAt       any `json:"created_at"`
+	UpdatedAt       any `json:"updated_at"`
+}
+
+func (s *Server) apiListSongs(w http.ResponseWriter, r *http.Request) error {
+	songs, err := s.listSongsWithRFID()
+	if err != nil {
+		return fmt.Errorf("apiListSongs|%w", err)
+	}
+	list := make([]*apiSong, len(songs))
+	for i, song := range songs {
+		list[i] = newAPISong(song)
+	}
+	p, err := paginate(r, list)
+	if err != nil {
+		return err
+	}
+	writeAPIJSON(w, http.StatusOK, p)
+	return nil
+}
+
+func (s *Server) apiGetSong(w http.ResponseWriter, r *http.Request) error {
+	song, err := s.apiSong(r.PathValue("song_id"))
+	if err != nil {
+		return err
+	}
+	writeAPIJSON(w, http.StatusOK, newAPISong(song))
+	return nil
+}
+
+// apiCreateSong adds a stream right away (201) or queues a download (202,
+// answering with the job).
+func (s *Server) apiCreateSong(w http.ResponseWriter, r *http.Request) error {
+	var req songRequest
+	if err := decodeAPIBody(r, &req); err != nil {
+		return err
+	}
+	switch req.Kind {
+	case string(model.SongKindStream):
+		song, err := s.createStream(req.URL, req.Title, req.RFID)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Location", apiPrefix+"/songs/"+song.ID)
+		writeAPIJSON(w, http.StatusCreated, newAPISong(song))
+	case "", "file":
+		if strings.TrimSpace(req.URL) == "" {
+			return asHTTPError(http.StatusBadRequest, downloader.ErrMissingURL)
+		}
+		job, err := s.jobs.Enqueue(normalizeVideoURL(req.URL), normalizeRFID(req.RFID), req.Force)
+		if err != nil {
+			return fmt.Errorf("apiCreateSong|Enqueue|%w", err)
+		}
+		w.Header().Set("Location", apiPrefix+"/jobs/"+job.ID)
+		writeAPIJSON(w, http.StatusAccepted, newAPIJob(job))
+	default:
+		return asHTTPError(http.StatusBadRequest, fmt.Errorf("unknown song kind %q", req.Kind))
+	}
+	return nil
+}
+
+func (s *Server) apiPatchSong(w http.ResponseWriter, r *http.Request) error {
+	song, err := s.apiSong(r.PathValue("song_id"))
+	if err != nil {
+		return err
+	}
+	var patch songPatch
+	if err := decodeAPIBody(r, &patch); err != nil {
+		return err
+	}
+	if patch.Title != nil {
+		title := strings.TrimSpace(*patch.Title)
+		if title == "" {
+			return asHTTPError(http.StatusBadRequest, errors.New("title cannot be empty"))
+		}
+		song.Title = title
+	}
+	if patch.Artist != nil {
+		song.Artist = *patch.Artist
+	}
+	if patch.Album != nil {
+		song.Album = *patch.Album
+	}
+	if patch.VolumeOffset != nil {
+		song.VolumeOffset = *patch.VolumeOffset
+	}
+	if patch.Resume != nil {
+		song.Resume = *patch.Resume
+	}
+	if err := s.db.UpdateSong(song); err != nil {
+		return fmt.Errorf("apiPatchSong|UpdateSong|%w", err)
+	}
+	if patch.Resume != nil {
+		if err := s.forgetPositionUnlessResuming(song); err != nil {
+			return fmt.Errorf("apiPatchSong|%w", err)
+		}
+	}
+	s.libraryChanged(events.SongUpdated, song.ID, "")
+	writeAPIJSON(w, http.StatusOK, newAPISong(song))
+	return nil
+}
+
+func (s *Server) apiDeleteSong(w http.ResponseWriter, r *http.Request) error {
+	song, err := s.apiSong(r.PathValue("song_id"))
+	if err != nil {
+		return err
+	}
+	if err := s.db.DeleteSong(song.ID); err != nil {
+		return fmt.Errorf("apiDeleteSong|DeleteSong|%w", err)
+	}
+	s.libraryChanged(events.SongDeleted, song.ID, "")
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// apiSong looks up a song, failing with 404 when there is none.
+func (s *Server) apiSong(id string) (*model.Song, error) {
+	song, err := s.db.GetSong(id)
+	if errors.Is(err, db.ErrNotFound) || (err == nil && song == nil) {
+		return nil, asHTTPError(http.StatusNotFound, fmt.Errorf("song %q not found", id))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("GetSong|%w", err)
+	}
+	return song, nil
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/config"
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/jobs"
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/jaredwarren/rpi_music/player"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apiTest serves the JSON API over a MockDB and a fake player.
+type apiTest struct {
+	t   *testing.T
+	s   *Server
+	db  *db.MockDB
+	mux *http.ServeMux
+}
+
+func newAPITest(t *testing.T) *apiTest {
+	t.Helper()
+	cfg, err := config.Load(filepath.Join(t.TempDir(), "config.yml"))
+	require.NoError(t, err)
+	p, err := player.NewWithBackend(player.Config{AllowOverride: true}, player.NewFakeBackend(), log.NewNoOpLogger())
+	require.NoError(t, err)
+	t.Cleanup(p.Stop)
+	mockDB := &db.MockDB{GetRFIDSongErr: db.ErrNotFound}
+	s := &Server{cfg: cfg, db: mockDB, player: p, bus: events.NewBus(0), logger: log.NewNoOpLogger()}
+	s.jobs = jobs.New(mockDB, s.runDownloadJob, jobs.Config{}, s.logger)
+	mux := http.NewServeMux()
+	s.registerAPIRoutes(mux)
+	return &apiTest{t: t, s: s, db: mockDB, mux: mux}
+}
+
+// do sends a request with an optional JSON body and decodes the JSON reply into out.
+func (a *apiTest) do(method, path, body string, out any) *httptest.ResponseRecorder {
+	a.t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	a.mux.ServeHTTP(w, req)
+	if out != nil {
+		require.NoError(a.t, json.NewDecoder(w.Body).Decode(out), w.Body.String())
+	}
+	return w
+}
+
+// errorMessage sends a request that should fail with code and returns the
+// message from its error envelope.
+func (a *apiTest) errorMessage(code int, method, path, body string) string {
+	a.t.Helper()
+	var got apiError
+	w := a.do(method, path, body, &got)
+	require.Equal(a.t, code, w.Code, got.Error.Message)
+	assert.Equal(a.t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(a.t, code, got.Error.Status)
+	return got.Error.Message
+}
+
+func TestAPIErrors(t *testing.T) {
+	a := newAPITest(t)
+	assert.Contains(t, a.errorMessage(http.StatusNotFound, http.MethodGet, "/api/v1/songs/nope", ""), `song "nope" not found`)
+	a.errorMessage(http.StatusNotFound, http.MethodGet, "/api/v1/nothing-here", "")
+	a.errorMessage(http.StatusBadRequest, http.MethodGet, "/api/v1/songs?limit=0", "")
+	a.errorMessage(http.StatusBadRequest, http.MethodGet, "/api/v1/songs?offset=-1", "")
+	a.errorMessage(http.StatusNotFound, http.MethodGet, "/api/v1/jobs/nope", "")
+}
+
+func TestAPISongs(t *testing.T) {
+	a := newAPITest(t)
+	a.db.ListSongsResult = []*model.Song{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+
+	var list page[apiSong]
+	w := a.do(http.MethodGet, "/api/v1/songs?limit=2&offset=1", "", &list)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 3, list.Total)
+	assert.Equal(t, 2, list.Limit)
+	require.Len(t, list.Items, 2)
+	a.do(http.MethodGet, "/api/v1/songs?offset=10", "", &list)
+	assert.Empty(t, list.Items)
+
+	song := &model.Song{ID: "a", Title: "Old", FilePath: "song_files/a.mp3", Duration: 90 * time.Second}
+	a.db.GetSongResult = song
+	var raw map[string]any
+	a.do(http.MethodGet, "/api/v1/songs/a", "", &raw)
+	assert.Equal(t, 90.0, raw["duration_seconds"])
+	assert.Equal(t, "file", raw["kind"])
+	assert.NotContains(t, raw, "file_path")
+	assert.NotContains(t, raw, "FilePath")
+
+	var got apiSong
+	w = a.do(http.MethodPatch, "/api/v1/songs/a", `{"title": "New", "volume_offset": -10}`, &got)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "New", got.Title)
+	assert.Equal(t, -10, got.VolumeOffset)
+
+	// What is read can be sent back.
+	got.Artist = "Someone"
+	body, err := json.Marshal(got)
+	require.NoError(t, err)
+	w = a.do(http.MethodPatch, "/api/v1/songs/a", string(body), &got)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Someone", got.Artist)
+	assert.Equal(t, "song_files/a.mp3", a.db.LastUpdateSongCall().FilePath)
+	assert.Equal(t, -10, a.db.LastUpdateSongCall().VolumeOffset)
+	a.errorMessage(http.StatusBadRequest, http.MethodPatch, "/api/v1/songs/a", `{"title": " "}`)
+	a.errorMessage(http.StatusBadRequest, http.MethodPatch, "/api/v1/songs/a", `{"file_path": "/etc/passwd"}`)
+
+	w = a.do(http.MethodDelete, "/api/v1/songs/a", "", nil)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, []string{"a"}, a.db.DeleteSongCalls)
+}
+
+func TestAPICreateSong(t *testing.T) {
+	a := newAPITest(t)
+
+	var song apiSong
+	w := a.do(http.MethodPost, "/api/v1/songs", `{"kind": "stream", "url": "https://radio/live", "title": "Radio"}`, &song)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "stream", song.Kind)
+	assert.Equal(t, "/api/v1/songs/"+song.ID, w.Header().Get("Location"))
+
+	var job apiJob
+	w = a.do(http.MethodPost, "/api/v1/songs", `{"kind": "file", "url": "https://youtu.be/abc", "rfid": "ab:cd"}`, &job)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "abcd", job.RFID)
+	assert.Equal(t, string(model.JobQueued), job.State)
+	assert.Equal(t, "/api/v1/jobs/"+job.ID, w.Header().Get("Location"))
+
+	a.errorMessage(http.StatusBadRequest, http.MethodPost, "/api/v1/songs", `{}`)
+	a.errorMessage(http.StatusBadRequest, http.MethodPost, "/api/v1/songs", `{"kind": "stream", "url": "ftp://x", "title": "x"}`)
+	a.errorMessage(http.StatusBadRequest, http.MethodPost, "/api/v1/songs", `{"kind": "vinyl"}`)
+}
+
+func TestAPICards(t *testing.T) {
+	a := newAPITest(t)
+	a.errorMessage(http.StatusNotFound, http.MethodGet, "/api/v1/cards/ABCD", "")
+
+	a.db.GetSongResult = &model.Song{ID: "s1", FilePath: "s1.mp3"}
+	a.db.OnAddRFIDSong = func(rfid, songID string) {
+		a.db.GetRFIDSongResult = &model.RFIDSong{RFID: rfid, Songs: []string{songID}}
+		a.db.GetRFIDSongErr = nil
+	}
+	var card apiCard
+	w := a.do(http.MethodPut, "/api/v1/cards/ab:cd/songs/s1", "", &card)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, apiCard{RFID: "abcd", Songs: []string{"s1"}}, card)
+	a.do(http.MethodPut, "/api/v1/cards/abcd/songs/s1", "", &card)
+	assert.Equal(t, 1, a.db.AddRFIDSongCallCount(), "already on the card")
+
+	a.errorMessage(http.StatusBadRequest, http.MethodPut, "/api/v1/cards/abcd/playback", `{"loop": "forever"}`)
+
+	var st apiPlayerStatus
+	w = a.do(http.MethodPost, "/api/v1/cards/abcd/play", "", &st)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, st.Playing)
+
+	a.db.GetCommandCardResult = &model.CommandCard{RFID: "CMD", Command: model.CommandStop}
+	a.errorMessage(http.StatusConflict, http.MethodPut, "/api/v1/cards/CMD/songs/s1", "")
+}
+
+func TestAPIPlayer(t *testing.T) {
+	a := newAPITest(t)
+	a.errorMessage(http.StatusConflict, http.MethodPost, "/api/v1/player/pause", "")
+	a.errorMessage(http.StatusNotFound, http.MethodPost, "/api/v1/player/play", `{"song_id": "x"}`)
+
+	a.db.GetSongResult = &model.Song{ID: "s1", FilePath: "s1.mp3"}
+	var st apiPlayerStatus
+	w := a.do(http.MethodPost, "/api/v1/player/play", `{"song_id": "s1"}`, &st)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, st.Playing)
+	assert.Equal(t, "s1", st.Song.ID)
+
+	a.do(http.MethodPost, "/api/v1/player/volume", `{"volume": 30}`, &st)
+	assert.Equal(t, 30, st.Volume)
+	a.do(http.MethodPost, "/api/v1/player/pause", "", &st)
+	assert.True(t, st.Paused)
+	a.errorMessage(http.StatusBadRequest, http.MethodPost, "/api/v1/player/seek", `{}`)
+	a.errorMessage(http.StatusConflict, http.MethodPost, "/api/v1/player/next-chapter", "")
+	a.errorMessage(http.StatusNotFound, http.MethodPost, "/api/v1/player/dance", "")
+
+	w = a.do(http.MethodGet, "/api/v1/player", "", &st)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, st.Paused)
+}
+
+func TestAPIConfig(t *testing.T) {
+	a := newAPITest(t)
+	var got map[string]any
+	w := a.do(http.MethodPatch, "/api/v1/config", `{"beep": true, "player.loop": "repeat-queue"}`, &got)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, true, got["beep"])
+	assert.Equal(t, "repeat-queue", got["player.loop"])
+	assert.Equal(t, model.LoopQueue, a.s.player.Loop())
+
+	a.errorMessage(http.StatusBadRequest, http.MethodPatch, "/api/v1/config", `{"player.loop": "sometimes"}`)
+	a.errorMessage(http.StatusBadRequest, http.MethodPatch, "/api/v1/config", `{"host": ":80"}`)
+}
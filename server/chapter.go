@@ -0,0 +1,147 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/jaredwarren/rpi_music/player"
+)
+
+var errTooFewChapters = errors.New("song needs at least two chapters to split")
+
+// NextChapterHandler skips to the next chapter of the current song.
+func (s *Server) NextChapterHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.player.NextChapter(); err != nil && !chapterControlIgnorable(err) {
+		s.httpError(w, fmt.Errorf("NextChapterHandler|NextChapter|%w", err), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/player/", http.StatusFound)
+}
+
+// PreviousChapterHandler goes back to the start of the current or previous chapter.
+func (s *Server) PreviousChapterHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.player.PreviousChapter(); err != nil && !chapterControlIgnorable(err) {
+		s.httpError(w, fmt.Errorf("PreviousChapterHandler|PreviousChapter|%w", err), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/player/", http.StatusFound)
+}
+
+// chapterControlIgnorable reports whether err only means there was nothing to skip.
+func chapterControlIgnorable(err error) bool {
+	return errors.Is(err, player.ErrNotPlaying) || errors.Is(err, player.ErrNoChapters) || errors.Is(err, player.ErrNoQueue)
+}
+
+// SplitChaptersHandlerE cuts a song into one new song per chapter and puts
+// them, in order, in the song's place on the "rfid" card or on its own card.
+// The original song stays in the library. If anything fails, the parts made
+// so far are removed again.
+func (s *Server) SplitChaptersHandlerE(w http.ResponseWriter, r *http.Request) error {
+	song, err := s.db.GetSong(r.PathValue("song_id"))
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, db.ErrNotFound) {
+			code = http.StatusNotFound
+		}
+		return asHTTPError(code, fmt.Errorf("SplitChapters|GetSong|%w", err))
+	}
+	if len(song.Chapters) < 2 || song.IsStream() {
+		return asHTTPError(http.StatusBadRequest, fmt.Errorf("SplitChapters|%w", errTooFewChapters))
+	}
+	if err := r.ParseForm(); err != nil {
+		return asHTTPError(http.StatusBadRequest, fmt.Errorf("SplitChapters|ParseForm|%w", err))
+	}
+	rfid := normalizeRFID(r.PostForm.Get("rfid"))
+	if rfid == "" {
+		if card, err := s.db.GetSongRFID(song.ID); err == nil && card != nil {
+			rfid = card.RFID
+		}
+	}
+	if rfid != "" {
+		if _, err := s.db.GetCommandCard(rfid); err == nil {
+			return asHTTPError(http.StatusConflict, fmt.Errorf("SplitChapters|card %s is a command card", rfid))
+		} else if !errors.Is(err, db.ErrNotFound) {
+			return asHTTPError(http.StatusInternalServerError, fmt.Errorf("SplitChapters|GetCommandCard|%w", err))
+		}
+	}
+
+	parts, err := s.splitChapters(r, song)
+	if err != nil {
+		s.removeSplitParts(parts)
+		return asHTTPError(http.StatusInternalServerError, fmt.Errorf("SplitChapters|%w", err))
+	}
+	if rfid != "" {
+		ids := make([]string, len(parts))
+		for i, part := range parts {
+			ids[i] = part.ID
+		}
+		if err := s.db.ReplaceRFIDSong(rfid, song.ID, ids); err != nil {
+			s.removeSplitParts(parts)
+			return asHTTPError(http.StatusInternalServerError, fmt.Errorf("SplitChapters|ReplaceRFIDSong|%w", err))
+		}
+		s.libraryChanged(events.CardRemoved, song.ID, rfid)
+		for _, id := range ids {
+			s.libraryChanged(events.CardAssigned, id, rfid)
+		}
+	}
+
+	http.Redirect(w, r, "/songs", http.StatusFound)
+	return nil
+}
+
+// splitChapters writes and saves a song for each of song's chapters.
+func (s *Server) splitChapters(r *http.Request, song *model.Song) ([]*model.Song, error) {
+	parts := make([]*model.Song, 0, len(song.Chapters))
+	for _, c := range song.Chapters {
+		id := uuid.New().String()
+		dst := filepath.Join(s.songAssetRoot(), id+filepath.Ext(song.FilePath))
+		if err := s.analyzer.ExtractClip(r.Context(), song.FilePath, dst, c.Start, c.End); err != nil {
+			_ = os.Remove(dst)
+			return parts, fmt.Errorf("ExtractClip|%w", err)
+		}
+		part := &model.Song{
+			ID:           id,
+			Title:        c.Title,
+			Artist:       song.Artist,
+			Album:        song.Title,
+			Extractor:    song.Extractor,
+			Thumbnail:    song.Thumbnail,
+			FilePath:     normalizeAssetPath(dst, s.songAssetRoot()),
+			VolumeOffset: song.VolumeOffset,
+			Loudness:     song.Loudness,
+			Gain:         song.Gain,
+		}
+		if c.End > c.Start {
+			part.Duration = c.End - c.Start
+		} else if song.Duration > c.Start {
+			part.Duration = song.Duration - c.Start
+		}
+		if err := s.db.CreateSong(part); err != nil {
+			_ = os.Remove(dst)
+			return parts, fmt.Errorf("CreateSong|%w", err)
+		}
+		s.libraryChanged(events.SongCreated, part.ID, "")
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// removeSplitParts deletes songs made by a split that did not finish, with
+// their clips. Thumbnails are shared with the original song and stay.
+func (s *Server) removeSplitParts(parts []*model.Song) {
+	for _, part := range parts {
+		if err := s.db.DeleteSong(part.ID); err != nil {
+			s.logger.Error("removeSplitParts|DeleteSong", "song", part.ID, "err", err)
+			continue
+		}
+		_ = os.Remove(part.FilePath)
+		s.libraryChanged(events.SongDeleted, part.ID, "")
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/config"
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/jaredwarren/rpi_music/media"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFFmpeg writes a stand-in for ffmpeg that records its arguments in its
+// output file, the last argument. It fails when the arguments contain failOn.
+func fakeFFmpeg(t *testing.T, failOn ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ffmpeg")
+	script := "#!/bin/sh\nfor a; do last=$a; done\necho \"$@\" > \"$last\"\n"
+	for _, f := range failOn {
+		script += "case \"$*\" in *\"" + f + "\"*) exit 1;; esac\n"
+	}
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o755))
+	return path
+}
+
+func TestSplitChapters(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Player.SongRoot = t.TempDir()
+	book := &model.Song{
+		ID: "book", Title: "The Book", Artist: "Author", FilePath: "book.m4a", Duration: 5 * time.Minute,
+		Chapters: []model.Chapter{
+			{Title: "Opening", End: 2 * time.Minute},
+			{Title: "Ending", Start: 2 * time.Minute},
+		},
+	}
+	mockDB := &db.MockDB{GetSongResult: book}
+	s := &Server{cfg: cfg, db: mockDB, logger: log.NewNoOpLogger(), analyzer: &media.Analyzer{Bin: fakeFFmpeg(t)}}
+
+	form := url.Values{"rfid": {"AB:CD"}}
+	req := httptest.NewRequest(http.MethodPost, "/song/book/split", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetPathValue("song_id", "book")
+	w := httptest.NewRecorder()
+	require.NoError(t, s.SplitChaptersHandlerE(w, req))
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	require.Len(t, mockDB.CreateSongCalls, 2)
+	first, second := mockDB.CreateSongCalls[0], mockDB.CreateSongCalls[1]
+	assert.Equal(t, "Opening", first.Title)
+	assert.Equal(t, "The Book", first.Album)
+	assert.Equal(t, "Author", first.Artist)
+	assert.Equal(t, 2*time.Minute, first.Duration)
+	assert.Equal(t, 3*time.Minute, second.Duration)
+	assert.Equal(t, ".m4a", filepath.Ext(second.FilePath))
+	args, err := os.ReadFile(second.FilePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(args), "-ss 120.000 -i book.m4a -vn")
+
+	assert.Equal(t, []db.ReplaceRFIDSongCall{{RFID: "ABCD", SongID: "book", With: []string{first.ID, second.ID}}}, mockDB.ReplaceRFIDSongCalls)
+
+	book.Chapters = book.Chapters[:1]
+	err = s.SplitChaptersHandlerE(httptest.NewRecorder(), req)
+	require.ErrorIs(t, err, errTooFewChapters)
+}
+
+func TestSplitChaptersCleansUpOnFailure(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Player.SongRoot = t.TempDir()
+	book := &model.Song{
+		ID: "book", FilePath: "book.m4a",
+		Chapters: []model.Chapter{{Title: "Opening", End: 2 * time.Minute}, {Title: "Ending", Start: 2 * time.Minute}},
+	}
+	mockDB := &db.MockDB{GetSongResult: book}
+	s := &Server{cfg: cfg, db: mockDB, logger: log.NewNoOpLogger(), analyzer: &media.Analyzer{Bin: fakeFFmpeg(t, "-ss 120.000")}}
+
+	req := httptest.NewRequest(http.MethodPost, "/song/book/split", strings.NewReader("rfid=ABCD"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetPathValue("song_id", "book")
+	err := s.SplitChaptersHandlerE(httptest.NewRecorder(), req)
+	require.ErrorContains(t, err, "ExtractClip")
+
+	require.Len(t, mockDB.CreateSongCalls, 1)
+	first := mockDB.CreateSongCalls[0]
+	assert.Equal(t, []string{first.ID}, mockDB.DeleteSongCalls)
+	assert.NoFileExists(t, first.FilePath)
+	clips, err := os.ReadDir(cfg.Player.SongRoot)
+	require.NoError(t, err)
+	assert.Empty(t, clips)
+	assert.Empty(t, mockDB.ReplaceRFIDSongCalls)
+}
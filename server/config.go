@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/jaredwarren/rpi_music/events"
 	"github.com/jaredwarren/rpi_music/model"
 )
 
@@ -34,21 +35,68 @@ func (s *Server) ConfigHandlerE(w http.ResponseWriter, r *http.Request) error {
 	}
 	s.logger.Info("ConfigHandler", "form", r.PostForm)
 
-	s.cfg.Beep = r.PostForm.Get("beep") == "on"
-	s.cfg.Player.Loop = r.PostForm.Get("player.loop") == "on"
-	s.cfg.AllowOverride = r.PostForm.Get("allow_override") == "on"
-	s.cfg.Startup.Play = r.PostForm.Get("startup.play") == "on"
-
+	// Unchecked boxes are not submitted, so they always count as off.
+	beep := r.PostForm.Get("beep") == "on"
+	allowOverride := r.PostForm.Get("allow_override") == "on"
+	startupPlay := r.PostForm.Get("startup.play") == "on"
+	update := configUpdate{Beep: &beep, AllowOverride: &allowOverride, StartupPlay: &startupPlay}
+	if v := r.PostForm.Get("player.loop"); v != "" {
+		update.Loop = &v
+	}
 	if v := r.PostForm.Get("player.volume"); v != "" {
 		if vol, err := strconv.Atoi(v); err == nil {
-			s.cfg.Player.Volume = vol
+			update.Volume = &vol
 		}
 	}
-
-	if err := s.cfg.Save(); err != nil {
-		return asHTTPError(http.StatusInternalServerError, fmt.Errorf("ConfigHandler|Save|%w", err))
+	if err := s.applyConfig(update); err != nil {
+		return fmt.Errorf("ConfigHandler|%w", err)
 	}
 
 	http.Redirect(w, r, "/songs", http.StatusFound)
 	return nil
 }
+
+// configUpdate holds the settings that can be changed while running; nil
+// fields are left alone.
+type configUpdate struct {
+	Beep          *bool   `json:"beep"`
+	AllowOverride *bool   `json:"allow_override"`
+	StartupPlay   *bool   `json:"startup.play"`
+	Loop          *string `json:"player.loop"`
+	Volume        *int    `json:"player.volume"`
+}
+
+// applyConfig changes the running settings and saves them.
+func (s *Server) applyConfig(u configUpdate) error {
+	if u.Loop != nil {
+		loop, err := model.ParseLoopMode(*u.Loop)
+		if err != nil {
+			return asHTTPError(http.StatusBadRequest, fmt.Errorf("ParseLoopMode|%w", err))
+		}
+		s.cfg.Player.Loop = *u.Loop
+		s.player.SetLoop(loop)
+	}
+	if u.Beep != nil {
+		s.cfg.Beep = *u.Beep
+	}
+	if u.AllowOverride != nil {
+		s.cfg.AllowOverride = *u.AllowOverride
+	}
+	if u.StartupPlay != nil {
+		s.cfg.Startup.Play = *u.StartupPlay
+	}
+	// The config form always sends the volume; only a change reaches the
+	// player, since ffplay has to restart the song to apply it.
+	if u.Volume != nil && *u.Volume != s.cfg.Player.Volume {
+		s.cfg.Player.Volume = *u.Volume
+		if err := s.player.SetVolume(*u.Volume); err != nil {
+			s.logger.Error("applyConfig|SetVolume", "err", err)
+		}
+	}
+
+	if err := s.cfg.Save(); err != nil {
+		return asHTTPError(http.StatusInternalServerError, fmt.Errorf("Save|%w", err))
+	}
+	s.bus.Publish(events.ConfigChanged, nil)
+	return nil
+}
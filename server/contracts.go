@@ -9,4 +9,15 @@ const TemplateTag = "csrfField"
 type Store interface {
 	db.SongStore
 	db.RFIDStore
+	db.CommandStore
+	db.JobStore
+	db.PlaylistStore
+	db.FeedStore
+	db.PositionStore
+}
+
+// TagWriter stores an NDEF message on the next blank NFC tag read;
+// *rfid.Reader implements it.
+type TagWriter interface {
+	WriteNDEF(msg []byte, done func(uid string, err error)) error
 }
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/downloader"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/jobs"
+	"github.com/jaredwarren/rpi_music/model"
+)
+
+// downloadTracker remembers in-flight downloads, oldest first, so a page
+// loaded mid-download can show their progress.
+type downloadTracker struct {
+	mu     sync.Mutex
+	active []events.Download
+}
+
+// update records d, forgetting it once it has finished or failed.
+func (t *downloadTracker) update(d events.Download) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	done := d.State == events.DownloadFinished || d.State == events.DownloadFailed
+	for i, a := range t.active {
+		if a.URL != d.URL {
+			continue
+		}
+		if done {
+			t.active = append(t.active[:i], t.active[i+1:]...)
+		} else {
+			t.active[i] = d
+		}
+		return
+	}
+	if !done {
+		t.active = append(t.active, d)
+	}
+}
+
+func (t *downloadTracker) list() []events.Download {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]events.Download{}, t.active...)
+}
+
+// publishDownload records and publishes a download's progress.
+func (s *Server) publishDownload(d events.Download) {
+	s.downloads.update(d)
+	s.bus.Publish(events.DownloadProgress, d)
+}
+
+// withDownloadProgress returns a context that publishes yt-dlp progress for
+// url, once per whole percent.
+func (s *Server) withDownloadProgress(ctx context.Context, url string) context.Context {
+	last := -1
+	return downloader.WithProgress(ctx, func(p downloader.Progress) {
+		if int(p.Percent) == last {
+			return
+		}
+		last = int(p.Percent)
+		s.publishDownload(events.Download{
+			URL:        url,
+			State:      events.DownloadRunning,
+			Percent:    p.Percent,
+			Speed:      p.Speed,
+			ETASeconds: p.ETA.Seconds(),
+		})
+	})
+}
+
+// runDownloadJob is the jobs.RunFunc for the download queue.
+func (s *Server) runDownloadJob(ctx context.Context, job *model.DownloadJob) (*model.Song, error) {
+	song, err := s.createDownloadedSong(ctx, job.URL, job.Force, job.RFID)
+	if errors.Is(err, downloader.ErrAlreadyExists) || errors.Is(err, downloader.ErrMissingURL) {
+		return nil, jobs.Permanent(err)
+	}
+	return song, err
+}
+
+// downloadJobFinished tells the user how a download job ended and updates
+// any playlists or feeds waiting on it.
+func (s *Server) downloadJobFinished(job *model.DownloadJob, song *model.Song) {
+	s.playlistJobFinished(job)
+	s.feedJobFinished(job)
+	if job.State == model.JobDone && song != nil {
+		notifyDesktop("Download complete", song.Title)
+		s.notify("Download complete", song.Title)
+		return
+	}
+	notifyDesktop("Download failed", job.Error)
+	s.notify("Download failed", job.Error)
+}
+
+// DownloadsHandler lists the download jobs.
+func (s *Server) DownloadsHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := s.jobs.List()
+	if err != nil {
+		s.httpError(w, fmt.Errorf("DownloadsHandler|List|%w", err), http.StatusInternalServerError)
+		return
+	}
+	s.render(w, r, s.templates["downloads"], map[string]any{
+		"Jobs":      list,
+		TemplateTag: template.HTML(""),
+	})
+}
+
+// CancelDownloadHandler stops a queued or running download job.
+func (s *Server) CancelDownloadHandler(w http.ResponseWriter, r *http.Request) {
+	job, err := s.jobs.Cancel(r.PathValue("job_id"))
+	if err != nil {
+		s.httpError(w, fmt.Errorf("CancelDownloadHandler|Cancel|%w", err), jobErrorCode(err))
+		return
+	}
+	writeJSON(w, job)
+}
+
+// RetryDownloadHandler queues a failed download job again.
+func (s *Server) RetryDownloadHandler(w http.ResponseWriter, r *http.Request) {
+	job, err := s.jobs.Retry(r.PathValue("job_id"))
+	if err != nil {
+		s.httpError(w, fmt.Errorf("RetryDownloadHandler|Retry|%w", err), jobErrorCode(err))
+		return
+	}
+	writeJSON(w, job)
+}
+
+func jobErrorCode(err error) int {
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, jobs.ErrNotCancellable), errors.Is(err, jobs.ErrNotRetryable):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
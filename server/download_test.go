@@ -0,0 +1,121 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/downloader"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/jobs"
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// progressDownloader reports fixed progress and then fails.
+type progressDownloader struct {
+	downloader.MockDownloader
+	steps []float64
+}
+
+func (d *progressDownloader) DownloadVideo(ctx context.Context, _ string, _ *slog.Logger) (string, *downloader.Metadata, error) {
+	for _, pct := range d.steps {
+		downloader.ReportProgress(ctx, downloader.Progress{Percent: pct})
+	}
+	return "", nil, errors.New("network down")
+}
+
+func TestDownloadSongPublishesProgress(t *testing.T) {
+	s := &Server{
+		logger:     log.NewNoOpLogger(),
+		bus:        events.NewBus(0),
+		downloader: &progressDownloader{steps: []float64{10.2, 10.8, 55}},
+	}
+	evs := s.bus.Subscribe(events.DownloadProgress).C
+
+	_, err := s.downloadSong(context.Background(), "https://youtu.be/x", true)
+	require.Error(t, err)
+
+	var got []events.Download
+	for range 4 {
+		got = append(got, (<-evs).Data.(events.Download))
+	}
+	assert.Equal(t, events.DownloadStarted, got[0].State)
+	assert.Equal(t, events.DownloadRunning, got[1].State)
+	assert.InDelta(t, 10.2, got[1].Percent, 0.001)
+	assert.InDelta(t, 55, got[2].Percent, 0.001) // 10.8 is the same whole percent
+	assert.Equal(t, events.DownloadFailed, got[3].State)
+	assert.Equal(t, "network down", got[3].Error)
+	assert.Empty(t, evs)
+	assert.Empty(t, s.downloads.list())
+}
+
+func TestDownloadTrackerKeepsInFlight(t *testing.T) {
+	var tr downloadTracker
+	tr.update(events.Download{URL: "a", State: events.DownloadStarted})
+	tr.update(events.Download{URL: "b", State: events.DownloadStarted})
+	tr.update(events.Download{URL: "a", State: events.DownloadRunning, Percent: 40})
+	assert.Equal(t, []events.Download{
+		{URL: "a", State: events.DownloadRunning, Percent: 40},
+		{URL: "b", State: events.DownloadStarted},
+	}, tr.list())
+
+	tr.update(events.Download{URL: "a", State: events.DownloadFinished})
+	tr.update(events.Download{URL: "c", State: events.DownloadFailed})
+	assert.Equal(t, []events.Download{{URL: "b", State: events.DownloadStarted}}, tr.list())
+}
+
+// startJobs runs a download queue for s until the test ends.
+func startJobs(t *testing.T, s *Server) {
+	t.Helper()
+	s.jobs = jobs.New(s.db, s.runDownloadJob, jobs.Config{Events: s.bus, OnFinish: s.downloadJobFinished}, s.logger)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.jobs.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestDownloadJobHandlers(t *testing.T) {
+	mockDB := &db.MockDB{}
+	s := &Server{logger: log.NewNoOpLogger(), db: mockDB}
+	s.jobs = jobs.New(mockDB, s.runDownloadJob, jobs.Config{}, s.logger)
+	job, err := s.jobs.Enqueue("https://youtu.be/x", "", false)
+	require.NoError(t, err)
+
+	post := func(handler http.HandlerFunc, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/downloads/"+id, nil)
+		req.SetPathValue("job_id", id)
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	w := post(s.RetryDownloadHandler, job.ID)
+	assert.Contains(t, w.Body.String(), jobs.ErrNotRetryable.Error())
+
+	w = post(s.CancelDownloadHandler, job.ID)
+	var got model.DownloadJob
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, model.JobFailed, got.State)
+
+	w = post(s.RetryDownloadHandler, job.ID)
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Equal(t, model.JobQueued, got.State)
+
+	assert.Equal(t, http.StatusNotFound, jobErrorCode(fmt.Errorf("x|%w", db.ErrNotFound)))
+	assert.Equal(t, http.StatusConflict, jobErrorCode(jobs.ErrNotCancellable))
+}
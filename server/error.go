@@ -5,7 +5,10 @@ import (
 	"net/http"
 )
 
+// httpError answers with code and err as a plain-text body.
 func (s *Server) httpError(w http.ResponseWriter, err error, code int) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(code)
 	_, _ = fmt.Fprintf(w, "%s", err)
 	if code >= 400 && code < 500 {
 		s.logger.Warn("", "err", err)
@@ -0,0 +1,22 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPErrorSetsStatus(t *testing.T) {
+	s := &Server{logger: log.NewNoOpLogger()}
+	for _, code := range []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError} {
+		w := httptest.NewRecorder()
+		s.httpError(w, errors.New("boom"), code)
+		assert.Equal(t, code, w.Code)
+		assert.Equal(t, "boom", w.Body.String())
+		assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	}
+}
@@ -3,64 +3,92 @@ package server
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jaredwarren/rpi_music/events"
 )
 
-// notifyEvent is sent to browser clients over SSE.
-type notifyEvent struct {
-	Title string `json:"title"`
-	Body  string `json:"body"`
+// notify publishes a notification for browser clients.
+func (s *Server) notify(title, body string) {
+	s.bus.Publish(events.Notification, events.Notice{Title: title, Body: body})
 }
 
-// notifyBroadcast sends a notification to all connected SSE clients.
-func (s *Server) notifyBroadcast(title, body string) {
-	ev := notifyEvent{Title: title, Body: body}
-	s.notifySubsMu.Lock()
-	defer s.notifySubsMu.Unlock()
-	for ch := range s.notifySubs {
-		select {
-		case ch <- ev:
-		default:
+// EventsSSE streams bus events to the browser. The query parameter "types"
+// takes a comma-separated list of event types to receive; all types are sent
+// without it. Clients reconnecting with Last-Event-ID (or "last_event_id")
+// first get the events they missed that are still in the bus history.
+func (s *Server) EventsSSE(w http.ResponseWriter, r *http.Request) {
+	var types []events.Type
+	for _, t := range strings.Split(r.URL.Query().Get("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, events.Type(t))
 		}
 	}
-}
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+
+	var (
+		sub    *events.Subscription
+		missed []events.Event
+	)
+	if lastID != "" {
+		id, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			s.httpError(w, fmt.Errorf("EventsSSE|Last-Event-ID|%w", err), http.StatusBadRequest)
+			return
+		}
+		sub, missed = s.bus.SubscribeSince(id, types...)
+	} else {
+		sub = s.bus.Subscribe(types...)
+	}
+	defer sub.Close()
 
-// EventsSSE streams server-sent events for browser Web Notifications.
-func (s *Server) EventsSSE(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("X-Accel-Buffering", "no")
-	if flusher, ok := w.(http.Flusher); ok {
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
 		flusher.Flush()
 	}
 
-	ch := make(chan notifyEvent, 8)
-	s.notifySubsMu.Lock()
-	s.notifySubs[ch] = struct{}{}
-	s.notifySubsMu.Unlock()
-
-	defer func() {
-		s.notifySubsMu.Lock()
-		delete(s.notifySubs, ch)
-		s.notifySubsMu.Unlock()
-		close(ch)
-	}()
-
-	enc := json.NewEncoder(w)
+	for _, ev := range missed {
+		writeSSE(w, ev)
+	}
+	if flusher != nil && len(missed) > 0 {
+		flusher.Flush()
+	}
 	for {
 		select {
 		case <-r.Context().Done():
 			return
 		case <-s.ctx.Done():
 			return
-		case ev := <-ch:
-			_, _ = fmt.Fprint(w, "event: notification\ndata: ")
-			_ = enc.Encode(ev)
-			_, _ = fmt.Fprint(w, "\n")
-			if flusher, ok := w.(http.Flusher); ok {
+		case ev, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			writeSSE(w, ev)
+			if flusher != nil {
 				flusher.Flush()
 			}
 		}
 	}
 }
+
+// writeSSE writes ev as one server-sent event with its bus ID.
+func writeSSE(w io.Writer, ev events.Event) {
+	_, _ = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: ", ev.ID, ev.Type)
+	_ = json.NewEncoder(w).Encode(ev.Data)
+	_, _ = fmt.Fprint(w, "\n")
+}
+
+// libraryChanged publishes a change to the song library or card mappings.
+func (s *Server) libraryChanged(action, songID, rfid string) {
+	s.bus.Publish(events.LibraryChanged, events.LibraryChange{Action: action, SongID: songID, RFID: rfid})
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventsSSEReplaysFilteredHistory(t *testing.T) {
+	s := &Server{ctx: context.Background(), logger: log.NewNoOpLogger(), bus: events.NewBus(8)}
+	s.notify("First", "one")
+	s.bus.Publish(events.PlayerStopped, nil)
+	s.notify("Second", "two")
+
+	// A cancelled request returns once the missed events are written.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/events?types=notification", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	w := httptest.NewRecorder()
+	s.EventsSSE(w, req)
+
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "id: 3\nevent: notification\ndata: {\"title\":\"Second\",\"body\":\"two\"}\n\n", w.Body.String())
+}
+
+func TestEventsSSEBadLastEventID(t *testing.T) {
+	s := &Server{ctx: context.Background(), logger: log.NewNoOpLogger(), bus: events.NewBus(8)}
+	req := httptest.NewRequest(http.MethodGet, "/events?last_event_id=x", nil)
+	w := httptest.NewRecorder()
+	s.EventsSSE(w, req)
+	assert.Contains(t, w.Body.String(), "Last-Event-ID")
+}
@@ -0,0 +1,416 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/feeds"
+	"github.com/jaredwarren/rpi_music/jobs"
+	"github.com/jaredwarren/rpi_music/model"
+)
+
+// Feed polling defaults for a zero config.
+const (
+	defaultFeedPollInterval = time.Hour
+	defaultFeedKeep         = 3
+)
+
+// AddFeedHandler subscribes to the podcast feed in the "url" form value and
+// downloads its newest episodes. "rfid" names the feed card and "keep" how
+// many episodes stay in the library. Adding a feed again updates it.
+func (s *Server) AddFeedHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.httpError(w, fmt.Errorf("AddFeedHandler|ParseForm|%w", err), http.StatusBadRequest)
+		return
+	}
+	url := r.PostForm.Get("url")
+	if url == "" {
+		s.httpError(w, fmt.Errorf("AddFeedHandler|url required"), http.StatusBadRequest)
+		return
+	}
+	keep := 0
+	if v := r.PostForm.Get("keep"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s.httpError(w, fmt.Errorf("AddFeedHandler|invalid keep %q", v), http.StatusBadRequest)
+			return
+		}
+		keep = n
+	}
+	rfid := normalizeRFID(r.PostForm.Get("rfid"))
+	if rfid != "" {
+		if _, err := s.db.GetCommandCard(rfid); err == nil {
+			s.httpError(w, fmt.Errorf("AddFeedHandler|card %s is a command card", rfid), http.StatusConflict)
+			return
+		} else if !errors.Is(err, db.ErrNotFound) {
+			s.httpError(w, fmt.Errorf("AddFeedHandler|GetCommandCard|%w", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	f, err := s.feedByURL(url)
+	if err != nil {
+		s.httpError(w, fmt.Errorf("AddFeedHandler|%w", err), http.StatusInternalServerError)
+		return
+	}
+	if f == nil {
+		f = &model.Feed{ID: uuid.New().String(), URL: url}
+	}
+	if rfid != "" {
+		f.RFID = rfid
+	}
+	if keep > 0 {
+		f.Keep = keep
+	}
+	if _, err := s.syncFeed(r.Context(), f); err != nil {
+		s.httpError(w, fmt.Errorf("AddFeedHandler|%w", err), feedErrorCode(err))
+		return
+	}
+	http.Redirect(w, r, "/downloads", http.StatusFound)
+}
+
+// ListFeedsHandler returns the podcast subscriptions as JSON.
+func (s *Server) ListFeedsHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := s.db.ListFeeds()
+	if err != nil {
+		s.httpError(w, fmt.Errorf("ListFeedsHandler|ListFeeds|%w", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, list)
+}
+
+// SyncFeedHandler checks a feed for new episodes now.
+func (s *Server) SyncFeedHandler(w http.ResponseWriter, r *http.Request) {
+	f, err := s.db.GetFeed(r.PathValue("feed_id"))
+	if err != nil {
+		s.httpError(w, fmt.Errorf("SyncFeedHandler|GetFeed|%w", err), feedErrorCode(err))
+		return
+	}
+	f, err = s.syncFeed(r.Context(), f)
+	if err != nil {
+		s.httpError(w, fmt.Errorf("SyncFeedHandler|%w", err), feedErrorCode(err))
+		return
+	}
+	writeJSON(w, f)
+}
+
+// DeleteFeedHandler unsubscribes from a feed and cancels its episodes'
+// downloads. Downloaded episodes stay in the library, and the feed's card
+// keeps the episode it has but is no longer moved on to new ones.
+func (s *Server) DeleteFeedHandler(w http.ResponseWriter, r *http.Request) {
+	s.feedMu.Lock()
+	defer s.feedMu.Unlock()
+	f, err := s.db.GetFeed(r.PathValue("feed_id"))
+	if err != nil {
+		s.httpError(w, fmt.Errorf("DeleteFeedHandler|GetFeed|%w", err), feedErrorCode(err))
+		return
+	}
+	if err := s.db.DeleteFeed(f.ID); err != nil {
+		s.httpError(w, fmt.Errorf("DeleteFeedHandler|DeleteFeed|%w", err), http.StatusInternalServerError)
+		return
+	}
+	for _, e := range f.Episodes {
+		if e.SongID == "" {
+			s.cancelEpisodeJob(e)
+		}
+	}
+	writeJSON(w, map[string]bool{"ok": true})
+}
+
+func feedErrorCode(err error) int {
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, feeds.ErrNotFeed):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (s *Server) feedByURL(url string) (*model.Feed, error) {
+	list, err := s.db.ListFeeds()
+	if err != nil {
+		return nil, fmt.Errorf("ListFeeds|%w", err)
+	}
+	for _, f := range list {
+		if f.URL == url {
+			return f, nil
+		}
+	}
+	return nil, nil
+}
+
+// pollFeeds syncs every feed now and then on each poll interval until ctx
+// is done.
+func (s *Server) pollFeeds(ctx context.Context) {
+	interval := defaultFeedPollInterval
+	if s.cfg != nil && s.cfg.Feeds.PollInterval.Duration > 0 {
+		interval = s.cfg.Feeds.PollInterval.Duration
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		list, err := s.db.ListFeeds()
+		if err != nil {
+			s.logger.Error("pollFeeds|ListFeeds", "err", err)
+		}
+		for _, f := range list {
+			// Feeds deleted since ListFeeds are not an error.
+			if _, err := s.syncFeed(ctx, f); err != nil && !errors.Is(err, db.ErrNotFound) {
+				s.logger.Error("pollFeeds|syncFeed", "feed", f.URL, "err", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) feedKeep(f *model.Feed) int {
+	if f.Keep > 0 {
+		return f.Keep
+	}
+	if s.cfg != nil && s.cfg.Feeds.Keep > 0 {
+		return s.cfg.Feeds.Keep
+	}
+	return defaultFeedKeep
+}
+
+// syncFeed fetches f, queues downloads for its newest episodes that are not
+// in the library, drops older ones and points its card at the newest
+// downloaded episode.
+func (s *Server) syncFeed(ctx context.Context, f *model.Feed) (*model.Feed, error) {
+	fetched, err := feeds.Fetch(ctx, f.URL)
+	if err != nil {
+		return nil, fmt.Errorf("Fetch|%w", err)
+	}
+
+	s.feedMu.Lock()
+	defer s.feedMu.Unlock()
+	// Keep song IDs recorded since f was read. A feed synced before but gone
+	// now was deleted during the fetch, and stays deleted.
+	if saved, err := s.db.GetFeed(f.ID); err == nil {
+		f.Episodes = saved.Episodes
+	} else if !errors.Is(err, db.ErrNotFound) || !f.CheckedAt.IsZero() {
+		return nil, fmt.Errorf("GetFeed|%w", err)
+	}
+	known := make(map[string]bool, len(f.Episodes))
+	for _, e := range f.Episodes {
+		known[e.GUID] = true
+	}
+	for _, e := range fetched.Episodes {
+		if !known[e.GUID] {
+			f.Episodes = append(f.Episodes, model.FeedEpisode{GUID: e.GUID, Title: e.Title, URL: e.URL, Published: e.Published})
+		}
+	}
+	sort.SliceStable(f.Episodes, func(i, j int) bool {
+		return f.Episodes[i].Published.After(f.Episodes[j].Published)
+	})
+	f.Title = fetched.Title
+	f.CheckedAt = time.Now()
+
+	byURL, err := s.songIDsByURL()
+	if err != nil {
+		return nil, err
+	}
+	for i, e := range f.Episodes {
+		if e.SongID == "" {
+			f.Episodes[i].SongID = byURL[e.URL]
+		}
+	}
+	s.pruneFeedLocked(f)
+	for i, e := range f.Episodes {
+		if e.SongID != "" {
+			continue
+		}
+		// Enqueue skips episodes already downloading and retries failed ones.
+		job, err := s.jobs.Enqueue(e.URL, "", true)
+		if err != nil {
+			return nil, fmt.Errorf("Enqueue|%w", err)
+		}
+		f.Episodes[i].JobID = job.ID
+	}
+	if err := s.db.SaveFeed(f); err != nil {
+		return nil, fmt.Errorf("SaveFeed|%w", err)
+	}
+	if err := s.syncFeedCardLocked(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// pruneFeedLocked keeps f's newest downloaded episodes, and any newer ones
+// still downloading, dropping the rest.
+func (s *Server) pruneFeedLocked(f *model.Feed) {
+	keep := s.feedKeep(f)
+	kept := 0
+	out := f.Episodes[:0]
+	for _, e := range f.Episodes {
+		switch {
+		case kept < keep && e.SongID != "":
+			kept++
+			out = append(out, e)
+		case kept < keep && len(out) < keep:
+			out = append(out, e)
+		default:
+			s.dropEpisode(e)
+		}
+	}
+	f.Episodes = out
+}
+
+// dropEpisode cancels a dropped episode's download, or deletes its song if the
+// feed downloaded it. Songs that were already in the library stay.
+func (s *Server) dropEpisode(e model.FeedEpisode) {
+	switch {
+	case e.SongID != "" && e.Downloaded:
+		s.deleteEpisodeSong(e.SongID)
+	case e.SongID == "":
+		s.cancelEpisodeJob(e)
+	}
+}
+
+// cancelEpisodeJob cancels the download queued for an episode, if any.
+func (s *Server) cancelEpisodeJob(e model.FeedEpisode) {
+	if e.JobID == "" {
+		return
+	}
+	// The job may already have finished, or been pruned since.
+	if _, err := s.jobs.Cancel(e.JobID); err != nil && !errors.Is(err, jobs.ErrNotCancellable) && !errors.Is(err, db.ErrNotFound) {
+		s.logger.Error("cancelEpisodeJob|Cancel", "job", e.JobID, "err", err)
+	}
+}
+
+// deleteEpisodeSong removes a dropped episode and its files.
+func (s *Server) deleteEpisodeSong(songID string) {
+	song, err := s.db.GetSong(songID)
+	if err != nil || song == nil {
+		// Still drop the library entry; only the files are left behind.
+		s.logger.Warn("deleteEpisodeSong|GetSong", "song", songID, "err", err)
+		song = &model.Song{}
+	}
+	if err := s.db.DeleteSong(songID); err != nil {
+		s.logger.Error("deleteEpisodeSong|DeleteSong", "song", songID, "err", err)
+		return
+	}
+	for _, path := range []string{song.FilePath, song.Thumbnail} {
+		if path == "" {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.logger.Warn("deleteEpisodeSong|Remove", "path", path, "err", err)
+		}
+	}
+	s.libraryChanged(events.SongDeleted, songID, "")
+}
+
+// syncFeedCardLocked makes f's newest downloaded episode the only song on
+// its card.
+func (s *Server) syncFeedCardLocked(f *model.Feed) error {
+	if f.RFID == "" {
+		return nil
+	}
+	var newest string
+	for _, e := range f.Episodes {
+		if e.SongID != "" {
+			newest = e.SongID
+			break
+		}
+	}
+	if newest == "" {
+		return nil
+	}
+	card, err := s.db.GetRFIDSong(f.RFID)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("GetRFIDSong|%w", err)
+	}
+	onCard := false
+	if card != nil {
+		for _, id := range card.Songs {
+			if id == newest {
+				onCard = true
+				continue
+			}
+			if err := s.db.RemoveRFIDSong(f.RFID, id); err != nil {
+				return fmt.Errorf("RemoveRFIDSong|%w", err)
+			}
+			s.libraryChanged(events.CardRemoved, id, f.RFID)
+		}
+	}
+	if !onCard {
+		if err := s.db.AddRFIDSong(f.RFID, newest); err != nil {
+			return fmt.Errorf("AddRFIDSong|%w", err)
+		}
+		s.libraryChanged(events.CardAssigned, newest, f.RFID)
+	}
+	return nil
+}
+
+// feedJobFinished records a downloaded episode, names the song after it and
+// moves the feed's card to it if it is the newest.
+func (s *Server) feedJobFinished(job *model.DownloadJob) {
+	if job.State != model.JobDone || job.SongID == "" {
+		return
+	}
+	s.feedMu.Lock()
+	defer s.feedMu.Unlock()
+	list, err := s.db.ListFeeds()
+	if err != nil {
+		s.logger.Error("feedJobFinished|ListFeeds", "err", err)
+		return
+	}
+	for _, f := range list {
+		i := -1
+		for j, e := range f.Episodes {
+			if e.URL == job.URL && e.SongID == "" {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			continue
+		}
+		f.Episodes[i].SongID = job.SongID
+		f.Episodes[i].Downloaded = f.Episodes[i].JobID == job.ID
+		s.nameEpisodeSong(f, f.Episodes[i])
+		s.pruneFeedLocked(f)
+		if err := s.db.SaveFeed(f); err != nil {
+			s.logger.Error("feedJobFinished|SaveFeed", "feed", f.ID, "err", err)
+			continue
+		}
+		if err := s.syncFeedCardLocked(f); err != nil {
+			s.logger.Error("feedJobFinished|syncFeedCard", "feed", f.ID, "err", err)
+		}
+	}
+}
+
+// nameEpisodeSong titles an episode's song from the feed, which names
+// episodes better than their audio file names do.
+func (s *Server) nameEpisodeSong(f *model.Feed, e model.FeedEpisode) {
+	song, err := s.db.GetSong(e.SongID)
+	if err != nil || song == nil {
+		s.logger.Warn("nameEpisodeSong|GetSong", "song", e.SongID, "err", err)
+		return
+	}
+	if e.Title != "" {
+		song.Title = e.Title
+	}
+	song.Album = f.Title
+	if err := s.db.UpdateSong(song); err != nil {
+		s.logger.Error("nameEpisodeSong|UpdateSong", "song", song.ID, "err", err)
+		return
+	}
+	s.libraryChanged(events.SongUpdated, song.ID, "")
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/downloader"
+	"github.com/jaredwarren/rpi_music/jobs"
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// feedServer serves an RSS feed whose episodes can be changed mid-test.
+type feedServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	episodes []int
+}
+
+func newFeedServer(t *testing.T, episodes ...int) *feedServer {
+	fs := &feedServer{episodes: episodes}
+	fs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fs.mu.Lock()
+		defer fs.mu.Unlock()
+		var items strings.Builder
+		for _, n := range fs.episodes {
+			fmt.Fprintf(&items, `<item><title>Episode %d</title><guid>ep-%d</guid>
+<pubDate>%s</pubDate><enclosure url="%s" type="audio/mpeg"/></item>`,
+				n, n, time.Date(2026, 10, n, 0, 0, 0, 0, time.UTC).Format(time.RFC1123Z), fs.episodeURL(n))
+		}
+		fmt.Fprintf(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>Kids Pod</title>%s</channel></rss>`, items.String())
+	}))
+	t.Cleanup(fs.Close)
+	return fs
+}
+
+func (fs *feedServer) episodeURL(n int) string {
+	return fmt.Sprintf("%s/ep%d.mp3", fs.URL, n)
+}
+
+func (fs *feedServer) publish(n int) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.episodes = append(fs.episodes, n)
+}
+
+func TestFeedCardFollowsNewestEpisode(t *testing.T) {
+	fs := newFeedServer(t, 1, 2)
+	dl := &downloader.MockDownloader{Response: map[string]*downloader.Metadata{}}
+	for n := 1; n <= 3; n++ {
+		dl.Response[fs.episodeURL(n)] = &downloader.Metadata{Title: fmt.Sprintf("ep%d.mp3", n)}
+	}
+	mockDB := &db.MockDB{GetRFIDSongErr: db.ErrNotFound, GetSongErr: db.ErrNotFound}
+	var mu sync.Mutex
+	var card []string
+	mockDB.OnAddRFIDSong = func(rfid, songID string) {
+		mu.Lock()
+		defer mu.Unlock()
+		card = append(card, songID)
+		mockDB.GetRFIDSongResult, mockDB.GetRFIDSongErr = &model.RFIDSong{RFID: rfid, Songs: []string{songID}}, nil
+	}
+	cardSongs := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), card...)
+	}
+	s := &Server{ctx: context.Background(), db: mockDB, logger: log.NewNoOpLogger(), downloader: dl}
+	startJobs(t, s)
+
+	req := httptest.NewRequest(http.MethodPost, "/feeds", strings.NewReader("url="+fs.URL+"&rfid=AB:CD&keep=1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	s.AddFeedHandler(w, req)
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+
+	// Only the newest episode is kept, so only it is downloaded.
+	require.Eventually(t, func() bool { return len(cardSongs()) == 1 }, 2*time.Second, time.Millisecond)
+	assert.Equal(t, 1, mockDB.CreateSongCallCount())
+	list, err := mockDB.ListFeeds()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	f := list[0]
+	assert.Equal(t, "Kids Pod", f.Title)
+	require.Len(t, f.Episodes, 1)
+	assert.Equal(t, "ep-2", f.Episodes[0].GUID)
+	assert.Equal(t, cardSongs()[0], f.Episodes[0].SongID)
+	first := f.Episodes[0].SongID
+
+	fs.publish(3)
+	req = httptest.NewRequest(http.MethodPost, "/feeds/"+f.ID+"/sync", nil)
+	req.SetPathValue("feed_id", f.ID)
+	w = httptest.NewRecorder()
+	s.SyncFeedHandler(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	require.Eventually(t, func() bool { return len(cardSongs()) == 2 }, 2*time.Second, time.Millisecond)
+	assert.Equal(t, 2, mockDB.CreateSongCallCount())
+	got, err := mockDB.GetFeed(f.ID)
+	require.NoError(t, err)
+	require.Len(t, got.Episodes, 1)
+	assert.Equal(t, "ep-3", got.Episodes[0].GUID)
+	assert.Equal(t, cardSongs()[1], got.Episodes[0].SongID)
+	assert.Equal(t, []string{first}, mockDB.DeleteSongCalls)
+}
+
+func TestPruneFeed(t *testing.T) {
+	mockDB := &db.MockDB{GetSongErr: db.ErrNotFound}
+	s := &Server{db: mockDB, logger: log.NewNoOpLogger()}
+	s.jobs = jobs.New(mockDB, nil, jobs.Config{}, s.logger)
+	pending, err := s.jobs.Enqueue("https://pod/4.mp3", "", true)
+	require.NoError(t, err)
+
+	f := &model.Feed{Keep: 2, Episodes: []model.FeedEpisode{
+		// 6 to 4 are not downloaded yet.
+		{GUID: "6"},
+		{GUID: "5"},
+		{GUID: "4", JobID: pending.ID},
+		{GUID: "3", SongID: "s3", Downloaded: true},
+		{GUID: "2", SongID: "s2", Downloaded: true},
+		{GUID: "1", SongID: "s1", Downloaded: true},
+		{GUID: "0", SongID: "mine"}, // added by hand before subscribing
+	}}
+	s.pruneFeedLocked(f)
+	var guids []string
+	for _, e := range f.Episodes {
+		guids = append(guids, e.GUID)
+	}
+	// Pending episodes up to Keep, then Keep downloaded ones until newer ones finish.
+	assert.Equal(t, []string{"6", "5", "3", "2"}, guids)
+	assert.Equal(t, []string{"s1"}, mockDB.DeleteSongCalls, "only songs the feed downloaded are deleted")
+	job, err := mockDB.GetJob(pending.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.JobFailed, job.State, "dropped downloads are cancelled")
+}
+
+func TestDeleteFeedCancelsDownloads(t *testing.T) {
+	fs := newFeedServer(t, 1)
+	mockDB := &db.MockDB{GetRFIDSongErr: db.ErrNotFound, GetSongErr: db.ErrNotFound}
+	s := &Server{ctx: context.Background(), db: mockDB, logger: log.NewNoOpLogger()}
+	// The queue is not running, so the episode's download stays queued.
+	s.jobs = jobs.New(mockDB, nil, jobs.Config{}, s.logger)
+
+	req := httptest.NewRequest(http.MethodPost, "/feeds", strings.NewReader("url="+fs.URL+"&rfid=AB:CD"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	s.AddFeedHandler(w, req)
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+	list, err := mockDB.ListFeeds()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	f := list[0]
+	require.Len(t, f.Episodes, 1)
+	jobID := f.Episodes[0].JobID
+	require.NotEmpty(t, jobID)
+
+	req = httptest.NewRequest(http.MethodDelete, "/feeds/"+f.ID, nil)
+	req.SetPathValue("feed_id", f.ID)
+	w = httptest.NewRecorder()
+	s.DeleteFeedHandler(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	job, err := mockDB.GetJob(jobID)
+	require.NoError(t, err)
+	assert.Equal(t, model.JobFailed, job.State, "the queued download is cancelled")
+	_, err = mockDB.GetFeed(f.ID)
+	require.ErrorIs(t, err, db.ErrNotFound)
+
+	// A refresh that read the feed before it was deleted does not bring it back.
+	_, err = s.syncFeed(context.Background(), f)
+	require.ErrorIs(t, err, db.ErrNotFound)
+	_, err = mockDB.GetFeed(f.ID)
+	require.ErrorIs(t, err, db.ErrNotFound)
+
+	w = httptest.NewRecorder()
+	s.DeleteFeedHandler(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/rfid"
+)
+
+// DefaultLearnTimeout is how long learn mode waits for a card to be scanned.
+const DefaultLearnTimeout = 30 * time.Second
+
+// Learn mode results reported when it ends.
+const (
+	learnAssigned  = "assigned"
+	learnTimeout   = "timeout"
+	learnCancelled = "cancelled"
+	learnError     = "error"
+)
+
+// learnStatus is the JSON shape of the learn mode endpoints and of the
+// "learn" SSE event.
+type learnStatus struct {
+	Active           bool    `json:"active"`
+	SongID           string  `json:"song_id,omitempty"`
+	RemainingSeconds float64 `json:"remaining_seconds"`
+	Result           string  `json:"result,omitempty"` // why learn mode ended
+	RFID             string  `json:"rfid,omitempty"`
+	Error            string  `json:"error,omitempty"`
+}
+
+// learnMode assigns the next scanned card to songID until its deadline.
+type learnMode struct {
+	songID   string
+	deadline time.Time
+	timer    *time.Timer
+}
+
+func (m *learnMode) status() learnStatus {
+	if m == nil {
+		return learnStatus{}
+	}
+	return learnStatus{Active: true, SongID: m.songID, RemainingSeconds: time.Until(m.deadline).Seconds()}
+}
+
+// startLearn arms learn mode for songID, replacing any song already waiting.
+func (s *Server) startLearn(songID string, timeout time.Duration) learnStatus {
+	m := &learnMode{songID: songID, deadline: time.Now().Add(timeout)}
+	s.learnMu.Lock()
+	old := s.learn
+	s.learn = m
+	m.timer = time.AfterFunc(timeout, func() {
+		if s.takeLearn(m) != nil {
+			s.bus.Publish(events.LearnChanged, learnStatus{SongID: songID, Result: learnTimeout})
+		}
+	})
+	s.learnMu.Unlock()
+	if old != nil {
+		old.timer.Stop()
+	}
+	st := m.status()
+	s.bus.Publish(events.LearnChanged, st)
+	return st
+}
+
+// takeLearn disarms learn mode and returns what was armed. If m is not nil it
+// only disarms m, so a late timer cannot end a newer learn mode.
+func (s *Server) takeLearn(m *learnMode) *learnMode {
+	s.learnMu.Lock()
+	defer s.learnMu.Unlock()
+	cur := s.learn
+	if cur == nil || m != nil && cur != m {
+		return nil
+	}
+	s.learn = nil
+	cur.timer.Stop()
+	return cur
+}
+
+// CaptureTag assigns a scanned card to the song waiting in learn mode. It
+// reports whether the event was consumed, in which case the RFID loop must
+// not play the card.
+func (s *Server) CaptureTag(ev rfid.Event) bool {
+	if ev.Kind != rfid.Present {
+		return false
+	}
+	m := s.takeLearn(nil)
+	if m == nil {
+		return false
+	}
+	st := learnStatus{SongID: m.songID, RFID: ev.UID, Result: learnAssigned}
+	if err := s.assignScanned(ev.UID, m.songID); err != nil {
+		s.logger.Error("CaptureTag|assignScanned", "err", err)
+		st.Result, st.Error = learnError, err.Error()
+	}
+	s.bus.Publish(events.LearnChanged, st)
+	return true
+}
+
+// assignScanned maps an unused card to songID.
+func (s *Server) assignScanned(uid, songID string) error {
+	if _, err := s.db.GetCommandCard(uid); err == nil {
+		return fmt.Errorf("card %s is a command card", uid)
+	} else if !errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("GetCommandCard|%w", err)
+	}
+	existing, err := s.db.GetRFIDSong(uid)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("GetRFIDSong|%w", err)
+	}
+	if existing != nil {
+		return fmt.Errorf("card %s is already assigned", uid)
+	}
+	if err := s.db.AddRFIDSong(uid, songID); err != nil {
+		return fmt.Errorf("AddRFIDSong|%w", err)
+	}
+	s.libraryChanged(events.CardAssigned, songID, uid)
+	return nil
+}
+
+// LearnStatusHandler reports whether learn mode is waiting for a card.
+func (s *Server) LearnStatusHandler(w http.ResponseWriter, r *http.Request) {
+	s.learnMu.Lock()
+	st := s.learn.status()
+	s.learnMu.Unlock()
+	writeJSON(w, st)
+}
+
+// StartLearnHandler arms learn mode so the next scanned card is assigned to
+// the song. The optional form value "seconds" overrides DefaultLearnTimeout.
+func (s *Server) StartLearnHandler(w http.ResponseWriter, r *http.Request) {
+	song, ok := s.getSongFromPath(w, r, "song_id")
+	if !ok {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		s.httpError(w, fmt.Errorf("StartLearnHandler|ParseForm|%w", err), http.StatusBadRequest)
+		return
+	}
+	timeout := DefaultLearnTimeout
+	if v := r.PostForm.Get("seconds"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds <= 0 {
+			s.httpError(w, fmt.Errorf("seconds must be a positive number"), http.StatusBadRequest)
+			return
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+	writeJSON(w, s.startLearn(song.ID, timeout))
+}
+
+// CancelLearnHandler leaves learn mode without assigning a card.
+func (s *Server) CancelLearnHandler(w http.ResponseWriter, r *http.Request) {
+	if m := s.takeLearn(nil); m != nil {
+		s.bus.Publish(events.LearnChanged, learnStatus{SongID: m.songID, Result: learnCancelled})
+	}
+	writeJSON(w, learnStatus{})
+}
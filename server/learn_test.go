@@ -0,0 +1,96 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/jaredwarren/rpi_music/rfid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLearnServer(t *testing.T, mockDB *db.MockDB) (*Server, <-chan events.Event) {
+	t.Helper()
+	s := &Server{db: mockDB, logger: log.NewNoOpLogger(), bus: events.NewBus(0)}
+	evs := s.bus.Subscribe(events.LearnChanged).C
+	t.Cleanup(func() { s.takeLearn(nil) })
+	return s, evs
+}
+
+func startLearnRequest(s *Server, seconds string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/song/song-1/rfid/learn", strings.NewReader(url.Values{"seconds": {seconds}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetPathValue("song_id", "song-1")
+	w := httptest.NewRecorder()
+	s.StartLearnHandler(w, req)
+	return w
+}
+
+func TestLearnAssignsNextScan(t *testing.T) {
+	mockDB := &db.MockDB{GetSongResult: &model.Song{ID: "song-1"}, GetRFIDSongErr: db.ErrNotFound}
+	s, evs := newLearnServer(t, mockDB)
+
+	assert.False(t, s.CaptureTag(rfid.Event{UID: "aa"}), "nothing captured before learn mode is armed")
+
+	w := startLearnRequest(s, "")
+	assert.Contains(t, w.Body.String(), `"active":true`)
+	assert.True(t, (<-evs).Data.(learnStatus).Active)
+
+	assert.False(t, s.CaptureTag(rfid.Event{UID: "bb", Kind: rfid.Removed}))
+	assert.True(t, s.CaptureTag(rfid.Event{UID: "cc"}))
+	assert.Equal(t, learnStatus{SongID: "song-1", RFID: "cc", Result: learnAssigned}, (<-evs).Data)
+	last, ok := mockDB.LastAddRFIDSongCall()
+	require.True(t, ok)
+	assert.Equal(t, db.AddRFIDSongCall{RFID: "cc", SongID: "song-1"}, last)
+
+	assert.False(t, s.CaptureTag(rfid.Event{UID: "dd"}), "learn mode ends after one card")
+}
+
+func TestLearnRejectsAssignedCard(t *testing.T) {
+	mockDB := &db.MockDB{
+		GetSongResult:     &model.Song{ID: "song-1"},
+		GetRFIDSongResult: &model.RFIDSong{RFID: "cc", Songs: []string{"other"}},
+	}
+	s, evs := newLearnServer(t, mockDB)
+	startLearnRequest(s, "")
+	<-evs
+
+	assert.True(t, s.CaptureTag(rfid.Event{UID: "cc"}))
+	st := (<-evs).Data.(learnStatus)
+	assert.Equal(t, learnError, st.Result)
+	assert.Contains(t, st.Error, "already assigned")
+	assert.Zero(t, mockDB.AddRFIDSongCallCount())
+}
+
+func TestLearnTimeoutAndCancel(t *testing.T) {
+	s, evs := newLearnServer(t, &db.MockDB{GetSongResult: &model.Song{ID: "song-1"}})
+
+	s.startLearn("song-1", 10*time.Millisecond)
+	<-evs
+	select {
+	case ev := <-evs:
+		assert.Equal(t, learnStatus{SongID: "song-1", Result: learnTimeout}, ev.Data)
+	case <-time.After(time.Second):
+		t.Fatal("learn mode did not time out")
+	}
+	assert.False(t, s.CaptureTag(rfid.Event{UID: "cc"}))
+
+	startLearnRequest(s, "60")
+	<-evs
+	w := httptest.NewRecorder()
+	s.CancelLearnHandler(w, httptest.NewRequest(http.MethodPost, "/rfid/learn/cancel", nil))
+	assert.Equal(t, learnStatus{SongID: "song-1", Result: learnCancelled}, (<-evs).Data)
+	assert.False(t, s.CaptureTag(rfid.Event{UID: "cc"}))
+
+	w = httptest.NewRecorder()
+	s.LearnStatusHandler(w, httptest.NewRequest(http.MethodGet, "/rfid/learn", nil))
+	assert.Contains(t, w.Body.String(), `"active":false`)
+}
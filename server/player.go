@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"strconv"
 
 	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/jaredwarren/rpi_music/player"
 )
 
 // PlayerHandler renders the player status page.
@@ -14,10 +17,49 @@ func (s *Server) PlayerHandler(w http.ResponseWriter, r *http.Request) {
 	s.render(w, r, s.templates["player"], map[string]any{
 		"Player":    s.player,
 		"Song":      s.player.GetPlaying(),
+		"Queue":     s.player.Queue(),
 		TemplateTag: template.HTML(""),
 	})
 }
 
+// playerStatus is the JSON shape of GET /player/status.
+type playerStatus struct {
+	Song            *model.Song `json:"song"`
+	Playing         bool        `json:"playing"`
+	Paused          bool        `json:"paused"`
+	ElapsedSeconds  float64     `json:"elapsed_seconds"`
+	DurationSeconds float64     `json:"duration_seconds"`
+	QueuePosition   int         `json:"queue_position"`
+	QueueLength     int         `json:"queue_length"`
+	Loop            string      `json:"loop"`
+	NowPlaying      string      `json:"now_playing,omitempty"`
+	Chapter         string      `json:"chapter,omitempty"`
+	Volume          int         `json:"volume"`
+}
+
+// PlayerStatusHandler reports the current song and how far into it playback is.
+func (s *Server) PlayerStatusHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.currentStatus())
+}
+
+// currentStatus snapshots the player for JSON clients.
+func (s *Server) currentStatus() playerStatus {
+	st := s.player.Status()
+	return playerStatus{
+		Song:            st.Song,
+		Playing:         st.Playing,
+		Paused:          st.Paused,
+		ElapsedSeconds:  st.Elapsed.Seconds(),
+		DurationSeconds: st.Duration.Seconds(),
+		QueuePosition:   st.QueuePosition,
+		QueueLength:     st.QueueLength,
+		Loop:            string(st.Loop),
+		NowPlaying:      st.NowPlaying,
+		Chapter:         st.Chapter,
+		Volume:          s.player.Volume(),
+	}
+}
+
 // PlaySongHandler looks up the song by ID and starts playback.
 func (s *Server) PlaySongHandler(w http.ResponseWriter, r *http.Request) {
 	songID := r.PathValue("song_id")
@@ -31,14 +73,14 @@ func (s *Server) PlaySongHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if song.FilePath == "" {
+	if song.Location() == "" {
 		s.player.Error()
 		s.httpError(w, fmt.Errorf("song has no file"), http.StatusBadRequest)
 		return
 	}
 
 	s.player.Beep()
-	if err := s.player.Play(song); err != nil {
+	if err := s.player.Play(song); err != nil && !errors.Is(err, player.ErrBusy) {
 		s.httpError(w, fmt.Errorf("PlaySongHandler|Play|%w", err), http.StatusInternalServerError)
 		return
 	}
@@ -46,6 +88,115 @@ func (s *Server) PlaySongHandler(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/songs", http.StatusFound)
 }
 
+// PlayRFIDHandler queues every song on a card and starts playback, as if the card was tapped.
+func (s *Server) PlayRFIDHandler(w http.ResponseWriter, r *http.Request) {
+	rfid := r.PathValue("rfid")
+	if rfid == "" {
+		s.httpError(w, fmt.Errorf("rfid required"), http.StatusBadRequest)
+		return
+	}
+	rfidSong, err := s.db.GetRFIDSong(rfid)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			s.httpError(w, fmt.Errorf("rfid not found"), http.StatusNotFound)
+			return
+		}
+		s.httpError(w, fmt.Errorf("PlayRFIDHandler|GetRFIDSong|%w", err), http.StatusInternalServerError)
+		return
+	}
+
+	songs := make([]*model.Song, 0, len(rfidSong.Songs))
+	for _, id := range rfidSong.Songs {
+		song, err := s.db.GetSong(id)
+		if err != nil {
+			s.logger.Error("PlayRFIDHandler|GetSong", "song", id, "err", err)
+			continue
+		}
+		songs = append(songs, song)
+	}
+	if len(songs) == 0 {
+		s.player.Error()
+		s.httpError(w, fmt.Errorf("rfid has no song"), http.StatusBadRequest)
+		return
+	}
+
+	// Refused while another card plays: the player has already sounded the error.
+	if _, err := s.player.PlayCard(songs, rfidSong.Playback); err != nil && !errors.Is(err, player.ErrBusy) {
+		s.httpError(w, fmt.Errorf("PlayRFIDHandler|PlayCard|%w", err), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, "/rfids", http.StatusFound)
+}
+
+// NextSongHandler skips to the next song in the queue.
+func (s *Server) NextSongHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.player.Next(); err != nil && !errors.Is(err, player.ErrNoQueue) {
+		s.httpError(w, fmt.Errorf("NextSongHandler|Next|%w", err), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/songs", http.StatusFound)
+}
+
+// PreviousSongHandler goes back to the previous song in the queue.
+func (s *Server) PreviousSongHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.player.Previous(); err != nil && !errors.Is(err, player.ErrNoQueue) {
+		s.httpError(w, fmt.Errorf("PreviousSongHandler|Previous|%w", err), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/songs", http.StatusFound)
+}
+
+// PauseSongHandler pauses the current song, keeping its position.
+func (s *Server) PauseSongHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.player.Pause(); err != nil && !errors.Is(err, player.ErrNotPlaying) {
+		s.httpError(w, fmt.Errorf("PauseSongHandler|Pause|%w", err), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/songs", http.StatusFound)
+}
+
+// ResumeSongHandler resumes a paused song.
+func (s *Server) ResumeSongHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.player.Resume(); err != nil && !errors.Is(err, player.ErrNotPlaying) {
+		s.httpError(w, fmt.Errorf("ResumeSongHandler|Resume|%w", err), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/songs", http.StatusFound)
+}
+
+// VolumeUpHandler raises the live volume by player.VolumeStep.
+func (s *Server) VolumeUpHandler(w http.ResponseWriter, r *http.Request) {
+	s.setVolume(w, s.player.Volume()+player.VolumeStep)
+}
+
+// VolumeDownHandler lowers the live volume by player.VolumeStep.
+func (s *Server) VolumeDownHandler(w http.ResponseWriter, r *http.Request) {
+	s.setVolume(w, s.player.Volume()-player.VolumeStep)
+}
+
+// SetVolumeHandler sets the live volume to the "volume" form value (0-100).
+func (s *Server) SetVolumeHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.httpError(w, fmt.Errorf("SetVolumeHandler|ParseForm|%w", err), http.StatusBadRequest)
+		return
+	}
+	vol, err := strconv.Atoi(r.PostForm.Get("volume"))
+	if err != nil {
+		s.httpError(w, fmt.Errorf("SetVolumeHandler|volume|%w", err), http.StatusBadRequest)
+		return
+	}
+	s.setVolume(w, vol)
+}
+
+func (s *Server) setVolume(w http.ResponseWriter, vol int) {
+	if err := s.player.SetVolume(vol); err != nil {
+		s.httpError(w, fmt.Errorf("SetVolume|%w", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]int{"volume": s.player.Volume()})
+}
+
 // StopSongHandler stops the current playback.
 func (s *Server) StopSongHandler(w http.ResponseWriter, r *http.Request) {
 	s.player.Stop()
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/downloader"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/model"
+)
+
+// ImportPlaylistHandler queues every video in a YouTube playlist for download
+// and puts the songs on the "rfid" card in playlist order. Importing a
+// playlist again syncs it instead.
+func (s *Server) ImportPlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.httpError(w, fmt.Errorf("ImportPlaylistHandler|ParseForm|%w", err), http.StatusBadRequest)
+		return
+	}
+	url := normalizeVideoURL(r.PostForm.Get("url"))
+	if url == "" {
+		s.httpError(w, fmt.Errorf("ImportPlaylistHandler|%w", downloader.ErrMissingURL), http.StatusBadRequest)
+		return
+	}
+	rfid := normalizeRFID(r.PostForm.Get("rfid"))
+	if rfid != "" {
+		if _, err := s.db.GetCommandCard(rfid); err == nil {
+			s.httpError(w, fmt.Errorf("ImportPlaylistHandler|card %s is a command card", rfid), http.StatusConflict)
+			return
+		} else if !errors.Is(err, db.ErrNotFound) {
+			s.httpError(w, fmt.Errorf("ImportPlaylistHandler|GetCommandCard|%w", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	p, err := s.playlistByURL(url)
+	if err != nil {
+		s.httpError(w, fmt.Errorf("ImportPlaylistHandler|%w", err), http.StatusInternalServerError)
+		return
+	}
+	if p == nil {
+		p = &model.Playlist{ID: uuid.New().String(), URL: url}
+	}
+	if rfid != "" {
+		p.RFID = rfid
+	}
+	if _, err := s.syncPlaylist(r.Context(), p); err != nil {
+		s.httpError(w, fmt.Errorf("ImportPlaylistHandler|%w", err), playlistErrorCode(err))
+		return
+	}
+	http.Redirect(w, r, "/downloads", http.StatusFound)
+}
+
+// SyncPlaylistHandler reads a saved playlist again, queuing new videos.
+func (s *Server) SyncPlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	p, err := s.db.GetPlaylist(r.PathValue("playlist_id"))
+	if err != nil {
+		s.httpError(w, fmt.Errorf("SyncPlaylistHandler|GetPlaylist|%w", err), playlistErrorCode(err))
+		return
+	}
+	p, err = s.syncPlaylist(r.Context(), p)
+	if err != nil {
+		s.httpError(w, fmt.Errorf("SyncPlaylistHandler|%w", err), playlistErrorCode(err))
+		return
+	}
+	writeJSON(w, p)
+}
+
+// ListPlaylistsHandler returns the imported playlists as JSON.
+func (s *Server) ListPlaylistsHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := s.db.ListPlaylists()
+	if err != nil {
+		s.httpError(w, fmt.Errorf("ListPlaylistsHandler|ListPlaylists|%w", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, list)
+}
+
+func playlistErrorCode(err error) int {
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errNoPlaylistSupport):
+		return http.StatusNotImplemented
+	case errors.Is(err, downloader.ErrNotPlaylist):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+var errNoPlaylistSupport = errors.New("downloader cannot expand playlists")
+
+func (s *Server) playlistByURL(url string) (*model.Playlist, error) {
+	list, err := s.db.ListPlaylists()
+	if err != nil {
+		return nil, fmt.Errorf("ListPlaylists|%w", err)
+	}
+	for _, p := range list {
+		if p.URL == url {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// syncPlaylist reads p's videos from YouTube, queues the ones not yet in the
+// library, saves p and updates its card. Videos removed from the playlist are
+// dropped from p but stay in the library and on the card.
+func (s *Server) syncPlaylist(ctx context.Context, p *model.Playlist) (*model.Playlist, error) {
+	expander, ok := s.downloader.(downloader.PlaylistExpander)
+	if !ok {
+		return nil, errNoPlaylistSupport
+	}
+	expanded, err := expander.ExpandPlaylist(ctx, p.URL)
+	if err != nil {
+		return nil, fmt.Errorf("ExpandPlaylist|%w", err)
+	}
+
+	s.playlistMu.Lock()
+	defer s.playlistMu.Unlock()
+	// Keep song IDs found by an earlier sync or a finished download.
+	if saved, err := s.db.GetPlaylist(p.ID); err == nil {
+		p.Entries = saved.Entries
+	} else if !errors.Is(err, db.ErrNotFound) {
+		return nil, fmt.Errorf("GetPlaylist|%w", err)
+	}
+	known := make(map[string]string, len(p.Entries))
+	for _, e := range p.Entries {
+		known[e.URL] = e.SongID
+	}
+	p.Title = expanded.Title
+	p.SyncedAt = time.Now()
+	p.Entries = make([]model.PlaylistEntry, 0, len(expanded.Entries))
+	for _, e := range expanded.Entries {
+		url := normalizeVideoURL(e.URL)
+		p.Entries = append(p.Entries, model.PlaylistEntry{URL: url, Title: e.Title, SongID: known[url]})
+	}
+
+	byURL, err := s.songIDsByURL()
+	if err != nil {
+		return nil, err
+	}
+	for i, e := range p.Entries {
+		if e.SongID == "" {
+			p.Entries[i].SongID = byURL[e.URL]
+		}
+	}
+	for _, e := range p.Entries {
+		if e.SongID != "" {
+			continue
+		}
+		if _, err := s.jobs.Enqueue(e.URL, "", true); err != nil {
+			return nil, fmt.Errorf("Enqueue|%w", err)
+		}
+	}
+	if err := s.db.SavePlaylist(p); err != nil {
+		return nil, fmt.Errorf("SavePlaylist|%w", err)
+	}
+	if err := s.syncPlaylistCardLocked(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// songIDsByURL maps the source URL of every downloaded song to its ID.
+func (s *Server) songIDsByURL() (map[string]string, error) {
+	songs, err := s.db.ListSongs()
+	if err != nil {
+		return nil, fmt.Errorf("ListSongs|%w", err)
+	}
+	byURL := make(map[string]string, len(songs))
+	for _, song := range songs {
+		if song.URL != "" && song.FilePath != "" {
+			byURL[song.URL] = song.ID
+		}
+	}
+	return byURL, nil
+}
+
+// syncPlaylistCardLocked adds p's downloaded songs to its card in playlist
+// order. It stops at the first video still downloading so later videos that
+// finish first cannot jump ahead; videos whose download failed are skipped.
+func (s *Server) syncPlaylistCardLocked(p *model.Playlist) error {
+	if p.RFID == "" {
+		return nil
+	}
+	jobList, err := s.jobs.List()
+	if err != nil {
+		return fmt.Errorf("ListJobs|%w", err)
+	}
+	pending := make(map[string]bool)
+	for _, job := range jobList {
+		if job.Active() {
+			pending[job.URL] = true
+		}
+	}
+	onCard := make(map[string]bool)
+	card, err := s.db.GetRFIDSong(p.RFID)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("GetRFIDSong|%w", err)
+	}
+	if card != nil {
+		for _, id := range card.Songs {
+			onCard[id] = true
+		}
+	}
+	for _, e := range p.Entries {
+		if e.SongID == "" {
+			if pending[e.URL] {
+				break
+			}
+			continue
+		}
+		if onCard[e.SongID] {
+			continue
+		}
+		if err := s.db.AddRFIDSong(p.RFID, e.SongID); err != nil {
+			return fmt.Errorf("AddRFIDSong|%w", err)
+		}
+		onCard[e.SongID] = true
+		s.libraryChanged(events.CardAssigned, e.SongID, p.RFID)
+	}
+	return nil
+}
+
+// playlistJobFinished records a finished download in the playlists that
+// contain it and moves their cards along.
+func (s *Server) playlistJobFinished(job *model.DownloadJob) {
+	s.playlistMu.Lock()
+	defer s.playlistMu.Unlock()
+	list, err := s.db.ListPlaylists()
+	if err != nil {
+		s.logger.Error("playlistJobFinished|ListPlaylists", "err", err)
+		return
+	}
+	for _, p := range list {
+		found := false
+		for i, e := range p.Entries {
+			if e.URL == job.URL {
+				found = true
+				if e.SongID == "" {
+					p.Entries[i].SongID = job.SongID
+				}
+			}
+		}
+		if !found {
+			continue
+		}
+		if err := s.db.SavePlaylist(p); err != nil {
+			s.logger.Error("playlistJobFinished|SavePlaylist", "playlist", p.ID, "err", err)
+			continue
+		}
+		if err := s.syncPlaylistCardLocked(p); err != nil {
+			s.logger.Error("playlistJobFinished|syncPlaylistCard", "playlist", p.ID, "err", err)
+		}
+	}
+}
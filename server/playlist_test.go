@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/downloader"
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportPlaylist(t *testing.T) {
+	const listURL = "https://www.youtube.com/playlist?list=PL1"
+	dl := &downloader.MockDownloader{
+		Response: map[string]*downloader.Metadata{
+			"https://youtu.be/b": {Title: "B"},
+			"https://youtu.be/c": {Title: "C"},
+			"https://youtu.be/d": {Title: "D"},
+		},
+		Playlists: map[string]*downloader.Playlist{listURL: {
+			Title: "Road trip",
+			Entries: []downloader.PlaylistEntry{
+				{ID: "a", URL: "https://youtu.be/a", Title: "A"},
+				{ID: "b", URL: "https://youtu.be/b", Title: "B"},
+				{ID: "c", URL: "https://youtu.be/c", Title: "C"},
+			},
+		}},
+	}
+	mockDB := &db.MockDB{
+		GetRFIDSongErr:  db.ErrNotFound,
+		ListSongsResult: []*model.Song{{ID: "song-a", URL: "https://youtu.be/a", FilePath: "a.mp3"}},
+	}
+	var mu sync.Mutex
+	var card []string
+	mockDB.OnAddRFIDSong = func(rfid, songID string) {
+		mu.Lock()
+		defer mu.Unlock()
+		card = append(card, songID)
+		mockDB.GetRFIDSongResult, mockDB.GetRFIDSongErr = &model.RFIDSong{RFID: rfid, Songs: append([]string(nil), card...)}, nil
+	}
+	cardSongs := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), card...)
+	}
+	s := &Server{ctx: context.Background(), db: mockDB, logger: log.NewNoOpLogger(), downloader: dl}
+	startJobs(t, s)
+
+	req := httptest.NewRequest(http.MethodPost, "/playlist", strings.NewReader("url="+listURL+"&rfid=AB:CD"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	s.ImportPlaylistHandler(w, req)
+	require.Equal(t, http.StatusFound, w.Code, w.Body.String())
+	assert.Equal(t, "/downloads", w.Header().Get("Location"))
+
+	// a is already in the library; b and c finish in either order but land
+	// on the card in playlist order.
+	require.Eventually(t, func() bool { return len(cardSongs()) == 3 }, 2*time.Second, time.Millisecond)
+	assert.Equal(t, 2, mockDB.CreateSongCallCount())
+	got := cardSongs()
+	assert.Equal(t, "song-a", got[0])
+	list, err := mockDB.ListPlaylists()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	p := list[0]
+	assert.Equal(t, "Road trip", p.Title)
+	assert.Equal(t, "ABCD", p.RFID)
+	for i, e := range p.Entries {
+		assert.Equal(t, got[i], e.SongID, e.URL)
+	}
+
+	// Syncing downloads only the new video.
+	dl.Playlists[listURL].Entries = append(dl.Playlists[listURL].Entries,
+		downloader.PlaylistEntry{ID: "d", URL: "https://youtu.be/d", Title: "D"})
+	req = httptest.NewRequest(http.MethodPost, "/playlists/"+p.ID+"/sync", nil)
+	req.SetPathValue("playlist_id", p.ID)
+	w = httptest.NewRecorder()
+	s.SyncPlaylistHandler(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var synced model.Playlist
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&synced))
+	assert.Len(t, synced.Entries, 4)
+
+	require.Eventually(t, func() bool { return len(cardSongs()) == 4 }, 2*time.Second, time.Millisecond)
+	assert.Equal(t, 3, mockDB.CreateSongCallCount())
+}
+
+func TestImportPlaylistErrors(t *testing.T) {
+	mockDB := &db.MockDB{GetCommandCardResult: &model.CommandCard{RFID: "CMD"}}
+	s := &Server{db: mockDB, logger: log.NewNoOpLogger(), downloader: &downloader.MockDownloader{}}
+
+	post := func(form string) string {
+		req := httptest.NewRequest(http.MethodPost, "/playlist", strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s.ImportPlaylistHandler(w, req)
+		return w.Body.String()
+	}
+	assert.Contains(t, post("url="), downloader.ErrMissingURL.Error())
+	assert.Contains(t, post("url=https://youtu.be/x&rfid=CMD"), "command card")
+
+	mockDB.GetCommandCardResult = nil
+	assert.Contains(t, post("url=https://youtu.be/x"), downloader.ErrNotPlaylist.Error())
+	assert.Equal(t, http.StatusBadRequest, playlistErrorCode(downloader.ErrNotPlaylist))
+	assert.Equal(t, http.StatusNotImplemented, playlistErrorCode(errNoPlaylistSupport))
+}
@@ -6,6 +6,8 @@ import (
 	"html/template"
 	"net/http"
 	"os"
+
+	"github.com/jaredwarren/rpi_music/events"
 )
 
 func (s *Server) PrintHandler(w http.ResponseWriter, r *http.Request) {
@@ -23,7 +25,8 @@ func (s *Server) PrintHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if thumbMissing {
+	// Uploaded songs and streams have nowhere to fetch a thumbnail from.
+	if thumbMissing && song.URL != "" && !song.IsStream() {
 		v, err := s.downloader.GetVideo(song.URL)
 		if err != nil {
 			s.httpError(w, fmt.Errorf("PrintHandler|GetVideo|%w", err), http.StatusInternalServerError)
@@ -39,6 +42,7 @@ func (s *Server) PrintHandler(w http.ResponseWriter, r *http.Request) {
 			s.httpError(w, fmt.Errorf("PrintHandler|UpdateSong|%w", err), http.StatusInternalServerError)
 			return
 		}
+		s.libraryChanged(events.SongUpdated, song.ID, "")
 	}
 
 	s.render(w, r, s.templates["print"], map[string]any{
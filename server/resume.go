@@ -0,0 +1,83 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/model"
+)
+
+// songPosition is the JSON shape of a song's resume setting and saved position.
+type songPosition struct {
+	Resume          bool    `json:"resume"`
+	PositionSeconds float64 `json:"position_seconds"`
+}
+
+// SongPositionHandler reports whether a song resumes and where it would resume from.
+func (s *Server) SongPositionHandler(w http.ResponseWriter, r *http.Request) {
+	song, ok := s.getSongFromPath(w, r, "song_id")
+	if !ok {
+		return
+	}
+	s.writeSongPosition(w, song)
+}
+
+// SetSongResumeHandler saves the "resume" form value on a song. Songs that
+// resume continue from where they last stopped; others always start over.
+func (s *Server) SetSongResumeHandler(w http.ResponseWriter, r *http.Request) {
+	song, ok := s.getSongFromPath(w, r, "song_id")
+	if !ok {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		s.httpError(w, fmt.Errorf("SetSongResumeHandler|ParseForm|%w", err), http.StatusBadRequest)
+		return
+	}
+	v := r.PostForm.Get("resume")
+	song.Resume = v == "on" || v == "true"
+	if err := s.db.UpdateSong(song); err != nil {
+		s.httpError(w, fmt.Errorf("SetSongResumeHandler|UpdateSong|%w", err), http.StatusInternalServerError)
+		return
+	}
+	if err := s.forgetPositionUnlessResuming(song); err != nil {
+		s.httpError(w, fmt.Errorf("SetSongResumeHandler|%w", err), http.StatusInternalServerError)
+		return
+	}
+	s.libraryChanged(events.SongUpdated, song.ID, "")
+	s.writeSongPosition(w, song)
+}
+
+// forgetPositionUnlessResuming clears the saved position of a song that
+// always starts over, so turning resume back on later starts fresh.
+func (s *Server) forgetPositionUnlessResuming(song *model.Song) error {
+	if song.Resume {
+		return nil
+	}
+	if err := s.db.SavePosition(song.ID, 0); err != nil {
+		return fmt.Errorf("SavePosition|%w", err)
+	}
+	return nil
+}
+
+// ResetSongPositionHandler forgets where a song stopped so it next plays from the start.
+func (s *Server) ResetSongPositionHandler(w http.ResponseWriter, r *http.Request) {
+	song, ok := s.getSongFromPath(w, r, "song_id")
+	if !ok {
+		return
+	}
+	if err := s.db.SavePosition(song.ID, 0); err != nil {
+		s.httpError(w, fmt.Errorf("ResetSongPositionHandler|SavePosition|%w", err), http.StatusInternalServerError)
+		return
+	}
+	s.writeSongPosition(w, song)
+}
+
+func (s *Server) writeSongPosition(w http.ResponseWriter, song *model.Song) {
+	pos, err := s.db.GetPosition(song.ID)
+	if err != nil {
+		s.httpError(w, fmt.Errorf("GetPosition|%w", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, songPosition{Resume: song.Resume, PositionSeconds: pos.Seconds()})
+}
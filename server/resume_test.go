@@ -0,0 +1,48 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSongResumeHandlers(t *testing.T) {
+	song := &model.Song{ID: "book", FilePath: "book.mp3"}
+	mockDB := &db.MockDB{GetSongResult: song}
+	require.NoError(t, mockDB.SavePosition("book", 90*time.Second))
+	s := &Server{db: mockDB, logger: log.NewNoOpLogger()}
+
+	call := func(handler http.HandlerFunc, form url.Values) songPosition {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/song/book/resume", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetPathValue("song_id", "book")
+		w := httptest.NewRecorder()
+		handler(w, req)
+		var got songPosition
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&got), w.Body.String())
+		return got
+	}
+
+	got := call(s.SetSongResumeHandler, url.Values{"resume": {"true"}})
+	assert.Equal(t, songPosition{Resume: true, PositionSeconds: 90}, got)
+	assert.True(t, mockDB.LastUpdateSongCall().Resume)
+
+	got = call(s.ResetSongPositionHandler, nil)
+	assert.Equal(t, songPosition{Resume: true}, got)
+
+	// Turning resume off forgets the position too.
+	require.NoError(t, mockDB.SavePosition("book", time.Minute))
+	got = call(s.SetSongResumeHandler, url.Values{})
+	assert.Equal(t, songPosition{}, got)
+}
@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/events"
 	"github.com/jaredwarren/rpi_music/model"
+	"github.com/jaredwarren/rpi_music/rfid"
 )
 
 func (s *Server) EditRFIDSongFormHandler(w http.ResponseWriter, r *http.Request) {
@@ -19,7 +22,9 @@ func (s *Server) EditRFIDSongFormHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	rfidMap := map[string][]*model.Song{}
+	playback := map[string]model.PlaybackOptions{}
 	for _, entry := range rfids {
+		playback[entry.RFID] = entry.Playback
 		rfidMap[entry.RFID] = []*model.Song{}
 		for _, sid := range entry.Songs {
 			song, err := s.db.GetSong(sid)
@@ -30,12 +35,80 @@ func (s *Server) EditRFIDSongFormHandler(w http.ResponseWriter, r *http.Request)
 			rfidMap[entry.RFID] = append(rfidMap[entry.RFID], song)
 		}
 	}
+	commands, err := s.db.ListCommandCards()
+	if err != nil {
+		s.httpError(w, fmt.Errorf("EditRFIDSongFormHandler|ListCommandCards|%w", err), http.StatusInternalServerError)
+		return
+	}
+	songs, err := s.listSongsWithRFID()
+	if err != nil {
+		s.httpError(w, fmt.Errorf("EditRFIDSongFormHandler|%w", err), http.StatusInternalServerError)
+		return
+	}
 	s.render(w, r, s.templates["editRfid"], map[string]any{
-		"Rfids":     rfidMap,
-		TemplateTag: template.HTML(""),
+		"Rfids":        rfidMap,
+		"Playback":     playback,
+		"CommandCards": commands,
+		"Commands":     model.Commands,
+		"UnknownTags":  s.recentUnknownTags(),
+		"Songs":        songs,
+		TemplateTag:    template.HTML(""),
 	})
 }
 
+// SetCommandCardHandler makes the "rfid" card run "command" when tapped.
+// "minutes" sets the delay for the sleep command; empty or 0 stops after the current song.
+func (s *Server) SetCommandCardHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.httpError(w, fmt.Errorf("SetCommandCardHandler|ParseForm|%w", err), http.StatusBadRequest)
+		return
+	}
+	rfid := strings.TrimSpace(r.PostForm.Get("rfid"))
+	if rfid == "" {
+		s.httpError(w, fmt.Errorf("rfid required"), http.StatusBadRequest)
+		return
+	}
+	cmd, err := model.ParseCommand(r.PostForm.Get("command"))
+	if err != nil {
+		s.httpError(w, err, http.StatusBadRequest)
+		return
+	}
+	card := &model.CommandCard{RFID: rfid, Command: cmd}
+	if v := r.PostForm.Get("minutes"); v != "" && cmd == model.CommandSleep {
+		minutes, err := strconv.Atoi(v)
+		if err != nil || minutes < 0 {
+			s.httpError(w, fmt.Errorf("minutes must be 0 or more"), http.StatusBadRequest)
+			return
+		}
+		card.SleepMinutes = minutes
+	}
+	// A song mapping would be shadowed, since command cards are checked first.
+	if existing, err := s.db.GetRFIDSong(rfid); err == nil && existing != nil && len(existing.Songs) > 0 {
+		s.httpError(w, fmt.Errorf("card %s already has songs; unassign them first", rfid), http.StatusConflict)
+		return
+	} else if err != nil && !errors.Is(err, db.ErrNotFound) {
+		s.httpError(w, fmt.Errorf("SetCommandCardHandler|GetRFIDSong|%w", err), http.StatusInternalServerError)
+		return
+	}
+	if err := s.db.SetCommandCard(card); err != nil {
+		s.httpError(w, fmt.Errorf("SetCommandCardHandler|SetCommandCard|%w", err), http.StatusInternalServerError)
+		return
+	}
+	s.libraryChanged(events.CardAssigned, "", rfid)
+	http.Redirect(w, r, "/rfids", http.StatusFound)
+}
+
+// DeleteCommandCardHandler turns a command card back into a plain card.
+func (s *Server) DeleteCommandCardHandler(w http.ResponseWriter, r *http.Request) {
+	rfid := r.PathValue("rfid")
+	if err := s.db.DeleteCommandCard(rfid); err != nil {
+		s.httpError(w, fmt.Errorf("DeleteCommandCardHandler|DeleteCommandCard|%w", err), http.StatusInternalServerError)
+		return
+	}
+	s.libraryChanged(events.CardRemoved, "", rfid)
+	writeJSON(w, map[string]any{"ok": true})
+}
+
 func (s *Server) UnassignRFIDSongHandler(w http.ResponseWriter, r *http.Request) {
 	songID := r.PathValue("song_id")
 	if songID == "" {
@@ -52,6 +125,41 @@ func (s *Server) UnassignRFIDSongHandler(w http.ResponseWriter, r *http.Request)
 		s.httpError(w, fmt.Errorf("UnassignRFIDSongHandler|RemoveRFIDSong|%w", err), http.StatusBadRequest)
 		return
 	}
+	s.libraryChanged(events.CardRemoved, songID, rfid)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+}
+
+// SetRFIDPlaybackHandler saves the shuffle and loop options for a card.
+func (s *Server) SetRFIDPlaybackHandler(w http.ResponseWriter, r *http.Request) {
+	rfid := r.PathValue("rfid")
+	if rfid == "" {
+		s.httpError(w, fmt.Errorf("rfid required"), http.StatusBadRequest)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		s.httpError(w, fmt.Errorf("ParseForm|%w", err), http.StatusBadRequest)
+		return
+	}
+
+	loop, err := model.ParseLoopMode(r.PostForm.Get("loop"))
+	if err != nil {
+		s.httpError(w, err, http.StatusBadRequest)
+		return
+	}
+	opts := model.PlaybackOptions{
+		Shuffle: r.PostForm.Get("shuffle") == "on",
+		Loop:    loop,
+	}
+	if err := s.db.SetRFIDPlayback(rfid, opts); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			s.httpError(w, fmt.Errorf("rfid not found"), http.StatusNotFound)
+			return
+		}
+		s.httpError(w, fmt.Errorf("SetRFIDPlaybackHandler|SetRFIDPlayback|%w", err), http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
@@ -73,8 +181,9 @@ func (s *Server) AssignRFIDToSongFormHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 	s.render(w, r, s.templates["assignSong"], map[string]any{
-		"Song":      song,
-		TemplateTag: template.HTML(""),
+		"Song":         song,
+		"CanWriteTags": s.tags != nil,
+		TemplateTag:    template.HTML(""),
 	})
 }
 
@@ -105,6 +214,13 @@ func (s *Server) AssignRFIDToSongHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if _, err := s.db.GetCommandCard(rfid); err == nil {
+		s.httpError(w, fmt.Errorf("card %s is a command card", rfid), http.StatusConflict)
+		return
+	} else if !errors.Is(err, db.ErrNotFound) {
+		s.httpError(w, fmt.Errorf("AssignRFIDToSongHandler|GetCommandCard|%w", err), http.StatusInternalServerError)
+		return
+	}
 	rfidSong, err := s.db.GetRFIDSong(rfid)
 	if err != nil && !errors.Is(err, db.ErrNotFound) {
 		s.logger.Error("AssignRFIDToSongHandler|GetRFIDSong", "err", err)
@@ -121,6 +237,33 @@ func (s *Server) AssignRFIDToSongHandler(w http.ResponseWriter, r *http.Request)
 		s.httpError(w, fmt.Errorf("AddRFIDSong: %w", err), http.StatusInternalServerError)
 		return
 	}
+	s.libraryChanged(events.CardAssigned, song.ID, rfid)
 
 	http.Redirect(w, r, "/songs", http.StatusFound)
 }
+
+// WriteSongTagHandler arms the tag writer to store the song's ID on the next
+// blank tag read. The result is reported as an SSE notification.
+func (s *Server) WriteSongTagHandler(w http.ResponseWriter, r *http.Request) {
+	song, ok := s.getSongFromPath(w, r, "song_id")
+	if !ok {
+		return
+	}
+	if s.tags == nil {
+		s.httpError(w, rfid.ErrWriteUnsupported, http.StatusNotImplemented)
+		return
+	}
+	msg := rfid.EncodeNDEF(rfid.TextRecord(song.ID))
+	err := s.tags.WriteNDEF(msg, func(uid string, err error) {
+		if err != nil {
+			s.notify("Tag write failed", err.Error())
+			return
+		}
+		s.notify("Tag written", fmt.Sprintf("%s (%s)", song.Title, uid))
+	})
+	if err != nil {
+		s.httpError(w, fmt.Errorf("WriteSongTagHandler|WriteNDEF|%w", err), http.StatusNotImplemented)
+		return
+	}
+	http.Redirect(w, r, "/songs", http.StatusFound)
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/jaredwarren/rpi_music/rfid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTagWriter records the message it was asked to write.
+type fakeTagWriter struct {
+	msg  []byte
+	done func(uid string, err error)
+}
+
+func (f *fakeTagWriter) WriteNDEF(msg []byte, done func(uid string, err error)) error {
+	f.msg, f.done = msg, done
+	return nil
+}
+
+func TestWriteSongTagHandler(t *testing.T) {
+	tags := &fakeTagWriter{}
+	s := &Server{
+		db:     &db.MockDB{GetSongResult: &model.Song{ID: "song-1", Title: "Song"}},
+		logger: log.NewNoOpLogger(),
+		tags:   tags,
+		bus:    events.NewBus(0),
+	}
+	evs := s.bus.Subscribe(events.Notification).C
+
+	req := httptest.NewRequest(http.MethodPost, "/song/song-1/rfid/write", nil)
+	req.SetPathValue("song_id", "song-1")
+	w := httptest.NewRecorder()
+	s.WriteSongTagHandler(w, req)
+	require.Equal(t, http.StatusFound, w.Code)
+
+	records, err := rfid.ParseNDEF(tags.msg)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	text, ok := records[0].Text()
+	require.True(t, ok)
+	assert.Equal(t, "song-1", text)
+
+	tags.done("04a1b2", nil)
+	ev := <-evs
+	assert.Equal(t, events.Notice{Title: "Tag written", Body: "Song (04a1b2)"}, ev.Data)
+}
+
+func TestWriteSongTagHandlerWithoutWriter(t *testing.T) {
+	s := &Server{db: &db.MockDB{GetSongResult: &model.Song{ID: "song-1"}}, logger: log.NewNoOpLogger()}
+	req := httptest.NewRequest(http.MethodPost, "/song/song-1/rfid/write", nil)
+	req.SetPathValue("song_id", "song-1")
+	w := httptest.NewRecorder()
+	s.WriteSongTagHandler(w, req)
+	assert.Contains(t, w.Body.String(), rfid.ErrWriteUnsupported.Error())
+}
+
+func TestCardCannotBeSongAndCommand(t *testing.T) {
+	mockDB := &db.MockDB{
+		GetSongResult:        &model.Song{ID: "song-1"},
+		GetRFIDSongResult:    &model.RFIDSong{RFID: "ABCD", Songs: []string{"song-2"}},
+		GetCommandCardResult: &model.CommandCard{RFID: "CMD", Command: model.CommandStop},
+	}
+	s := &Server{db: mockDB, logger: log.NewNoOpLogger()}
+
+	form := url.Values{"rfid": {"ABCD"}, "command": {string(model.CommandStop)}}
+	req := httptest.NewRequest(http.MethodPost, "/rfid/command", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	s.SetCommandCardHandler(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "already has songs")
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	require.NoError(t, mw.WriteField("rfid", "CMD"))
+	require.NoError(t, mw.Close())
+	req = httptest.NewRequest(http.MethodPost, "/song/song-1/rfid", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.SetPathValue("song_id", "song-1")
+	w = httptest.NewRecorder()
+	s.AssignRFIDToSongHandler(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "command card")
+	assert.Zero(t, mockDB.AddRFIDSongCallCount())
+}
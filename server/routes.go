@@ -9,7 +9,10 @@ import (
 	"time"
 
 	"github.com/jaredwarren/rpi_music/config"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/model"
 	"github.com/jaredwarren/rpi_music/player"
+	"github.com/jaredwarren/rpi_music/rfid"
 )
 
 // Config provides the settings needed to start the HTTP server.
@@ -21,10 +24,14 @@ type Config struct {
 	Db           Store
 	Logger       *slog.Logger
 	Player       *player.Player
+	Sleep        *player.SleepTimer
+	TagWriter    TagWriter // nil when the tag source cannot write tags
+	Events       *events.Bus
 }
 
 // HTMLServer is the running HTTP server lifecycle handle.
 type HTMLServer struct {
+	srv    *Server
 	server *http.Server
 	wg     sync.WaitGroup
 	logger *slog.Logger
@@ -41,11 +48,12 @@ func StartHTTPServer(cfg *Config) (*HTMLServer, error) {
 	}
 	serverCtx, cancel := context.WithCancel(serverCtx)
 
-	s, err := New(serverCtx, cfg.AppConfig, cfg.Db, cfg.Player, cfg.Logger)
+	s, err := New(serverCtx, cfg.AppConfig, cfg.Db, cfg.Player, cfg.Sleep, cfg.Events, cfg.Logger)
 	if err != nil {
 		cancel()
 		return nil, fmt.Errorf("StartHTTPServer|New|%w", err)
 	}
+	s.tags = cfg.TagWriter
 
 	mux := http.NewServeMux()
 	s.registerRoutes(mux)
@@ -54,6 +62,7 @@ func StartHTTPServer(cfg *Config) (*HTMLServer, error) {
 	handler = s.loggingMiddleware(handler)
 
 	htmlServer := HTMLServer{
+		srv:    s,
 		logger: cfg.Logger,
 		cancel: cancel,
 		server: &http.Server{
@@ -65,6 +74,18 @@ func StartHTTPServer(cfg *Config) (*HTMLServer, error) {
 		},
 	}
 
+	htmlServer.wg.Add(1)
+	go func() {
+		defer htmlServer.wg.Done()
+		s.jobs.Run(serverCtx)
+	}()
+
+	htmlServer.wg.Add(1)
+	go func() {
+		defer htmlServer.wg.Done()
+		s.pollFeeds(serverCtx)
+	}()
+
 	htmlServer.wg.Add(1)
 	go func() {
 		defer htmlServer.wg.Done()
@@ -89,6 +110,18 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	// Misc
 	mux.HandleFunc("POST /log", s.withError(s.LogE))
 	mux.HandleFunc("GET /stop", s.StopSongHandler)
+	mux.HandleFunc("GET /pause", s.PauseSongHandler)
+	mux.HandleFunc("GET /resume", s.ResumeSongHandler)
+	mux.HandleFunc("GET /volume/up", s.VolumeUpHandler)
+	mux.HandleFunc("GET /volume/down", s.VolumeDownHandler)
+	mux.HandleFunc("POST /volume", s.SetVolumeHandler)
+	mux.HandleFunc("GET /next", s.NextSongHandler)
+	mux.HandleFunc("GET /previous", s.PreviousSongHandler)
+	mux.HandleFunc("GET /chapter/next", s.NextChapterHandler)
+	mux.HandleFunc("GET /chapter/previous", s.PreviousChapterHandler)
+
+	// JSON API
+	s.registerAPIRoutes(mux)
 
 	// Songs list
 	mux.HandleFunc("GET /", s.ListSongHandler)
@@ -98,14 +131,30 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /rfids", s.EditRFIDSongFormHandler)
 	mux.HandleFunc("DELETE /rfid/{rfid}/{song_id}", s.UnassignRFIDSongHandler)
 	mux.HandleFunc("GET /rfid/{rfid}/json", s.JSONGetSongByRFID)
+	mux.HandleFunc("GET /rfid/{rfid}/play", s.PlayRFIDHandler)
+	mux.HandleFunc("POST /rfid/{rfid}/playback", s.SetRFIDPlaybackHandler)
+	mux.HandleFunc("POST /rfid/command", s.SetCommandCardHandler)
+	mux.HandleFunc("DELETE /rfid/{rfid}/command", s.DeleteCommandCardHandler)
 
 	// Song — new
 	mux.HandleFunc("GET /song/new", s.NewSongFormHandler)
 	mux.HandleFunc("POST /song/new", s.withError(s.NewSongHandlerE))
 	mux.HandleFunc("POST /song", s.withError(s.NewSongHandlerE))
+	mux.HandleFunc("POST /song/upload", s.withError(s.UploadSongHandlerE))
+	mux.HandleFunc("POST /stream", s.withError(s.AddStreamHandlerE))
 
 	// Song — download (async)
 	mux.HandleFunc("POST /download", s.withError(s.DownloadSongE))
+	mux.HandleFunc("GET /downloads", s.DownloadsHandler)
+	mux.HandleFunc("POST /downloads/{job_id}/cancel", s.CancelDownloadHandler)
+	mux.HandleFunc("POST /downloads/{job_id}/retry", s.RetryDownloadHandler)
+	mux.HandleFunc("GET /playlists", s.ListPlaylistsHandler)
+	mux.HandleFunc("POST /playlist", s.ImportPlaylistHandler)
+	mux.HandleFunc("POST /playlists/{playlist_id}/sync", s.SyncPlaylistHandler)
+	mux.HandleFunc("GET /feeds", s.ListFeedsHandler)
+	mux.HandleFunc("POST /feeds", s.AddFeedHandler)
+	mux.HandleFunc("POST /feeds/{feed_id}/sync", s.SyncFeedHandler)
+	mux.HandleFunc("DELETE /feeds/{feed_id}", s.DeleteFeedHandler)
 
 	// SSE for browser notifications
 	mux.HandleFunc("GET /events", s.EventsSSE)
@@ -113,6 +162,11 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	// Song — RFID assignment
 	mux.HandleFunc("GET /song/{song_id}/rfid", s.AssignRFIDToSongFormHandler)
 	mux.HandleFunc("POST /song/{song_id}/rfid", s.AssignRFIDToSongHandler)
+	mux.HandleFunc("POST /song/{song_id}/rfid/write", s.WriteSongTagHandler)
+	mux.HandleFunc("POST /song/{song_id}/rfid/learn", s.StartLearnHandler)
+	mux.HandleFunc("GET /rfid/learn", s.LearnStatusHandler)
+	mux.HandleFunc("GET /rfid/unknown", s.UnknownTagsHandler)
+	mux.HandleFunc("POST /rfid/learn/cancel", s.CancelLearnHandler)
 
 	// Song — actions
 	mux.HandleFunc("DELETE /song/{song_id}", s.withError(s.DeleteSongHandlerE))
@@ -121,7 +175,12 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /song/{song_id}/stop", s.StopSongHandler)
 	mux.HandleFunc("GET /song/{song_id}/play_video", s.PlayVideoHandler)
 	mux.HandleFunc("GET /song/{song_id}/redownload", s.RedownloadSongAssetsHandler)
+	mux.HandleFunc("POST /song/{song_id}/volume", s.SetSongVolumeOffsetHandler)
+	mux.HandleFunc("GET /song/{song_id}/position", s.SongPositionHandler)
+	mux.HandleFunc("POST /song/{song_id}/resume", s.SetSongResumeHandler)
+	mux.HandleFunc("POST /song/{song_id}/position/reset", s.ResetSongPositionHandler)
 	mux.HandleFunc("GET /song/{song_id}/print", s.PrintHandler)
+	mux.HandleFunc("POST /song/{song_id}/split", s.withError(s.SplitChaptersHandlerE))
 	mux.HandleFunc("GET /song/{song_id}/json", s.JSONHandler)
 	mux.HandleFunc("GET /song/json", s.JSONHandler)
 
@@ -131,6 +190,10 @@ func (s *Server) registerRoutes(mux *http.ServeMux) {
 
 	// Player
 	mux.HandleFunc("GET /player/", s.PlayerHandler)
+	mux.HandleFunc("GET /player/status", s.PlayerStatusHandler)
+	mux.HandleFunc("GET /sleep", s.SleepStatusHandler)
+	mux.HandleFunc("POST /sleep", s.StartSleepHandler)
+	mux.HandleFunc("POST /sleep/cancel", s.CancelSleepHandler)
 
 	// Admin
 	mux.HandleFunc("GET /admin", s.RawHandler)
@@ -150,6 +213,22 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// SongForURL returns the library song for rawURL, downloading it first if
+// needed; see Server.SongForURL.
+func (h *HTMLServer) SongForURL(ctx context.Context, rawURL, rfid string) (*model.Song, error) {
+	return h.srv.SongForURL(ctx, rawURL, rfid)
+}
+
+// TagScanned publishes a scan; see Server.TagScanned.
+func (h *HTMLServer) TagScanned(ev rfid.Event) {
+	h.srv.TagScanned(ev)
+}
+
+// CaptureTag hands a scanned tag to learn mode; see Server.CaptureTag.
+func (h *HTMLServer) CaptureTag(ev rfid.Event) bool {
+	return h.srv.CaptureTag(ev)
+}
+
 // StopHTTPServer gracefully shuts down the HTTP server.
 func (h *HTMLServer) StopHTTPServer() error {
 	const timeout = 5 * time.Second
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/rfid"
+)
+
+// recentUnknownLimit caps how many unknown cards are remembered.
+const recentUnknownLimit = 10
+
+// scanEvent is the data of the events.RFIDScanned event, published for every
+// tag the reader sees.
+type scanEvent struct {
+	UID     string    `json:"uid"`
+	Removed bool      `json:"removed,omitempty"`
+	Known   bool      `json:"known"`             // the card has songs or a command
+	Command string    `json:"command,omitempty"` // set for command cards
+	URL     string    `json:"url,omitempty"`
+	Text    string    `json:"text,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// unknownTag is a recently scanned card with no songs or command.
+type unknownTag struct {
+	UID    string    `json:"uid"`
+	URL    string    `json:"url,omitempty"`
+	Text   string    `json:"text,omitempty"`
+	SeenAt time.Time `json:"seen_at"`
+}
+
+// recentTags remembers the last unknown cards scanned, most recent first.
+type recentTags struct {
+	mu   sync.Mutex
+	tags []unknownTag
+}
+
+func (r *recentTags) add(t unknownTag) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeLocked(t.UID)
+	r.tags = append([]unknownTag{t}, r.tags...)
+	if len(r.tags) > recentUnknownLimit {
+		r.tags = r.tags[:recentUnknownLimit]
+	}
+}
+
+func (r *recentTags) remove(uid string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeLocked(uid)
+}
+
+func (r *recentTags) removeLocked(uid string) {
+	for i, t := range r.tags {
+		if t.UID == uid {
+			r.tags = append(r.tags[:i], r.tags[i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *recentTags) list() []unknownTag {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]unknownTag(nil), r.tags...)
+}
+
+// TagScanned publishes a scan and remembers cards that have no mapping.
+func (s *Server) TagScanned(ev rfid.Event) {
+	se := scanEvent{UID: ev.UID, Removed: ev.Kind == rfid.Removed, URL: ev.URL, Text: ev.Text, Time: time.Now()}
+	known, command, err := s.lookupCard(ev.UID)
+	if err != nil {
+		s.logger.Error("TagScanned|lookupCard", "err", err)
+	}
+	se.Known, se.Command = known, command
+	if !known && err == nil && !se.Removed {
+		s.unknownTags.add(unknownTag{UID: ev.UID, URL: ev.URL, Text: ev.Text, SeenAt: se.Time})
+	}
+	s.bus.Publish(events.RFIDScanned, se)
+}
+
+// lookupCard reports whether uid is a song card or command card, and the
+// command if it is one.
+func (s *Server) lookupCard(uid string) (bool, string, error) {
+	card, err := s.db.GetCommandCard(uid)
+	if err == nil {
+		return true, string(card.Command), nil
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		return false, "", err
+	}
+	rs, err := s.db.GetRFIDSong(uid)
+	if errors.Is(err, db.ErrNotFound) {
+		return false, "", nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+	return rs != nil, "", nil
+}
+
+// recentUnknownTags lists recently scanned unknown cards, dropping any that
+// have been assigned since.
+func (s *Server) recentUnknownTags() []unknownTag {
+	tags := s.unknownTags.list()
+	out := make([]unknownTag, 0, len(tags))
+	for _, t := range tags {
+		if known, _, err := s.lookupCard(t.UID); err == nil && known {
+			s.unknownTags.remove(t.UID)
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// UnknownTagsHandler lists recently scanned cards that have no mapping.
+func (s *Server) UnknownTagsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.recentUnknownTags())
+}
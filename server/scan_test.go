@@ -0,0 +1,62 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/jaredwarren/rpi_music/rfid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagScannedBroadcastsAndRemembersUnknown(t *testing.T) {
+	mockDB := &db.MockDB{GetRFIDSongErr: db.ErrNotFound}
+	s := &Server{db: mockDB, logger: log.NewNoOpLogger(), bus: events.NewBus(0)}
+	evs := s.bus.Subscribe(events.RFIDScanned).C
+
+	s.TagScanned(rfid.Event{UID: "aa", URL: "https://youtu.be/x"})
+	ev := <-evs
+	assert.Equal(t, events.RFIDScanned, ev.Type)
+	se := ev.Data.(scanEvent)
+	assert.Equal(t, "aa", se.UID)
+	assert.False(t, se.Known)
+	assert.Equal(t, "https://youtu.be/x", se.URL)
+
+	s.TagScanned(rfid.Event{UID: "aa", Kind: rfid.Removed})
+	assert.True(t, (<-evs).Data.(scanEvent).Removed)
+
+	w := httptest.NewRecorder()
+	s.UnknownTagsHandler(w, httptest.NewRequest(http.MethodGet, "/rfid/unknown", nil))
+	assert.Contains(t, w.Body.String(), `"uid":"aa"`)
+
+	// Once the card is assigned it is known and drops off the list.
+	mockDB.GetRFIDSongErr = nil
+	mockDB.GetRFIDSongResult = &model.RFIDSong{RFID: "aa", Songs: []string{"song-1"}}
+	s.TagScanned(rfid.Event{UID: "aa"})
+	assert.True(t, (<-evs).Data.(scanEvent).Known)
+	assert.Empty(t, s.recentUnknownTags())
+
+	mockDB.GetCommandCardResult = &model.CommandCard{RFID: "aa", Command: model.CommandStop}
+	s.TagScanned(rfid.Event{UID: "aa"})
+	assert.Equal(t, "stop", (<-evs).Data.(scanEvent).Command)
+}
+
+func TestRecentTagsKeepsNewestUnique(t *testing.T) {
+	var r recentTags
+	for i := range recentUnknownLimit + 2 {
+		r.add(unknownTag{UID: fmt.Sprint(i)})
+	}
+	r.add(unknownTag{UID: "5"})
+
+	tags := r.list()
+	require.Len(t, tags, recentUnknownLimit)
+	assert.Equal(t, "5", tags[0].UID)
+	assert.Equal(t, "11", tags[1].UID)
+	assert.Equal(t, "2", tags[len(tags)-1].UID)
+}
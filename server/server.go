@@ -9,24 +9,37 @@ import (
 
 	"github.com/jaredwarren/rpi_music/config"
 	"github.com/jaredwarren/rpi_music/downloader"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/jobs"
+	"github.com/jaredwarren/rpi_music/media"
 	"github.com/jaredwarren/rpi_music/player"
 )
 
 // Server is the application handler with all dependencies injected.
 type Server struct {
-	ctx          context.Context
-	cfg          *config.Config
-	db           Store
-	logger       *slog.Logger
-	downloader   downloader.Downloader
-	player       *player.Player
-	templates    map[string]*template.Template
-	notifySubsMu sync.Mutex
-	notifySubs   map[chan notifyEvent]struct{}
+	ctx         context.Context
+	cfg         *config.Config
+	db          Store
+	logger      *slog.Logger
+	downloader  downloader.Downloader
+	prober      *media.Prober
+	analyzer    *media.Analyzer
+	player      *player.Player
+	sleep       *player.SleepTimer
+	tags        TagWriter
+	learnMu     sync.Mutex
+	learn       *learnMode // armed learn mode, or nil
+	unknownTags recentTags
+	downloads   downloadTracker
+	jobs        *jobs.Queue
+	playlistMu  sync.Mutex // serialises playlist and playlist card updates
+	feedMu      sync.Mutex // serialises feed and feed card updates
+	templates   map[string]*template.Template
+	bus         *events.Bus
 }
 
 // New constructs a Server with all dependencies.
-func New(ctx context.Context, cfg *config.Config, database Store, p *player.Player, l *slog.Logger) (*Server, error) {
+func New(ctx context.Context, cfg *config.Config, database Store, p *player.Player, sleep *player.SleepTimer, bus *events.Bus, l *slog.Logger) (*Server, error) {
 	var dl downloader.Downloader
 	if cfg.Downloader == "ytdl" {
 		dl = &downloader.YoutubeDownloader{
@@ -47,15 +60,30 @@ func New(ctx context.Context, cfg *config.Config, database Store, p *player.Play
 		dl = ytdl
 	}
 
+	if bus == nil {
+		bus = events.NewBus(events.DefaultHistory)
+	}
 	srv := &Server{
 		ctx:        ctx,
 		cfg:        cfg,
 		db:         database,
 		logger:     l,
 		downloader: dl,
+		prober:     &media.Prober{},
+		analyzer:   &media.Analyzer{},
 		player:     p,
-		notifySubs: make(map[chan notifyEvent]struct{}),
+		sleep:      sleep,
+		bus:        bus,
 	}
+	sleep.OnUpdate(srv.publishSleep)
+	srv.jobs = jobs.New(database, srv.runDownloadJob, jobs.Config{
+		Workers:     cfg.Downloads.Workers,
+		MaxAttempts: cfg.Downloads.MaxAttempts,
+		Backoff:     cfg.Downloads.Backoff.Duration,
+		Retention:   cfg.Downloads.Retention.Duration,
+		Events:      bus,
+		OnFinish:    srv.downloadJobFinished,
+	}, l)
 	srv.templates = srv.loadTemplates()
 	return srv, nil
 }
@@ -11,10 +11,12 @@ import (
 	"runtime"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jaredwarren/rpi_music/db"
 	"github.com/jaredwarren/rpi_music/downloader"
+	"github.com/jaredwarren/rpi_music/events"
 	"github.com/jaredwarren/rpi_music/model"
 )
 
@@ -55,7 +57,9 @@ func (s *Server) tryAssignRFID(rfid, songID string) {
 	}
 	if err := s.db.AddRFIDSong(rfid, songID); err != nil {
 		s.logger.Error("tryAssignRFID|AddRFIDSong", "err", err)
+		return
 	}
+	s.libraryChanged(events.CardAssigned, songID, rfid)
 }
 
 func (s *Server) downloadSong(ctx context.Context, rawURL string, force bool) (*model.Song, error) {
@@ -70,20 +74,78 @@ func (s *Server) downloadSong(ctx context.Context, rawURL string, force bool) (*
 		}
 	}
 
-	filePath, video, err := s.downloader.DownloadVideo(ctx, url, s.logger)
+	s.publishDownload(events.Download{URL: url, State: events.DownloadStarted})
+	filePath, meta, err := s.downloader.DownloadVideo(s.withDownloadProgress(ctx, url), url, s.logger)
 	if err != nil {
+		s.publishDownload(events.Download{URL: url, State: events.DownloadFailed, Error: err.Error()})
 		return nil, fmt.Errorf("DownloadVideo|%w", err)
 	}
 
-	thumb, _ := s.downloader.DownloadThumb(video)
+	thumb, _ := s.downloader.DownloadThumb(meta)
 
-	return &model.Song{
+	song := &model.Song{
 		ID:        uuid.New().String(),
 		URL:       url,
 		Thumbnail: thumb,
 		FilePath:  filePath,
-		Title:     video.Title,
-	}, nil
+	}
+	applyMetadata(song, meta)
+	if d := s.probeDuration(ctx, filePath); d > 0 {
+		song.Duration = d
+	}
+	s.measureLoudness(ctx, song, filePath)
+	s.publishDownload(events.Download{URL: url, State: events.DownloadFinished, Percent: 100, SongID: song.ID, Title: song.Title})
+	return song, nil
+}
+
+// applyMetadata copies the downloader's non-empty fields onto song.
+func applyMetadata(song *model.Song, meta *downloader.Metadata) {
+	if meta == nil {
+		return
+	}
+	if meta.Title != "" {
+		song.Title = meta.Title
+	}
+	if meta.Artist != "" {
+		song.Artist = meta.Artist
+	}
+	if meta.Album != "" {
+		song.Album = meta.Album
+	}
+	if meta.Extractor != "" {
+		song.Extractor = meta.Extractor
+	}
+	if meta.Duration > 0 {
+		song.Duration = meta.Duration
+	}
+	if len(meta.Chapters) > 0 {
+		song.Chapters = make([]model.Chapter, len(meta.Chapters))
+		for i, c := range meta.Chapters {
+			song.Chapters[i] = model.Chapter{Title: c.Title, Start: c.Start, End: c.End}
+		}
+	}
+}
+
+// probeDuration returns the file's duration, or 0 if ffprobe cannot read it.
+func (s *Server) probeDuration(ctx context.Context, filePath string) time.Duration {
+	d, err := s.prober.Duration(ctx, filePath)
+	if err != nil {
+		s.logger.Warn("probeDuration", "file", filePath, "err", err)
+		return 0
+	}
+	return d
+}
+
+// measureLoudness stores the file's loudness and normalisation gain on song.
+// On failure the song keeps a zero gain and plays unadjusted.
+func (s *Server) measureLoudness(ctx context.Context, song *model.Song, filePath string) {
+	l, err := s.analyzer.Loudness(ctx, filePath)
+	if err != nil {
+		s.logger.Warn("measureLoudness", "file", filePath, "err", err)
+		song.Loudness, song.Gain = 0, 0
+		return
+	}
+	song.Loudness, song.Gain = l.Integrated, l.Gain
 }
 
 func normalizeVideoURL(url string) string {
@@ -147,10 +209,34 @@ func (s *Server) createDownloadedSong(ctx context.Context, rawURL string, force
 	if err := s.db.CreateSong(song); err != nil {
 		return nil, fmt.Errorf("CreateSong|%w", err)
 	}
+	s.libraryChanged(events.SongCreated, song.ID, "")
 	s.tryAssignRFID(rfid, song.ID)
 	return song, nil
 }
 
+// SongForURL returns the library song downloaded from rawURL, or downloads it
+// now. Either way the song is assigned to rfid if that card is unassigned.
+func (s *Server) SongForURL(ctx context.Context, rawURL, rfid string) (*model.Song, error) {
+	url := normalizeVideoURL(rawURL)
+	songs, err := s.db.ListSongs()
+	if err != nil {
+		return nil, fmt.Errorf("ListSongs|%w", err)
+	}
+	for _, song := range songs {
+		if song.URL == url && song.FilePath != "" {
+			s.tryAssignRFID(rfid, song.ID)
+			return song, nil
+		}
+	}
+	song, err := s.createDownloadedSong(ctx, rawURL, true, rfid)
+	if err != nil {
+		s.notify("Download failed", err.Error())
+		return nil, err
+	}
+	s.notify("Download complete", song.Title)
+	return song, nil
+}
+
 func (s *Server) updateDownloadedSong(ctx context.Context, rawURL string, force bool, rfid string) (*model.Song, error) {
 	song, err := s.downloadSong(ctx, rawURL, force)
 	if err != nil {
@@ -159,28 +245,37 @@ func (s *Server) updateDownloadedSong(ctx context.Context, rawURL string, force
 	if err := s.db.UpdateSong(song); err != nil {
 		return nil, fmt.Errorf("UpdateSong|%w", err)
 	}
+	s.libraryChanged(events.SongUpdated, song.ID, "")
 	s.tryAssignRFID(rfid, song.ID)
 	return song, nil
 }
 
 func (s *Server) redownloadMissingAssets(song *model.Song) error {
+	if song.IsStream() {
+		return nil
+	}
 	videoMissing := pathMissing(song.FilePath)
 	thumbMissing := pathMissing(song.Thumbnail)
 	if !videoMissing && !thumbMissing {
 		return nil
 	}
+	if song.URL == "" {
+		return errNoSourceURL
+	}
 
 	if videoMissing {
-		filePath, video, err := s.downloader.DownloadVideo(s.ctx, song.URL, s.logger)
+		filePath, meta, err := s.downloader.DownloadVideo(s.ctx, song.URL, s.logger)
 		if err != nil {
 			return fmt.Errorf("DownloadVideo|%w", err)
 		}
 		song.FilePath = normalizeAssetPath(filePath, s.songAssetRoot())
-		if video != nil && video.Title != "" {
-			song.Title = video.Title
+		applyMetadata(song, meta)
+		if d := s.probeDuration(s.ctx, filePath); d > 0 {
+			song.Duration = d
 		}
+		s.measureLoudness(s.ctx, song, filePath)
 		if thumbMissing {
-			thumb, err := s.downloader.DownloadThumb(video)
+			thumb, err := s.downloader.DownloadThumb(meta)
 			if err != nil {
 				return fmt.Errorf("DownloadThumb|%w", err)
 			}
@@ -190,11 +285,11 @@ func (s *Server) redownloadMissingAssets(song *model.Song) error {
 	}
 
 	if thumbMissing {
-		video, err := s.downloader.GetVideo(song.URL)
+		meta, err := s.downloader.GetVideo(song.URL)
 		if err != nil {
 			return fmt.Errorf("GetVideo|%w", err)
 		}
-		thumb, err := s.downloader.DownloadThumb(video)
+		thumb, err := s.downloader.DownloadThumb(meta)
 		if err != nil {
 			return fmt.Errorf("DownloadThumb|%w", err)
 		}
@@ -204,6 +299,7 @@ func (s *Server) redownloadMissingAssets(song *model.Song) error {
 	if err := s.db.UpdateSong(song); err != nil {
 		return fmt.Errorf("UpdateSong|%w", err)
 	}
+	s.libraryChanged(events.SongUpdated, song.ID, "")
 	return nil
 }
 
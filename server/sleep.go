@@ -0,0 +1,65 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/player"
+)
+
+// sleepStatus is the JSON shape of GET /sleep and of the "sleep" SSE event.
+type sleepStatus struct {
+	Active           bool    `json:"active"`
+	AfterSong        bool    `json:"after_song"`
+	RemainingSeconds float64 `json:"remaining_seconds"`
+	Paused           bool    `json:"paused"`
+}
+
+func newSleepStatus(st player.SleepStatus) sleepStatus {
+	return sleepStatus{
+		Active:           st.Active,
+		AfterSong:        st.AfterSong,
+		RemainingSeconds: st.Remaining.Seconds(),
+		Paused:           st.Paused,
+	}
+}
+
+// publishSleep publishes the sleep timer's state. It is only called when the
+// state changes, so pages count the time left down themselves.
+func (s *Server) publishSleep(st player.SleepStatus) {
+	s.bus.Publish(events.SleepChanged, newSleepStatus(st))
+}
+
+// SleepStatusHandler reports whether the sleep timer is running and how long is left.
+func (s *Server) SleepStatusHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, newSleepStatus(s.sleep.Status()))
+}
+
+// StartSleepHandler starts the sleep timer. The form value "after" set to
+// "song" stops after the current song; otherwise "minutes" sets the delay.
+func (s *Server) StartSleepHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.httpError(w, fmt.Errorf("StartSleepHandler|ParseForm|%w", err), http.StatusBadRequest)
+		return
+	}
+	if r.PostForm.Get("after") == "song" {
+		s.sleep.StartAfterSong()
+	} else {
+		minutes, err := strconv.Atoi(r.PostForm.Get("minutes"))
+		if err != nil || minutes <= 0 {
+			s.httpError(w, fmt.Errorf("minutes must be a positive number"), http.StatusBadRequest)
+			return
+		}
+		s.sleep.Start(time.Duration(minutes) * time.Minute)
+	}
+	writeJSON(w, newSleepStatus(s.sleep.Status()))
+}
+
+// CancelSleepHandler stops the sleep timer and restores the volume.
+func (s *Server) CancelSleepHandler(w http.ResponseWriter, r *http.Request) {
+	s.sleep.Cancel()
+	writeJSON(w, newSleepStatus(s.sleep.Status()))
+}
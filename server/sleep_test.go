@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/jaredwarren/rpi_music/player"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSleepHandlers(t *testing.T) {
+	p := newNoopPlayer(t)
+	sleep := player.NewSleepTimer(p, 0)
+	t.Cleanup(sleep.Cancel)
+	s := &Server{logger: log.NewNoOpLogger(), player: p, sleep: sleep, bus: events.NewBus(0)}
+	sleep.OnUpdate(s.publishSleep)
+
+	evs := s.bus.Subscribe(events.SleepChanged).C
+
+	post := func(handler http.HandlerFunc, form url.Values) sleepStatus {
+		req := httptest.NewRequest(http.MethodPost, "/sleep", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler(w, req)
+		var st sleepStatus
+		_ = json.NewDecoder(w.Body).Decode(&st)
+		return st
+	}
+
+	st := post(s.StartSleepHandler, url.Values{"minutes": {"20"}})
+	assert.True(t, st.Active)
+	assert.InDelta(t, 1200, st.RemainingSeconds, 1)
+
+	ev := <-evs
+	assert.Equal(t, events.SleepChanged, ev.Type)
+	assert.True(t, ev.Data.(sleepStatus).Active)
+
+	require.NoError(t, p.Play(&model.Song{FilePath: "a.mp3"}))
+	st = post(s.StartSleepHandler, url.Values{"after": {"song"}})
+	assert.True(t, st.AfterSong)
+
+	st = post(s.CancelSleepHandler, nil)
+	assert.False(t, st.Active)
+
+	post(s.StartSleepHandler, url.Values{"minutes": {"soon"}})
+	require.False(t, sleep.Status().Active)
+}
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"strconv"
 
 	"github.com/jaredwarren/rpi_music/db"
 	"github.com/jaredwarren/rpi_music/downloader"
+	"github.com/jaredwarren/rpi_music/events"
 	"github.com/jaredwarren/rpi_music/model"
 )
 
@@ -22,6 +24,16 @@ func writeJSON(w http.ResponseWriter, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
+// cardSongs is the JSON shape of GET /rfid/{rfid}/json. Songs and playback
+// options use the same shapes as /api/v1.
+type cardSongs struct {
+	RFID     string          `json:"rfid"`
+	Songs    []*apiSong      `json:"songs"` // in play order
+	Playback playbackRequest `json:"playback"`
+}
+
+// JSONGetSongByRFID returns every song on a card, in order, with the card's
+// playback options. Songs missing from the library are left out.
 func (s *Server) JSONGetSongByRFID(w http.ResponseWriter, r *http.Request) {
 	rfid := r.PathValue("rfid")
 	if rfid == "" {
@@ -43,16 +55,23 @@ func (s *Server) JSONGetSongByRFID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	song, err := s.db.GetSong(rfidSong.Songs[0])
-	if err != nil {
-		if errors.Is(err, db.ErrNotFound) {
-			writeJSONError(w, "song not found")
+	out := cardSongs{RFID: rfidSong.RFID, Playback: newPlaybackRequest(rfidSong.Playback)}
+	for _, id := range rfidSong.Songs {
+		song, err := s.db.GetSong(id)
+		if errors.Is(err, db.ErrNotFound) || (err == nil && song == nil) {
+			continue
+		}
+		if err != nil {
+			writeJSONError(w, err.Error())
 			return
 		}
-		writeJSONError(w, err.Error())
+		out.Songs = append(out.Songs, newAPISong(song))
+	}
+	if len(out.Songs) == 0 {
+		writeJSONError(w, "song not found")
 		return
 	}
-	writeJSON(w, song)
+	writeJSON(w, out)
 }
 
 func (s *Server) JSONHandler(w http.ResponseWriter, r *http.Request) {
@@ -96,6 +115,7 @@ func (s *Server) ListSongHandler(w http.ResponseWriter, r *http.Request) {
 
 	s.render(w, r, s.templates["index"], map[string]any{
 		"Songs":       songs,
+		"Downloads":   s.downloads.list(),
 		"CurrentSong": s.player.GetPlaying(),
 		"Player":      s.player,
 		TemplateTag:   template.HTML(""),
@@ -109,7 +129,7 @@ func (s *Server) NewSongFormHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// DownloadSong starts a background download and immediately redirects to /songs.
+// DownloadSong queues a download and immediately redirects to /songs.
 func (s *Server) DownloadSong(w http.ResponseWriter, r *http.Request) {
 	if err := s.DownloadSongE(w, r); err != nil {
 		var httpErr *HTTPError
@@ -121,7 +141,7 @@ func (s *Server) DownloadSong(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// DownloadSongE starts a background download and immediately redirects to /songs.
+// DownloadSongE queues a download and immediately redirects to /songs.
 func (s *Server) DownloadSongE(w http.ResponseWriter, r *http.Request) error {
 	if err := r.ParseForm(); err != nil {
 		s.logger.Error("DownloadSong|ParseForm", "err", err)
@@ -135,17 +155,9 @@ func (s *Server) DownloadSongE(w http.ResponseWriter, r *http.Request) error {
 	force := r.PostForm.Get("force") != ""
 	rfid := normalizeRFID(r.PostForm.Get("rfid"))
 
-	go func() {
-		song, err := s.createDownloadedSong(s.ctx, rawURL, force, rfid)
-		if err != nil {
-			s.logger.Error("createDownloadedSong", "err", err)
-			notifyDesktop("Download failed", err.Error())
-			s.notifyBroadcast("Download failed", err.Error())
-			return
-		}
-		notifyDesktop("Download complete", song.Title)
-		s.notifyBroadcast("Download complete", song.Title)
-	}()
+	if _, err := s.jobs.Enqueue(normalizeVideoURL(rawURL), rfid, force); err != nil {
+		return asHTTPError(http.StatusInternalServerError, fmt.Errorf("DownloadSong|Enqueue|%w", err))
+	}
 
 	http.Redirect(w, r, "/songs", http.StatusFound)
 	return nil
@@ -218,6 +230,7 @@ func (s *Server) DeleteSongHandlerE(w http.ResponseWriter, r *http.Request) erro
 	if err := s.db.DeleteSong(songID); err != nil {
 		return asHTTPError(http.StatusInternalServerError, fmt.Errorf("DeleteSongHandler|DeleteSong|%w", err))
 	}
+	s.libraryChanged(events.SongDeleted, songID, "")
 	http.Redirect(w, r, "/songs", http.StatusFound)
 	return nil
 }
@@ -236,6 +249,31 @@ func (s *Server) RedownloadSongAssetsHandler(w http.ResponseWriter, r *http.Requ
 	http.Redirect(w, r, "/songs", http.StatusFound)
 }
 
+// SetSongVolumeOffsetHandler saves the "volume_offset" form value on a song.
+// The offset is added to the player volume whenever the song plays.
+func (s *Server) SetSongVolumeOffsetHandler(w http.ResponseWriter, r *http.Request) {
+	song, ok := s.getSongFromPath(w, r, "song_id")
+	if !ok {
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		s.httpError(w, fmt.Errorf("SetSongVolumeOffsetHandler|ParseForm|%w", err), http.StatusBadRequest)
+		return
+	}
+	offset, err := strconv.Atoi(r.PostForm.Get("volume_offset"))
+	if err != nil {
+		s.httpError(w, fmt.Errorf("SetSongVolumeOffsetHandler|volume_offset|%w", err), http.StatusBadRequest)
+		return
+	}
+	song.VolumeOffset = offset
+	if err := s.db.UpdateSong(song); err != nil {
+		s.httpError(w, fmt.Errorf("SetSongVolumeOffsetHandler|UpdateSong|%w", err), http.StatusInternalServerError)
+		return
+	}
+	s.libraryChanged(events.SongUpdated, song.ID, "")
+	writeJSON(w, song)
+}
+
 func (s *Server) PlayVideoHandler(w http.ResponseWriter, r *http.Request) {
 	song, ok := s.getSongFromPath(w, r, "song_id")
 	if !ok {
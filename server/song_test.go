@@ -2,6 +2,7 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"html/template"
 	"io"
@@ -10,7 +11,6 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -20,7 +20,6 @@ import (
 	"github.com/jaredwarren/rpi_music/log"
 	"github.com/jaredwarren/rpi_music/model"
 	"github.com/jaredwarren/rpi_music/player"
-	"github.com/kkdai/youtube/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -34,12 +33,10 @@ func newTestTemplates() map[string]*template.Template {
 	}
 }
 
-// noopPlayer is a test double that satisfies the player methods used in handlers.
+// newNoopPlayer returns a player backed by an in-memory backend, so no audio binary is needed.
 func newNoopPlayer(t *testing.T) *player.Player {
 	t.Helper()
-	trueBin, err := exec.LookPath("true")
-	require.NoError(t, err, "true binary not found in PATH")
-	p, err := player.New(player.Config{FFPlayBin: trueBin, Beep: false}, log.NewNoOpLogger())
+	p, err := player.NewWithBackend(player.Config{Beep: false}, player.NewFakeBackend(), log.NewNoOpLogger())
 	require.NoError(t, err)
 	return p
 }
@@ -158,13 +155,35 @@ func TestJSONGetSongByRFID(t *testing.T) {
 			name: "success",
 			rfid: "rfid-123",
 			db: &db.MockDB{
-				GetRFIDSongResult: &model.RFIDSong{RFID: "rfid-123", Songs: []string{"song-1"}},
-				GetSongResult:     &model.Song{ID: "song-1", Title: "RFID Song"},
+				GetRFIDSongResult: &model.RFIDSong{
+					RFID:     "rfid-123",
+					Songs:    []string{"song-1", "song-1"},
+					Playback: model.PlaybackOptions{Shuffle: true, Loop: model.LoopQueue},
+				},
+				GetSongResult: &model.Song{ID: "song-1", Title: "RFID Song"},
 			},
 			wantBody: func(t *testing.T, body []byte) {
-				var song model.Song
-				require.NoError(t, json.Unmarshal(body, &song))
-				assert.Equal(t, "song-1", song.ID)
+				var card map[string]any
+				require.NoError(t, json.Unmarshal(body, &card))
+				assert.Equal(t, "rfid-123", card["rfid"])
+				songs, ok := card["songs"].([]any)
+				require.True(t, ok)
+				require.Len(t, songs, 2)
+				assert.Equal(t, "song-1", songs[0].(map[string]any)["id"])
+				assert.Equal(t, map[string]any{"shuffle": true, "loop": string(model.LoopQueue)}, card["playback"])
+			},
+		},
+		{
+			name: "songs missing from the library",
+			rfid: "rfid-123",
+			db: &db.MockDB{
+				GetRFIDSongResult: &model.RFIDSong{RFID: "rfid-123", Songs: []string{"gone"}},
+				GetSongErr:        db.ErrNotFound,
+			},
+			wantBody: func(t *testing.T, body []byte) {
+				var out map[string]string
+				require.NoError(t, json.Unmarshal(body, &out))
+				assert.Equal(t, "song not found", out["error"])
 			},
 		},
 	}
@@ -202,13 +221,13 @@ func TestDeleteSongHandler(t *testing.T) {
 			name:       "missing song_id",
 			songID:     "",
 			db:         &db.MockDB{},
-			wantStatus: http.StatusOK,
+			wantStatus: http.StatusBadRequest,
 		},
 		{
 			name:       "db error",
 			songID:     "song-123",
 			db:         &db.MockDB{DeleteSongErr: assert.AnError},
-			wantStatus: http.StatusOK,
+			wantStatus: http.StatusInternalServerError,
 		},
 		{
 			name:         "success",
@@ -239,10 +258,6 @@ func TestDeleteSongHandler(t *testing.T) {
 }
 
 func TestListSongHandler(t *testing.T) {
-	origWd, err := os.Getwd()
-	require.NoError(t, err)
-	t.Cleanup(func() { _ = os.Chdir(origWd) })
-
 	tests := []struct {
 		name      string
 		setupDB   func(t *testing.T) db.DBer
@@ -284,7 +299,7 @@ func TestListSongHandler(t *testing.T) {
 				db:         tt.setupDB(t),
 				logger:     log.NewNoOpLogger(),
 				player:     p,
-				downloader: &downloader.MockDownloader{Response: map[string]*youtube.Video{}},
+				downloader: &downloader.MockDownloader{Response: map[string]*downloader.Metadata{}},
 				templates:  newTestTemplates(),
 			}
 
@@ -311,9 +326,9 @@ func TestListSongHandler(t *testing.T) {
 
 func TestDownloadSong(t *testing.T) {
 	downloadURL := "https://example.com/watch?v=xyz"
-	mockVideo := &youtube.Video{
-		ID: "test-id", Title: "Downloaded Song Title",
-		Thumbnails: youtube.Thumbnails{{URL: "https://thumb.example.com/img.jpg"}},
+	mockVideo := &downloader.Metadata{
+		ID: "test-id", Title: "Downloaded Song Title", Artist: "Some Band", Album: "Live", Extractor: "Soundcloud",
+		Thumbnails: []downloader.Thumbnail{{URL: "https://thumb.example.com/img.jpg"}},
 	}
 
 	tests := []struct {
@@ -328,13 +343,13 @@ func TestDownloadSong(t *testing.T) {
 		{
 			name:       "ParseForm error",
 			form:       nil,
-			dl:         &downloader.MockDownloader{Response: map[string]*youtube.Video{downloadURL: mockVideo}},
-			wantStatus: http.StatusOK,
+			dl:         &downloader.MockDownloader{Response: map[string]*downloader.Metadata{downloadURL: mockVideo}},
+			wantStatus: http.StatusBadRequest,
 		},
 		{
 			name:         "success redirects and creates song in background",
 			form:         map[string]string{"url": downloadURL, "force": "1"},
-			dl:           &downloader.MockDownloader{Response: map[string]*youtube.Video{downloadURL: mockVideo}},
+			dl:           &downloader.MockDownloader{Response: map[string]*downloader.Metadata{downloadURL: mockVideo}},
 			wantStatus:   http.StatusFound,
 			wantRedirect: "/songs",
 			waitCreate:   true,
@@ -343,12 +358,15 @@ func TestDownloadSong(t *testing.T) {
 				last := mock.LastCreateSongCall()
 				require.NotNil(t, last)
 				assert.Equal(t, "Downloaded Song Title", last.Title)
+				assert.Equal(t, "Some Band", last.Artist)
+				assert.Equal(t, "Live", last.Album)
+				assert.Equal(t, "Soundcloud", last.Extractor)
 			},
 		},
 		{
 			name:         "rfid strips colons and assigns",
 			form:         map[string]string{"url": downloadURL, "force": "1", "rfid": "AB:CD:EF"},
-			dl:           &downloader.MockDownloader{Response: map[string]*youtube.Video{downloadURL: mockVideo}},
+			dl:           &downloader.MockDownloader{Response: map[string]*downloader.Metadata{downloadURL: mockVideo}},
 			wantStatus:   http.StatusFound,
 			wantRedirect: "/songs",
 			waitCreate:   true,
@@ -378,7 +396,8 @@ func TestDownloadSong(t *testing.T) {
 				mockDB.OnAddRFIDSong = func(string, string) { close(assignDone) }
 			}
 
-			s := &Server{db: mockDB, logger: log.NewNoOpLogger(), downloader: tt.dl}
+			s := &Server{ctx: context.Background(), db: mockDB, logger: log.NewNoOpLogger(), downloader: tt.dl}
+			startJobs(t, s)
 
 			var req *http.Request
 			if tt.form != nil {
@@ -414,9 +433,9 @@ func TestDownloadSong(t *testing.T) {
 
 func TestNewSongHandler(t *testing.T) {
 	newSongURL := "https://example.com/v"
-	mockVideo := &youtube.Video{
+	mockVideo := &downloader.Metadata{
 		ID: "new-id", Title: "New Song Title",
-		Thumbnails: youtube.Thumbnails{{URL: "https://thumb.example.com/new.jpg"}},
+		Thumbnails: []downloader.Thumbnail{{URL: "https://thumb.example.com/new.jpg"}},
 	}
 
 	tests := []struct {
@@ -430,7 +449,7 @@ func TestNewSongHandler(t *testing.T) {
 		{
 			name:         "success creates song and redirects",
 			form:         map[string]string{"url": newSongURL, "force": "1"},
-			dl:           &downloader.MockDownloader{Response: map[string]*youtube.Video{newSongURL: mockVideo}},
+			dl:           &downloader.MockDownloader{Response: map[string]*downloader.Metadata{newSongURL: mockVideo}},
 			wantStatus:   http.StatusFound,
 			wantRedirect: "/songs",
 			checkCalls: func(t *testing.T, mock *db.MockDB) {
@@ -441,7 +460,7 @@ func TestNewSongHandler(t *testing.T) {
 		{
 			name:         "rfid strips colons",
 			form:         map[string]string{"url": newSongURL, "force": "1", "rfid": "AA:BB:CC"},
-			dl:           &downloader.MockDownloader{Response: map[string]*youtube.Video{newSongURL: mockVideo}},
+			dl:           &downloader.MockDownloader{Response: map[string]*downloader.Metadata{newSongURL: mockVideo}},
 			wantStatus:   http.StatusFound,
 			wantRedirect: "/songs",
 			checkCalls: func(t *testing.T, mock *db.MockDB) {
@@ -454,7 +473,7 @@ func TestNewSongHandler(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockDB := &db.MockDB{}
-			s := &Server{db: mockDB, logger: log.NewNoOpLogger(), downloader: tt.dl}
+			s := &Server{ctx: context.Background(), db: mockDB, logger: log.NewNoOpLogger(), downloader: tt.dl}
 
 			req := newMultipartRequest(t, http.MethodPost, "/song", tt.form)
 			w := httptest.NewRecorder()
@@ -488,9 +507,10 @@ func TestRedownloadSongAssetsHandler(t *testing.T) {
 			},
 		}
 		s := &Server{
+			ctx:        context.Background(),
 			db:         mockDB,
 			logger:     log.NewNoOpLogger(),
-			downloader: &downloader.MockDownloader{Response: map[string]*youtube.Video{}},
+			downloader: &downloader.MockDownloader{Response: map[string]*downloader.Metadata{}},
 		}
 
 		req := httptest.NewRequest(http.MethodGet, "/song/song-1/redownload", nil)
@@ -517,12 +537,13 @@ func TestRedownloadSongAssetsHandler(t *testing.T) {
 			},
 		}
 		s := &Server{
+			ctx:    context.Background(),
 			db:     mockDB,
 			logger: log.NewNoOpLogger(),
-			downloader: &downloader.MockDownloader{Response: map[string]*youtube.Video{
+			downloader: &downloader.MockDownloader{Response: map[string]*downloader.Metadata{
 				downloadURL: {
 					Title:      "downloaded-video-path.mp4",
-					Thumbnails: youtube.Thumbnails{{URL: "https://thumb.example.com/new.jpg"}},
+					Thumbnails: []downloader.Thumbnail{{URL: "https://thumb.example.com/new.jpg"}},
 				},
 			}},
 		}
@@ -557,12 +578,13 @@ func TestRedownloadSongAssetsHandler(t *testing.T) {
 			},
 		}
 		s := &Server{
+			ctx:    context.Background(),
 			db:     mockDB,
 			logger: log.NewNoOpLogger(),
-			downloader: &downloader.MockDownloader{Response: map[string]*youtube.Video{
+			downloader: &downloader.MockDownloader{Response: map[string]*downloader.Metadata{
 				downloadURL: {
 					Title:      "unused-video-title",
-					Thumbnails: youtube.Thumbnails{{URL: "https://thumb.example.com/thumb-only.jpg"}},
+					Thumbnails: []downloader.Thumbnail{{URL: "https://thumb.example.com/thumb-only.jpg"}},
 				},
 			}},
 		}
@@ -611,27 +633,32 @@ func newMultipartRequest(t *testing.T, method, path string, form map[string]stri
 	return req
 }
 
-func findModuleRoot(t *testing.T) string {
-	t.Helper()
-	dir, err := os.Getwd()
+func initDB(t *testing.T) db.DBer {
+	d, err := db.NewSongDB(filepath.Join(t.TempDir(), "test.db"))
 	require.NoError(t, err)
-	for {
-		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
-			return dir
-		}
-		parent := filepath.Dir(dir)
-		if parent == dir {
-			t.Fatal("go.mod not found")
-		}
-		dir = parent
-	}
+	t.Cleanup(func() { require.NoError(t, d.Close()) })
+	return d
 }
 
-func initDB(t *testing.T) db.DBer {
-	moduleRoot := findModuleRoot(t)
-	require.NoError(t, os.Chdir(moduleRoot))
-	_ = os.Remove("test.db")
-	d, err := db.NewSongDB("test.db")
+func TestSongForURL(t *testing.T) {
+	existing := &model.Song{ID: "old", URL: "https://youtu.be/old", FilePath: "song_files/old.mp3"}
+	mockDB := &db.MockDB{ListSongsResult: []*model.Song{existing}, GetRFIDSongErr: db.ErrNotFound}
+	dl := &downloader.MockDownloader{Response: map[string]*downloader.Metadata{
+		"https://youtu.be/new": {ID: "new", Title: "New Song"},
+	}}
+	s := &Server{ctx: context.Background(), db: mockDB, logger: log.NewNoOpLogger(), downloader: dl}
+
+	song, err := s.SongForURL(context.Background(), "https://youtu.be/old", "UID1")
 	require.NoError(t, err)
-	return d
+	assert.Same(t, existing, song)
+	assert.Zero(t, mockDB.CreateSongCallCount())
+
+	song, err = s.SongForURL(context.Background(), "https://youtu.be/new", "UID2")
+	require.NoError(t, err)
+	assert.Equal(t, "New Song", song.Title)
+	assert.Equal(t, 1, mockDB.CreateSongCallCount())
+
+	require.Equal(t, 2, mockDB.AddRFIDSongCallCount())
+	last, _ := mockDB.LastAddRFIDSongCall()
+	assert.Equal(t, db.AddRFIDSongCall{RFID: "UID2", SongID: song.ID}, last)
 }
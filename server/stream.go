@@ -0,0 +1,58 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/model"
+)
+
+var (
+	errStreamURL   = errors.New("stream URL must be http or https")
+	errStreamTitle = errors.New("stream name required")
+)
+
+// AddStreamHandlerE adds an internet radio stream from the "url" and "title"
+// form values, assigning it to "rfid" if given. Streams play straight from
+// the URL and are never downloaded.
+func (s *Server) AddStreamHandlerE(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseForm(); err != nil {
+		return asHTTPError(http.StatusBadRequest, fmt.Errorf("AddStream|ParseForm|%w", err))
+	}
+	if _, err := s.createStream(r.PostForm.Get("url"), r.PostForm.Get("title"), r.PostForm.Get("rfid")); err != nil {
+		return fmt.Errorf("AddStream|%w", err)
+	}
+	http.Redirect(w, r, "/songs", http.StatusFound)
+	return nil
+}
+
+// createStream saves a stream song and assigns it to rfid if that card is free.
+func (s *Server) createStream(streamURL, title, rfid string) (*model.Song, error) {
+	streamURL = strings.TrimSpace(streamURL)
+	u, err := url.Parse(streamURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, asHTTPError(http.StatusBadRequest, errStreamURL)
+	}
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, asHTTPError(http.StatusBadRequest, errStreamTitle)
+	}
+
+	song := &model.Song{
+		ID:    uuid.New().String(),
+		Kind:  model.SongKindStream,
+		Title: title,
+		URL:   streamURL,
+	}
+	if err := s.db.CreateSong(song); err != nil {
+		return nil, asHTTPError(http.StatusInternalServerError, fmt.Errorf("CreateSong|%w", err))
+	}
+	s.libraryChanged(events.SongCreated, song.ID, "")
+	s.tryAssignRFID(normalizeRFID(rfid), song.ID)
+	return song, nil
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/downloader"
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/jaredwarren/rpi_music/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func streamRequest(form url.Values) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/stream", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestAddStream(t *testing.T) {
+	mockDB := &db.MockDB{GetRFIDSongErr: db.ErrNotFound}
+	s := &Server{db: mockDB, logger: log.NewNoOpLogger()}
+
+	w := httptest.NewRecorder()
+	err := s.AddStreamHandlerE(w, streamRequest(url.Values{
+		"url": {"https://radio.example/live.mp3"}, "title": {"Kids Radio"}, "rfid": {"AB:CD"},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	song := mockDB.LastCreateSongCall()
+	require.NotNil(t, song)
+	assert.True(t, song.IsStream())
+	assert.Equal(t, "Kids Radio", song.Title)
+	assert.Equal(t, "https://radio.example/live.mp3", song.Location())
+	last, ok := mockDB.LastAddRFIDSongCall()
+	require.True(t, ok)
+	assert.Equal(t, "ABCD", last.RFID)
+
+	err = s.AddStreamHandlerE(httptest.NewRecorder(), streamRequest(url.Values{"url": {"file:///etc/passwd"}, "title": {"x"}}))
+	require.ErrorIs(t, err, errStreamURL)
+	err = s.AddStreamHandlerE(httptest.NewRecorder(), streamRequest(url.Values{"url": {"http://radio/x"}}))
+	require.ErrorIs(t, err, errStreamTitle)
+	assert.Equal(t, 1, mockDB.CreateSongCallCount())
+}
+
+func TestRedownloadSkipsStreams(t *testing.T) {
+	s := &Server{logger: log.NewNoOpLogger(), downloader: &downloader.MockDownloader{}}
+	song := &model.Song{Kind: model.SongKindStream, URL: "http://radio/x"}
+	require.NoError(t, s.redownloadMissingAssets(song))
+	assert.Empty(t, song.FilePath)
+}
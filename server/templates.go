@@ -19,6 +19,7 @@ func (s *Server) loadTemplates() map[string]*template.Template {
 		"adminEditSong": template.Must(template.ParseFiles("templates/editSong.html", layout)),
 		"player":        template.Must(template.New("base").ParseFiles("templates/player.html", layout)),
 		"print":         template.Must(template.New("base").ParseFiles("templates/print.html", layout)),
+		"downloads":     template.Must(template.ParseFiles("templates/downloads.html", layout)),
 	}
 	cfgMap := s.cfg.ToMap()
 	configFuncs := template.FuncMap{
@@ -33,6 +34,18 @@ func (s *Server) loadTemplates() map[string]*template.Template {
 			}
 			return template.HTML(fmt.Sprintf(`<input type="checkbox" name="%s" %s><i class="form-icon"></i> %s`, feature, checked, feature))
 		},
+		"ConfigSelect": func(feature string, options ...string) template.HTML {
+			v := fmt.Sprint(cfgMap[feature])
+			var opts string
+			for _, o := range options {
+				selected := ""
+				if o == v {
+					selected = " selected"
+				}
+				opts += fmt.Sprintf(`<option value="%s"%s>%s</option>`, o, selected, o)
+			}
+			return template.HTML(fmt.Sprintf(`<label for="%s">%s</label><select class="form-select" id="%s" name="%s">%s</select>`, feature, feature, feature, feature, opts))
+		},
 		"ConfigInt": func(feature string) template.HTML {
 			v := fmt.Sprint(cfgMap[feature])
 			return template.HTML(fmt.Sprintf(`<label for="%s">%s</label><input class="form-input" id="%s" type="number" placeholder="00" value="%s" name="%s">`, feature, feature, feature, v, feature))
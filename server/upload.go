@@ -0,0 +1,118 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jaredwarren/rpi_music/events"
+	"github.com/jaredwarren/rpi_music/model"
+)
+
+// maxUploadSize bounds an uploaded audio file.
+const maxUploadSize = 512 << 20
+
+// uploadExts are the audio formats the player can play.
+var uploadExts = map[string]bool{
+	".mp3": true, ".m4a": true, ".aac": true, ".ogg": true, ".oga": true,
+	".opus": true, ".flac": true, ".wav": true, ".webm": true,
+}
+
+var (
+	errMissingFile     = errors.New("file required")
+	errUnsupportedFile = errors.New("unsupported audio file type")
+	errNoSourceURL     = errors.New("uploaded song has no URL to download from")
+	errNotAudio        = errors.New("file is not playable audio")
+)
+
+// UploadSongHandlerE adds a local audio file from the "file" form field to
+// the library. Files ffprobe cannot read as audio are rejected. Title, artist,
+// album and cover art come from the file's tags; a "title" form value
+// overrides the tagged title.
+func (s *Server) UploadSongHandlerE(w http.ResponseWriter, r *http.Request) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		return asHTTPError(http.StatusBadRequest, fmt.Errorf("UploadSong|ParseMultipartForm|%w", err))
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return asHTTPError(http.StatusBadRequest, fmt.Errorf("UploadSong|FormFile|%w", errMissingFile))
+	}
+	defer func() { _ = file.Close() }()
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	if !uploadExts[ext] {
+		return asHTTPError(http.StatusBadRequest, fmt.Errorf("UploadSong|%w: %q", errUnsupportedFile, ext))
+	}
+
+	id := uuid.New().String()
+	filePath := filepath.Join(s.songAssetRoot(), id+ext)
+	if err := saveUpload(filePath, file); err != nil {
+		return asHTTPError(http.StatusInternalServerError, fmt.Errorf("UploadSong|saveUpload|%w", err))
+	}
+
+	song := &model.Song{
+		ID:       id,
+		FilePath: normalizeAssetPath(filePath, s.songAssetRoot()),
+		Title:    strings.TrimSuffix(filepath.Base(header.Filename), filepath.Ext(header.Filename)),
+	}
+	info, err := s.prober.Probe(r.Context(), filePath)
+	if err == nil && !info.HasAudio {
+		err = errors.New("no audio stream")
+	}
+	if err != nil {
+		_ = os.Remove(filePath)
+		return asHTTPError(http.StatusBadRequest, fmt.Errorf("UploadSong|Probe|%w: %w", errNotAudio, err))
+	}
+	if info.Title != "" {
+		song.Title = info.Title
+	}
+	if title := strings.TrimSpace(r.PostForm.Get("title")); title != "" {
+		song.Title = title
+	}
+	song.Artist, song.Album, song.Duration = info.Artist, info.Album, info.Duration
+	if info.HasCover {
+		thumb := filepath.Join(s.thumbAssetRoot(), id+".jpg")
+		if err := os.MkdirAll(s.thumbAssetRoot(), 0o755); err != nil {
+			s.logger.Warn("UploadSong|MkdirAll", "dir", s.thumbAssetRoot(), "err", err)
+		} else if err := s.analyzer.ExtractCover(r.Context(), filePath, thumb); err != nil {
+			s.logger.Warn("UploadSong|ExtractCover", "file", filePath, "err", err)
+		} else {
+			song.Thumbnail = normalizeAssetPath(thumb, s.thumbAssetRoot())
+		}
+	}
+	s.measureLoudness(r.Context(), song, filePath)
+
+	if err := s.db.CreateSong(song); err != nil {
+		_ = os.Remove(filePath)
+		return asHTTPError(http.StatusInternalServerError, fmt.Errorf("UploadSong|CreateSong|%w", err))
+	}
+	s.libraryChanged(events.SongCreated, song.ID, "")
+	s.tryAssignRFID(normalizeRFID(r.PostForm.Get("rfid")), song.ID)
+
+	http.Redirect(w, r, "/songs", http.StatusFound)
+	return nil
+}
+
+// saveUpload copies src to a new file at path, removing it on failure.
+func saveUpload(path string, src io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, src)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+	}
+	return err
+}
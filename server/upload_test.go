@@ -0,0 +1,132 @@
+package server
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jaredwarren/rpi_music/config"
+	"github.com/jaredwarren/rpi_music/db"
+	"github.com/jaredwarren/rpi_music/log"
+	"github.com/jaredwarren/rpi_music/media"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func uploadRequest(t *testing.T, filename string, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		require.NoError(t, mw.WriteField(k, v))
+	}
+	if filename != "" {
+		fw, err := mw.CreateFormFile("file", filename)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte("ID3 not really audio"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, mw.Close())
+	req := httptest.NewRequest(http.MethodPost, "/song/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// fakeTool writes an executable shell script to a temp dir and returns its path.
+func fakeTool(t *testing.T, name, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755))
+	return path
+}
+
+// fakeProber reports an untagged audio file, with cover art if cover is set.
+func fakeProber(t *testing.T, cover bool) *media.Prober {
+	streams := `{"codec_type": "audio"}`
+	if cover {
+		streams += `, {"codec_type": "video", "disposition": {"attached_pic": 1}}`
+	}
+	return &media.Prober{Bin: fakeTool(t, "ffprobe", "echo '{\"format\": {\"duration\": \"12.5\"}, \"streams\": ["+streams+"]}'\n")}
+}
+
+func TestUploadSong(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Player.SongRoot = t.TempDir()
+	mockDB := &db.MockDB{GetRFIDSongErr: db.ErrNotFound}
+	s := &Server{cfg: cfg, db: mockDB, logger: log.NewNoOpLogger(), prober: fakeProber(t, false)}
+
+	w := httptest.NewRecorder()
+	err := s.UploadSongHandlerE(w, uploadRequest(t, "Grandma Story.MP3", map[string]string{"rfid": "AB:CD"}))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	song := mockDB.LastCreateSongCall()
+	require.NotNil(t, song)
+	assert.Equal(t, "Grandma Story", song.Title) // no tags, so the file name
+	assert.Equal(t, 12500*time.Millisecond, song.Duration)
+	assert.Empty(t, song.URL)
+	assert.Equal(t, filepath.Join(cfg.Player.SongRoot, song.ID+".mp3"), song.FilePath)
+	data, err := os.ReadFile(song.FilePath)
+	require.NoError(t, err)
+	assert.Equal(t, "ID3 not really audio", string(data))
+	last, ok := mockDB.LastAddRFIDSongCall()
+	require.True(t, ok)
+	assert.Equal(t, "ABCD", last.RFID)
+
+	require.NoError(t, s.UploadSongHandlerE(httptest.NewRecorder(), uploadRequest(t, "x.ogg", map[string]string{"title": "Named"})))
+	assert.Equal(t, "Named", mockDB.LastCreateSongCall().Title)
+}
+
+func TestUploadSongCover(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Player.SongRoot = t.TempDir()
+	cfg.Player.ThumbRoot = filepath.Join(t.TempDir(), "not", "yet", "made")
+	mockDB := &db.MockDB{GetRFIDSongErr: db.ErrNotFound}
+	// The fake ffmpeg only extracts covers; loudness measurement fails and is skipped.
+	ffmpeg := fakeTool(t, "ffmpeg", "for a; do last=$a; done\ncase \"$last\" in *.jpg) echo cover > \"$last\";; *) exit 1;; esac\n")
+	s := &Server{
+		cfg: cfg, db: mockDB, logger: log.NewNoOpLogger(),
+		prober: fakeProber(t, true), analyzer: &media.Analyzer{Bin: ffmpeg},
+	}
+
+	require.NoError(t, s.UploadSongHandlerE(httptest.NewRecorder(), uploadRequest(t, "story.mp3", nil)))
+	song := mockDB.LastCreateSongCall()
+	require.NotNil(t, song)
+	thumb := filepath.Join(cfg.Player.ThumbRoot, song.ID+".jpg")
+	assert.Equal(t, thumb, song.Thumbnail)
+	assert.FileExists(t, thumb)
+}
+
+func TestUploadSongRejects(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Player.SongRoot = t.TempDir()
+	mockDB := &db.MockDB{}
+	s := &Server{cfg: cfg, db: mockDB, logger: log.NewNoOpLogger(), prober: &media.Prober{Bin: fakeTool(t, "ffprobe", "exit 1\n")}}
+
+	err := s.UploadSongHandlerE(httptest.NewRecorder(), uploadRequest(t, "", nil))
+	require.ErrorIs(t, err, errMissingFile)
+	err = s.UploadSongHandlerE(httptest.NewRecorder(), uploadRequest(t, "notes.txt", nil))
+	require.ErrorIs(t, err, errUnsupportedFile)
+	var httpErr *HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+
+	// ffprobe cannot read it, or finds no audio in it.
+	err = s.UploadSongHandlerE(httptest.NewRecorder(), uploadRequest(t, "broken.mp3", nil))
+	require.ErrorIs(t, err, errNotAudio)
+	require.ErrorAs(t, err, &httpErr)
+	assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	s.prober.Bin = fakeTool(t, "ffprobe", "echo '{\"format\": {}, \"streams\": [{\"codec_type\": \"video\"}]}'\n")
+	err = s.UploadSongHandlerE(httptest.NewRecorder(), uploadRequest(t, "video.mp3", nil))
+	require.ErrorIs(t, err, errNotAudio)
+	assert.Equal(t, 0, mockDB.CreateSongCallCount())
+
+	entries, err := os.ReadDir(cfg.Player.SongRoot)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}